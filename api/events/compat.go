@@ -0,0 +1,185 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// schema is the part of JSON Schema the compatibility check understands
+type schema struct {
+	Type                 types              `json:"type"`
+	Format               string             `json:"format"`
+	Enum                 []json.RawMessage  `json:"enum"`
+	Const                json.RawMessage    `json:"const"`
+	Ref                  string             `json:"$ref"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Defs                 map[string]*schema `json:"$defs"`
+	MinLength            *float64           `json:"minLength"`
+	MaxLength            *float64           `json:"maxLength"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+}
+
+// types is the type keyword, a name or a list of them
+type types []string
+
+func (t *types) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = types{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return errors.New("type must be a string or a list of strings")
+	}
+	*t = names
+	return nil
+}
+
+func parse(data []byte) (*schema, error) {
+	var s schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return &s, nil
+}
+
+// Compatible reports the changes from old to current that break consumers
+// written against old, that is everything current allows and old doesn't.
+// Adding optional properties and tightening constraints is compatible,
+// removing or retyping properties, making them optional and changing enum
+// values is not
+func Compatible(old, current []byte) error {
+	o, err := parse(old)
+	if err != nil {
+		return err
+	}
+	c, err := parse(current)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	compare("#", o, c, &errs)
+	for _, name := range sortedKeys(o.Defs) {
+		at := "#/$defs/" + name
+		if c.Defs[name] == nil {
+			errs = append(errs, fmt.Errorf("%s was removed", at))
+			continue
+		}
+		compare(at, o.Defs[name], c.Defs[name], &errs)
+	}
+	return errors.Join(errs...)
+}
+
+func compare(at string, old, current *schema, errs *[]error) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, fmt.Errorf("%s: %s", at, fmt.Sprintf(format, args...)))
+	}
+
+	if old.Ref != current.Ref {
+		fail("$ref changed from %q to %q", old.Ref, current.Ref)
+		return
+	}
+	if len(old.Type) > 0 {
+		if len(current.Type) == 0 {
+			fail("type %v was removed", old.Type)
+		}
+		for _, t := range current.Type {
+			if !slices.Contains(old.Type, t) && !(t == "integer" && slices.Contains(old.Type, "number")) {
+				fail("type %s was added to %v", t, old.Type)
+			}
+		}
+	}
+	if old.Format != "" && old.Format != current.Format {
+		fail("format changed from %q to %q", old.Format, current.Format)
+	}
+	if len(old.Enum) > 0 {
+		if len(current.Enum) == 0 {
+			fail("enum was removed")
+		}
+		for _, v := range current.Enum {
+			if !containsValue(old.Enum, v) {
+				fail("enum value %s was added", v)
+			}
+		}
+		// consumers branch on the values, one that is no longer sent
+		// breaks them as well
+		for _, v := range old.Enum {
+			if len(current.Enum) > 0 && !containsValue(current.Enum, v) {
+				fail("enum value %s was removed", v)
+			}
+		}
+	}
+	if old.Const != nil && !bytes.Equal(old.Const, current.Const) {
+		fail("const changed from %s to %s", old.Const, current.Const)
+	}
+	widened(old.MinLength, current.MinLength, true, "minLength", fail)
+	widened(old.MaxLength, current.MaxLength, false, "maxLength", fail)
+	widened(old.Minimum, current.Minimum, true, "minimum", fail)
+	widened(old.Maximum, current.Maximum, false, "maximum", fail)
+
+	for _, name := range old.Required {
+		if !slices.Contains(current.Required, name) {
+			fail("property %s is no longer required", name)
+		}
+	}
+	for _, name := range sortedKeys(old.Properties) {
+		if current.Properties[name] == nil {
+			fail("property %s was removed", name)
+			continue
+		}
+		compare(at+"/properties/"+name, old.Properties[name], current.Properties[name], errs)
+	}
+	if old.AdditionalProperties != nil && !*old.AdditionalProperties {
+		if current.AdditionalProperties == nil || *current.AdditionalProperties {
+			fail("additional properties are allowed")
+		}
+		for _, name := range sortedKeys(current.Properties) {
+			if old.Properties[name] == nil {
+				fail("property %s was added to a closed object", name)
+			}
+		}
+	}
+
+	if old.Items != nil {
+		if current.Items == nil {
+			fail("items were removed")
+			return
+		}
+		compare(at+"/items", old.Items, current.Items, errs)
+	}
+}
+
+// widened fails when current lets through values a lower or upper bound of
+// old rejected
+func widened(old, current *float64, lower bool, keyword string, fail func(format string, args ...any)) {
+	switch {
+	case old == nil:
+	case current == nil:
+		fail("%s %v was removed", keyword, *old)
+	case lower && *current < *old, !lower && *current > *old:
+		fail("%s changed from %v to %v", keyword, *old, *current)
+	}
+}
+
+func containsValue(values []json.RawMessage, value json.RawMessage) bool {
+	return slices.ContainsFunc(values, func(v json.RawMessage) bool { return bytes.Equal(v, value) })
+}
+
+func sortedKeys(m map[string]*schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
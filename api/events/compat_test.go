@@ -0,0 +1,131 @@
+package events
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testdata/baseline holds the published schema versions. A new version is
+// copied there once it is released, published ones never change
+func TestCheckBaseline(t *testing.T) {
+	if err := Check(os.DirFS("testdata/baseline")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	old, err := os.ReadFile("testdata/baseline/goods-event/v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(s map[string]any)
+		// breaks is part of the error, empty when the change is compatible
+		breaks string
+	}{
+		{
+			name:   "unchanged",
+			change: func(s map[string]any) {},
+		},
+		{
+			name: "optional property added",
+			change: func(s map[string]any) {
+				properties(s)["note"] = map[string]any{"type": "string"}
+			},
+		},
+		{
+			name: "constraint tightened",
+			change: func(s map[string]any) {
+				property(goods(s), "name")["maxLength"] = 100
+			},
+		},
+		{
+			name: "required property removed",
+			change: func(s map[string]any) {
+				delete(properties(s), "goods_id")
+				s["required"] = without(s["required"], "goods_id")
+			},
+			breaks: "property goods_id was removed",
+		},
+		{
+			name: "required property made optional",
+			change: func(s map[string]any) {
+				s["required"] = without(s["required"], "goods_id")
+			},
+			breaks: "property goods_id is no longer required",
+		},
+		{
+			name: "type changed",
+			change: func(s map[string]any) {
+				property(goods(s), "priority")["type"] = "string"
+			},
+			breaks: "#/$defs/goods/properties/priority: type string was added",
+		},
+		{
+			name: "enum narrowed",
+			change: func(s map[string]any) {
+				p := property(s, "type")
+				p["enum"] = without(p["enum"], "goods.reprioritized")
+			},
+			breaks: `enum value "goods.reprioritized" was removed`,
+		},
+		{
+			name: "enum widened",
+			change: func(s map[string]any) {
+				p := property(s, "type")
+				p["enum"] = append(p["enum"].([]any), "goods.archived")
+			},
+			breaks: `enum value "goods.archived" was added`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s map[string]any
+			if err := json.Unmarshal(old, &s); err != nil {
+				t.Fatal(err)
+			}
+			tt.change(s)
+			current, err := json.Marshal(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = Compatible(old, current)
+			switch {
+			case tt.breaks == "" && err != nil:
+				t.Errorf("Compatible() = %v, want nil", err)
+			case tt.breaks != "" && err == nil:
+				t.Errorf("Compatible() = nil, want %q", tt.breaks)
+			case tt.breaks != "" && !strings.Contains(err.Error(), tt.breaks):
+				t.Errorf("Compatible() = %v, want %q", err, tt.breaks)
+			}
+		})
+	}
+}
+
+func properties(s map[string]any) map[string]any {
+	return s["properties"].(map[string]any)
+}
+
+func property(s map[string]any, name string) map[string]any {
+	return properties(s)[name].(map[string]any)
+}
+
+func goods(s map[string]any) map[string]any {
+	return s["$defs"].(map[string]any)["goods"].(map[string]any)
+}
+
+func without(list any, value string) []any {
+	var kept []any
+	for _, v := range list.([]any) {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,137 @@
+// Package events is the catalog of the events the service publishes, with
+// the JSON Schemas of their data. A published schema version never breaks,
+// see Check, incompatible changes get a new version
+package events
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+//go:embed schemas
+var files embed.FS
+
+// Schema is one version of the data of some event types
+type Schema struct {
+	Name    string   `json:"name"`
+	Version int      `json:"version"`
+	Types   []string `json:"types"`
+}
+
+// Catalog lists every published schema, oldest versions first. Events are
+// published with the last version of their type
+var Catalog = []Schema{
+	{
+		Name:    "goods-event",
+		Version: 1,
+		Types:   []string{"goods.created", "goods.updated", "goods.removed", "goods.reprioritized"},
+	},
+	{
+		Name:    "config-reload",
+		Version: 1,
+		Types:   []string{"config.reloaded"},
+	},
+}
+
+// Path is the path of the schema below the schemas root, like goods-event/v1
+func (s Schema) Path() string {
+	return fmt.Sprintf("%s/v%d", s.Name, s.Version)
+}
+
+// File returns the JSON Schema document
+func (s Schema) File() ([]byte, error) {
+	return files.ReadFile("schemas/" + s.Path() + ".json")
+}
+
+// Lookup finds a schema by name and version
+func Lookup(name string, version int) (Schema, bool) {
+	for _, s := range Catalog {
+		if s.Name == name && s.Version == version {
+			return s, true
+		}
+	}
+	return Schema{}, false
+}
+
+// Current returns the last schema of the event type
+func Current(eventType string) (Schema, bool) {
+	var current Schema
+	var ok bool
+	for _, s := range Catalog {
+		for _, t := range s.Types {
+			if t == eventType {
+				current, ok = s, true
+			}
+		}
+	}
+	return current, ok
+}
+
+// SchemaURL returns the dataschema of the event types under base, the URL
+// /api/events/schemas is served at
+func SchemaURL(base string) func(eventType string) (string, bool) {
+	base = strings.TrimSuffix(base, "/")
+	return func(eventType string) (string, bool) {
+		s, ok := Current(eventType)
+		if !ok {
+			return "", false
+		}
+		return base + "/" + s.Path(), true
+	}
+}
+
+// Check verifies that every schema of the catalog has a valid document and
+// that the schemas of an earlier release in baseline, laid out like the
+// schemas directory, are still there and accept what the current ones
+// allow. A nil baseline only checks the catalog
+func Check(baseline fs.FS) error {
+	var errs []error
+	for _, s := range Catalog {
+		data, err := s.File()
+		if err == nil {
+			_, err = parse(data)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Path(), err))
+		}
+	}
+	if baseline == nil {
+		return errors.Join(errs...)
+	}
+
+	published, err := fs.Glob(baseline, "*/v*.json")
+	if err != nil {
+		return err
+	}
+	for _, file := range published {
+		name := path.Dir(file)
+		var version int
+		if _, err := fmt.Sscanf(path.Base(file), "v%d.json", &version); err != nil {
+			errs = append(errs, fmt.Errorf("%s: not a schema version", file))
+			continue
+		}
+		s, ok := Lookup(name, version)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: published schema was removed", file))
+			continue
+		}
+
+		old, err := fs.ReadFile(baseline, file)
+		if err != nil {
+			return err
+		}
+		current, err := s.File()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+			continue
+		}
+		if err := Compatible(old, current); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file, err))
+		}
+	}
+	return errors.Join(errs...)
+}
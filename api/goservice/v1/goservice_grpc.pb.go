@@ -0,0 +1,543 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.1
+// source: goservice/v1/goservice.proto
+
+package goservicev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ProjectsService_CreateProject_FullMethodName = "/goservice.v1.ProjectsService/CreateProject"
+	ProjectsService_GetProject_FullMethodName    = "/goservice.v1.ProjectsService/GetProject"
+	ProjectsService_ListProjects_FullMethodName  = "/goservice.v1.ProjectsService/ListProjects"
+	ProjectsService_UpdateProject_FullMethodName = "/goservice.v1.ProjectsService/UpdateProject"
+	ProjectsService_DeleteProject_FullMethodName = "/goservice.v1.ProjectsService/DeleteProject"
+)
+
+// ProjectsServiceClient is the client API for ProjectsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ProjectsServiceClient interface {
+	CreateProject(ctx context.Context, in *CreateProjectRequest, opts ...grpc.CallOption) (*CreateProjectResponse, error)
+	GetProject(ctx context.Context, in *GetProjectRequest, opts ...grpc.CallOption) (*Project, error)
+	ListProjects(ctx context.Context, in *ListProjectsRequest, opts ...grpc.CallOption) (*ListProjectsResponse, error)
+	// UpdateProject changes the fields that are set
+	UpdateProject(ctx context.Context, in *UpdateProjectRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// DeleteProject fails with ABORTED while the project still has goods
+	DeleteProject(ctx context.Context, in *DeleteProjectRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type projectsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProjectsServiceClient(cc grpc.ClientConnInterface) ProjectsServiceClient {
+	return &projectsServiceClient{cc}
+}
+
+func (c *projectsServiceClient) CreateProject(ctx context.Context, in *CreateProjectRequest, opts ...grpc.CallOption) (*CreateProjectResponse, error) {
+	out := new(CreateProjectResponse)
+	err := c.cc.Invoke(ctx, ProjectsService_CreateProject_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectsServiceClient) GetProject(ctx context.Context, in *GetProjectRequest, opts ...grpc.CallOption) (*Project, error) {
+	out := new(Project)
+	err := c.cc.Invoke(ctx, ProjectsService_GetProject_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectsServiceClient) ListProjects(ctx context.Context, in *ListProjectsRequest, opts ...grpc.CallOption) (*ListProjectsResponse, error) {
+	out := new(ListProjectsResponse)
+	err := c.cc.Invoke(ctx, ProjectsService_ListProjects_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectsServiceClient) UpdateProject(ctx context.Context, in *UpdateProjectRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ProjectsService_UpdateProject_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *projectsServiceClient) DeleteProject(ctx context.Context, in *DeleteProjectRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, ProjectsService_DeleteProject_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProjectsServiceServer is the server API for ProjectsService service.
+// All implementations must embed UnimplementedProjectsServiceServer
+// for forward compatibility
+type ProjectsServiceServer interface {
+	CreateProject(context.Context, *CreateProjectRequest) (*CreateProjectResponse, error)
+	GetProject(context.Context, *GetProjectRequest) (*Project, error)
+	ListProjects(context.Context, *ListProjectsRequest) (*ListProjectsResponse, error)
+	// UpdateProject changes the fields that are set
+	UpdateProject(context.Context, *UpdateProjectRequest) (*emptypb.Empty, error)
+	// DeleteProject fails with ABORTED while the project still has goods
+	DeleteProject(context.Context, *DeleteProjectRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedProjectsServiceServer()
+}
+
+// UnimplementedProjectsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedProjectsServiceServer struct {
+}
+
+func (UnimplementedProjectsServiceServer) CreateProject(context.Context, *CreateProjectRequest) (*CreateProjectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateProject not implemented")
+}
+func (UnimplementedProjectsServiceServer) GetProject(context.Context, *GetProjectRequest) (*Project, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProject not implemented")
+}
+func (UnimplementedProjectsServiceServer) ListProjects(context.Context, *ListProjectsRequest) (*ListProjectsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProjects not implemented")
+}
+func (UnimplementedProjectsServiceServer) UpdateProject(context.Context, *UpdateProjectRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProject not implemented")
+}
+func (UnimplementedProjectsServiceServer) DeleteProject(context.Context, *DeleteProjectRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteProject not implemented")
+}
+func (UnimplementedProjectsServiceServer) mustEmbedUnimplementedProjectsServiceServer() {}
+
+// UnsafeProjectsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ProjectsServiceServer will
+// result in compilation errors.
+type UnsafeProjectsServiceServer interface {
+	mustEmbedUnimplementedProjectsServiceServer()
+}
+
+func RegisterProjectsServiceServer(s grpc.ServiceRegistrar, srv ProjectsServiceServer) {
+	s.RegisterService(&ProjectsService_ServiceDesc, srv)
+}
+
+func _ProjectsService_CreateProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectsServiceServer).CreateProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectsService_CreateProject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectsServiceServer).CreateProject(ctx, req.(*CreateProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectsService_GetProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectsServiceServer).GetProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectsService_GetProject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectsServiceServer).GetProject(ctx, req.(*GetProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectsService_ListProjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectsServiceServer).ListProjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectsService_ListProjects_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectsServiceServer).ListProjects(ctx, req.(*ListProjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectsService_UpdateProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectsServiceServer).UpdateProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectsService_UpdateProject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectsServiceServer).UpdateProject(ctx, req.(*UpdateProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProjectsService_DeleteProject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteProjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProjectsServiceServer).DeleteProject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProjectsService_DeleteProject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProjectsServiceServer).DeleteProject(ctx, req.(*DeleteProjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ProjectsService_ServiceDesc is the grpc.ServiceDesc for ProjectsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ProjectsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "goservice.v1.ProjectsService",
+	HandlerType: (*ProjectsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateProject",
+			Handler:    _ProjectsService_CreateProject_Handler,
+		},
+		{
+			MethodName: "GetProject",
+			Handler:    _ProjectsService_GetProject_Handler,
+		},
+		{
+			MethodName: "ListProjects",
+			Handler:    _ProjectsService_ListProjects_Handler,
+		},
+		{
+			MethodName: "UpdateProject",
+			Handler:    _ProjectsService_UpdateProject_Handler,
+		},
+		{
+			MethodName: "DeleteProject",
+			Handler:    _ProjectsService_DeleteProject_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "goservice/v1/goservice.proto",
+}
+
+const (
+	GoodsService_CreateGoods_FullMethodName       = "/goservice.v1.GoodsService/CreateGoods"
+	GoodsService_GetGoods_FullMethodName          = "/goservice.v1.GoodsService/GetGoods"
+	GoodsService_ListGoods_FullMethodName         = "/goservice.v1.GoodsService/ListGoods"
+	GoodsService_UpdateGoods_FullMethodName       = "/goservice.v1.GoodsService/UpdateGoods"
+	GoodsService_DeleteGoods_FullMethodName       = "/goservice.v1.GoodsService/DeleteGoods"
+	GoodsService_ReprioritizeGoods_FullMethodName = "/goservice.v1.GoodsService/ReprioritizeGoods"
+)
+
+// GoodsServiceClient is the client API for GoodsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GoodsServiceClient interface {
+	CreateGoods(ctx context.Context, in *CreateGoodsRequest, opts ...grpc.CallOption) (*CreateGoodsResponse, error)
+	GetGoods(ctx context.Context, in *GetGoodsRequest, opts ...grpc.CallOption) (*Goods, error)
+	ListGoods(ctx context.Context, in *ListGoodsRequest, opts ...grpc.CallOption) (*ListGoodsResponse, error)
+	// UpdateGoods changes the fields that are set, a new priority moves the
+	// other goods of the project down
+	UpdateGoods(ctx context.Context, in *UpdateGoodsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// DeleteGoods marks goods as removed, they are still listed
+	DeleteGoods(ctx context.Context, in *DeleteGoodsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ReprioritizeGoods(ctx context.Context, in *ReprioritizeGoodsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+}
+
+type goodsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGoodsServiceClient(cc grpc.ClientConnInterface) GoodsServiceClient {
+	return &goodsServiceClient{cc}
+}
+
+func (c *goodsServiceClient) CreateGoods(ctx context.Context, in *CreateGoodsRequest, opts ...grpc.CallOption) (*CreateGoodsResponse, error) {
+	out := new(CreateGoodsResponse)
+	err := c.cc.Invoke(ctx, GoodsService_CreateGoods_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goodsServiceClient) GetGoods(ctx context.Context, in *GetGoodsRequest, opts ...grpc.CallOption) (*Goods, error) {
+	out := new(Goods)
+	err := c.cc.Invoke(ctx, GoodsService_GetGoods_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goodsServiceClient) ListGoods(ctx context.Context, in *ListGoodsRequest, opts ...grpc.CallOption) (*ListGoodsResponse, error) {
+	out := new(ListGoodsResponse)
+	err := c.cc.Invoke(ctx, GoodsService_ListGoods_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goodsServiceClient) UpdateGoods(ctx context.Context, in *UpdateGoodsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GoodsService_UpdateGoods_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goodsServiceClient) DeleteGoods(ctx context.Context, in *DeleteGoodsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GoodsService_DeleteGoods_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *goodsServiceClient) ReprioritizeGoods(ctx context.Context, in *ReprioritizeGoodsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GoodsService_ReprioritizeGoods_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GoodsServiceServer is the server API for GoodsService service.
+// All implementations must embed UnimplementedGoodsServiceServer
+// for forward compatibility
+type GoodsServiceServer interface {
+	CreateGoods(context.Context, *CreateGoodsRequest) (*CreateGoodsResponse, error)
+	GetGoods(context.Context, *GetGoodsRequest) (*Goods, error)
+	ListGoods(context.Context, *ListGoodsRequest) (*ListGoodsResponse, error)
+	// UpdateGoods changes the fields that are set, a new priority moves the
+	// other goods of the project down
+	UpdateGoods(context.Context, *UpdateGoodsRequest) (*emptypb.Empty, error)
+	// DeleteGoods marks goods as removed, they are still listed
+	DeleteGoods(context.Context, *DeleteGoodsRequest) (*emptypb.Empty, error)
+	ReprioritizeGoods(context.Context, *ReprioritizeGoodsRequest) (*emptypb.Empty, error)
+	mustEmbedUnimplementedGoodsServiceServer()
+}
+
+// UnimplementedGoodsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedGoodsServiceServer struct {
+}
+
+func (UnimplementedGoodsServiceServer) CreateGoods(context.Context, *CreateGoodsRequest) (*CreateGoodsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateGoods not implemented")
+}
+func (UnimplementedGoodsServiceServer) GetGoods(context.Context, *GetGoodsRequest) (*Goods, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGoods not implemented")
+}
+func (UnimplementedGoodsServiceServer) ListGoods(context.Context, *ListGoodsRequest) (*ListGoodsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGoods not implemented")
+}
+func (UnimplementedGoodsServiceServer) UpdateGoods(context.Context, *UpdateGoodsRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateGoods not implemented")
+}
+func (UnimplementedGoodsServiceServer) DeleteGoods(context.Context, *DeleteGoodsRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteGoods not implemented")
+}
+func (UnimplementedGoodsServiceServer) ReprioritizeGoods(context.Context, *ReprioritizeGoodsRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReprioritizeGoods not implemented")
+}
+func (UnimplementedGoodsServiceServer) mustEmbedUnimplementedGoodsServiceServer() {}
+
+// UnsafeGoodsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GoodsServiceServer will
+// result in compilation errors.
+type UnsafeGoodsServiceServer interface {
+	mustEmbedUnimplementedGoodsServiceServer()
+}
+
+func RegisterGoodsServiceServer(s grpc.ServiceRegistrar, srv GoodsServiceServer) {
+	s.RegisterService(&GoodsService_ServiceDesc, srv)
+}
+
+func _GoodsService_CreateGoods_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateGoodsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).CreateGoods(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_CreateGoods_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).CreateGoods(ctx, req.(*CreateGoodsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoodsService_GetGoods_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGoodsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).GetGoods(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_GetGoods_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).GetGoods(ctx, req.(*GetGoodsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoodsService_ListGoods_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGoodsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).ListGoods(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_ListGoods_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).ListGoods(ctx, req.(*ListGoodsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoodsService_UpdateGoods_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateGoodsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).UpdateGoods(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_UpdateGoods_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).UpdateGoods(ctx, req.(*UpdateGoodsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoodsService_DeleteGoods_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteGoodsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).DeleteGoods(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_DeleteGoods_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).DeleteGoods(ctx, req.(*DeleteGoodsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GoodsService_ReprioritizeGoods_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReprioritizeGoodsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GoodsServiceServer).ReprioritizeGoods(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GoodsService_ReprioritizeGoods_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GoodsServiceServer).ReprioritizeGoods(ctx, req.(*ReprioritizeGoodsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// GoodsService_ServiceDesc is the grpc.ServiceDesc for GoodsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GoodsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "goservice.v1.GoodsService",
+	HandlerType: (*GoodsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateGoods",
+			Handler:    _GoodsService_CreateGoods_Handler,
+		},
+		{
+			MethodName: "GetGoods",
+			Handler:    _GoodsService_GetGoods_Handler,
+		},
+		{
+			MethodName: "ListGoods",
+			Handler:    _GoodsService_ListGoods_Handler,
+		},
+		{
+			MethodName: "UpdateGoods",
+			Handler:    _GoodsService_UpdateGoods_Handler,
+		},
+		{
+			MethodName: "DeleteGoods",
+			Handler:    _GoodsService_DeleteGoods_Handler,
+		},
+		{
+			MethodName: "ReprioritizeGoods",
+			Handler:    _GoodsService_ReprioritizeGoods_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "goservice/v1/goservice.proto",
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"go-service/internal/config"
+	"go-service/internal/repository"
+	r "go-service/pkg/redis"
+)
+
+func cacheCmd(cfg *config.Config, logger *zap.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the Redis cache",
+	}
+
+	var patterns []string
+	flush := &cobra.Command{
+		Use:   "flush",
+		Short: "Drop cached goods, projects and list pages",
+		Long: "Drop cached goods, projects and list pages from Redis. Tag and version counters are kept. " +
+			"In-process caches of running servers expire on their own within cache.local.ttl.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := r.NewClient(r.ClientConfig{
+				Addr:     cfg.Redis.Host + ":" + cfg.Redis.Port,
+				Password: cfg.Redis.Password,
+				DB:       cfg.Redis.DB,
+			})
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			removed, err := r.Flush(cmd.Context(), client, patterns...)
+			logger.Info("flushed cache", zap.Strings("patterns", patterns), zap.Int64("removed", removed))
+			return err
+		},
+	}
+	flush.Flags().StringSliceVar(&patterns, "pattern", repository.CachePatterns, "key patterns to remove")
+
+	cmd.AddCommand(flush)
+	return cmd
+}
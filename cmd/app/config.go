@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"go-service/internal/config"
+)
+
+func configCmd(opts *config.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+		// check reports load errors itself instead of failing before it runs
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "check",
+		Short: "Validate the configuration and print it with secrets redacted",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, loadErr := config.Load(*opts)
+			if cfg == nil {
+				return loadErr
+			}
+
+			settings, err := cfg.Redacted()
+			if err != nil {
+				return err
+			}
+			out, err := yaml.Marshal(settings)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(os.Stdout, string(out))
+
+			if loadErr != nil {
+				fmt.Fprintln(os.Stderr, loadErr)
+				return errors.New("configuration is invalid")
+			}
+			fmt.Fprintln(os.Stderr, "configuration is valid")
+			return nil
+		},
+	})
+	return cmd
+}
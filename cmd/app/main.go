@@ -3,23 +3,18 @@ package main
 import (
 	"context"
 	"os"
-	"os/signal"
-	"syscall"
 
 	_ "github.com/lib/pq"
+	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
 	"go-service/internal/app"
+	"go-service/internal/config"
+	l "go-service/pkg/logger"
 )
 
 func main() {
-	ctx := context.Background()
-
-	if err := app.InitConfig(); err != nil {
-		panic(err)
-	}
-
-	logger := zap.Must(zap.NewProduction())
+	logger := l.GetLogger()
 	defer func(logger *zap.Logger) {
 		err := logger.Sync()
 		if err != nil {
@@ -27,23 +22,58 @@ func main() {
 		}
 	}(logger)
 
-	app := app.NewApp(ctx, logger)
-
-	go func() {
-		if err := app.Run(ctx); err != nil {
-			app.Logger.Fatal("failed to run server: %w", zap.Error(err))
-		}
-	}()
+	var (
+		cfg  = new(config.Config)
+		opts config.Options
+	)
 
-	app.Logger.Info("app started")
+	root := &cobra.Command{
+		Use:           "app",
+		Args:          cobra.NoArgs,
+		Short:         "Go Service API server and tools",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			loaded, err := config.Load(opts)
+			if err != nil {
+				return err
+			}
+			*cfg = *loaded
+			return l.SetLevel(cfg.Log.Level)
+		},
+	}
+	root.PersistentFlags().StringVar(&opts.Dir, "config-dir", "configs", "directory with config.yml and profile overlays")
+	root.PersistentFlags().StringVar(&opts.Profile, "profile", "", "config profile to overlay, $GOSERVICE_PROFILE by default")
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
-	<-quit
+	serve := serveCmd(cfg, &opts, logger)
+	// without a subcommand the binary keeps starting the server, as it always did
+	root.RunE = serve.RunE
 
-	app.Logger.Info("app shutting down")
+	root.AddCommand(
+		serve,
+		workerCmd(cfg, &opts, logger),
+		migrateCmd(cfg, logger),
+		seedCmd(cfg, logger),
+		configCmd(&opts),
+		cacheCmd(cfg, logger),
+		schemasCmd(),
+	)
 
-	if err := app.Shutdown(context.Background(), logger); err != nil {
-		app.Logger.Error("failed to shutdown server: %w", zap.Error(err))
+	if err := root.ExecuteContext(context.Background()); err != nil {
+		logger.Error("command failed", zap.Error(err))
+		_ = logger.Sync()
+		os.Exit(1)
 	}
 }
+
+// newReloader watches the config files of a long-running command, apply
+// swaps in what can change without a restart
+func newReloader(cfg *config.Config, opts *config.Options, core *app.Core, apply func(cfg *config.Config) error) *app.Reloader {
+	return app.NewReloader(app.ReloaderConfig{
+		Options: *opts,
+		Config:  cfg,
+		Apply:   apply,
+		Bus:     core.Bus,
+		Logger:  core.Logger,
+	})
+}
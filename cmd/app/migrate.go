@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"go-service/internal/app"
+	"go-service/internal/config"
+	"go-service/pkg/migrate"
+)
+
+func migrateCmd(cfg *config.Config, logger *zap.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Apply or roll back the embedded database migrations",
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "Apply all pending migrations",
+			Args:  cobra.NoArgs,
+			RunE: withMigrator(cfg, logger, func(ctx context.Context, m *migrate.Migrator, args []string) error {
+				n, err := m.Up(ctx)
+				logger.Info("migrated up", zap.Int("applied", n))
+				return err
+			}),
+		},
+		&cobra.Command{
+			Use:   "down [N]",
+			Short: "Roll back the last N migrations, 1 by default",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: withMigrator(cfg, logger, func(ctx context.Context, m *migrate.Migrator, args []string) error {
+				steps := 1
+				if len(args) > 0 {
+					var err error
+					if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+						return fmt.Errorf("N must be a positive integer, got %q", args[0])
+					}
+				}
+				n, err := m.Down(ctx, steps)
+				logger.Info("migrated down", zap.Int("rolled_back", n))
+				return err
+			}),
+		},
+		&cobra.Command{
+			Use:   "to VERSION",
+			Short: "Migrate up or down to VERSION, none rolls back everything",
+			Args:  cobra.ExactArgs(1),
+			RunE: withMigrator(cfg, logger, func(ctx context.Context, m *migrate.Migrator, args []string) error {
+				version, err := parseVersion(args[0])
+				if err != nil {
+					return err
+				}
+				n, err := m.To(ctx, version)
+				logger.Info("migrated", zap.Int64("version", version), zap.Int("applied", n))
+				return err
+			}),
+		},
+		&cobra.Command{
+			Use:   "force VERSION",
+			Short: "Set VERSION and clear the dirty flag without running SQL, none for an empty database",
+			Args:  cobra.ExactArgs(1),
+			RunE: withMigrator(cfg, logger, func(ctx context.Context, m *migrate.Migrator, args []string) error {
+				version, err := parseVersion(args[0])
+				if err != nil {
+					return err
+				}
+				return m.Force(ctx, version)
+			}),
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "Print the current version and pending migrations",
+			Args:  cobra.NoArgs,
+			RunE: withMigrator(cfg, logger, func(ctx context.Context, m *migrate.Migrator, args []string) error {
+				status, err := m.Status(ctx)
+				if err != nil {
+					return err
+				}
+				printStatus(status)
+				return nil
+			}),
+		},
+	)
+	return cmd
+}
+
+// withMigrator opens the database only for the duration of the command
+func withMigrator(cfg *config.Config, logger *zap.Logger, fn func(ctx context.Context, m *migrate.Migrator, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		migrator, closeDB, err := app.NewMigrator(cmd.Context(), cfg, logger)
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+
+		return fn(cmd.Context(), migrator, args)
+	}
+}
+
+func parseVersion(arg string) (int64, error) {
+	if arg == "none" {
+		return migrate.NilVersion, nil
+	}
+	version, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("VERSION must be an integer or none, got %q", arg)
+	}
+	return version, nil
+}
+
+func printStatus(status migrate.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "version:\t%d\n", status.Version)
+	fmt.Fprintf(w, "dirty:\t%t\n\n", status.Dirty)
+	for _, m := range status.Applied {
+		fmt.Fprintf(w, "%06d\t%s\tapplied\n", m.Version, m.Name)
+	}
+	for _, m := range status.Pending {
+		fmt.Fprintf(w, "%06d\t%s\tpending\n", m.Version, m.Name)
+	}
+}
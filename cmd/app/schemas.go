@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"go-service/api/events"
+)
+
+func schemasCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schemas",
+		Short: "Check the event schemas",
+		// the schemas are compiled in, no configuration is needed
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	}
+
+	var baseline string
+	check := &cobra.Command{
+		Use:   "check",
+		Short: "Fail on event schema changes that break consumers",
+		Long: "Check that every schema of the catalog is valid. With --baseline the schemas of an earlier " +
+			"release, laid out like api/events/schemas, must still exist and accept what the current ones allow. " +
+			"CI runs it against the target branch:\n\n" +
+			"  git archive origin/main api/events/schemas | tar -x -C /tmp/base\n" +
+			"  app schemas check --baseline /tmp/base/api/events/schemas",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var published fs.FS
+			if baseline != "" {
+				if _, err := os.Stat(baseline); err != nil {
+					return err
+				}
+				published = os.DirFS(baseline)
+			}
+			if err := events.Check(published); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return errors.New("event schemas are incompatible")
+			}
+			fmt.Fprintln(os.Stderr, "event schemas are compatible")
+			return nil
+		},
+	}
+	check.Flags().StringVar(&baseline, "baseline", "", "directory with the schemas of an earlier release")
+
+	cmd.AddCommand(check)
+	return cmd
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"go-service/internal/app"
+	"go-service/internal/config"
+	"go-service/internal/seed"
+)
+
+func seedCmd(cfg *config.Config, logger *zap.Logger) *cobra.Command {
+	var opts seed.Options
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Fill the database with generated projects and goods",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			core, err := app.NewCore(cmd.Context(), app.Options{Config: cfg, Logger: logger, GlobalTracer: true})
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := core.Close(context.Background()); err != nil {
+					logger.Error("failed to close connections", zap.Error(err))
+				}
+			}()
+
+			result, err := seed.Run(cmd.Context(), core.Services, opts)
+			logger.Info("seeded", zap.Int("projects", result.Projects), zap.Int("goods", result.Goods))
+			return err
+		},
+	}
+	cmd.Flags().IntVar(&opts.Projects, "projects", 10, "number of projects to create")
+	cmd.Flags().IntVar(&opts.Goods, "goods", 100, "number of goods to create in each project")
+	cmd.Flags().Int64Var(&opts.Seed, "seed", 0, "random seed for reproducible data, 0 picks one")
+	return cmd
+}
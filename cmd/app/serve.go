@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"go-service/internal/app"
+	"go-service/internal/config"
+)
+
+func serveCmd(cfg *config.Config, opts *config.Options, logger *zap.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Start the HTTP server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			app, err := app.NewApp(ctx, app.Options{Config: cfg, Logger: logger, GlobalTracer: true})
+			if err != nil {
+				return err
+			}
+
+			quit, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+			defer stop()
+
+			failed := make(chan error, 1)
+			go func() {
+				failed <- app.Run(ctx)
+			}()
+
+			app.Logger.Info("app started")
+
+			reloader := newReloader(cfg, opts, app.Core, app.Reload)
+			go reloader.Watch(quit)
+
+			select {
+			case <-quit.Done():
+			case err = <-failed:
+				app.Logger.Error("failed to run server", zap.Error(err))
+			}
+
+			app.Logger.Info("app shutting down")
+
+			// the signal context is done already, shutdown gets its own deadline
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+			defer cancel()
+			if serr := app.Shutdown(shutdownCtx, logger); serr != nil {
+				app.Logger.Error("failed to shutdown server", zap.Error(serr))
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"go-service/internal/app"
+	"go-service/internal/config"
+)
+
+func workerCmd(cfg *config.Config, opts *config.Options, logger *zap.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "worker",
+		Short: "Run background jobs without serving HTTP",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGTERM, syscall.SIGINT)
+			defer stop()
+
+			worker, err := app.NewWorker(ctx, app.Options{Config: cfg, Logger: logger, GlobalTracer: true})
+			if err != nil {
+				return err
+			}
+			worker.Logger.Info("worker started")
+
+			reloader := newReloader(cfg, opts, worker.Core, worker.Reload)
+			go reloader.Watch(ctx)
+
+			err = worker.Run(ctx)
+
+			worker.Logger.Info("worker shutting down")
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+			defer cancel()
+			if serr := worker.Shutdown(shutdownCtx); serr != nil {
+				worker.Logger.Error("failed to shutdown worker", zap.Error(serr))
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,38 @@
+CREATE TABLE webhooks (
+                          id SERIAL PRIMARY KEY,
+                          project_id INT NOT NULL,
+                          url TEXT NOT NULL,
+                          event_types TEXT[] NOT NULL DEFAULT '{}',
+                          secret VARCHAR(255) NOT NULL,
+                          active BOOLEAN NOT NULL DEFAULT TRUE,
+                          created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                          CONSTRAINT fk_webhooks_project
+                              FOREIGN KEY (project_id)
+                                  REFERENCES projects (id)
+                                  ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhooks_project_id ON webhooks (project_id);
+
+-- Deliveries are pending until they succeed or run out of attempts and
+-- become dead, next_attempt_at schedules the retries
+CREATE TABLE webhook_deliveries (
+                                    id SERIAL PRIMARY KEY,
+                                    webhook_id INT NOT NULL,
+                                    event_type VARCHAR(64) NOT NULL,
+                                    payload JSONB NOT NULL,
+                                    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+                                    attempts INT NOT NULL DEFAULT 0,
+                                    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                    response_status INT,
+                                    last_error TEXT,
+                                    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+                                    delivered_at TIMESTAMP WITH TIME ZONE,
+                                    CONSTRAINT fk_webhook_deliveries_webhook
+                                        FOREIGN KEY (webhook_id)
+                                            REFERENCES webhooks (id)
+                                            ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, id);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
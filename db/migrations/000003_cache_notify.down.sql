@@ -0,0 +1,7 @@
+DROP TRIGGER trigger_notify_projects_change ON projects;
+
+DROP TRIGGER trigger_notify_goods_change ON goods;
+
+DROP FUNCTION notify_projects_change();
+
+DROP FUNCTION notify_goods_change();
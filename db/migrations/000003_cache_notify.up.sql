@@ -0,0 +1,41 @@
+-- Row changes are announced on the cache_invalidation channel, so the
+-- services drop cached rows that were written around them, by direct SQL,
+-- migrations or other services. Identical notifications of one transaction
+-- are delivered once
+CREATE OR REPLACE FUNCTION notify_goods_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM pg_notify('cache_invalidation', json_build_object(
+            'table', TG_TABLE_NAME, 'id', OLD.id, 'project_id', OLD.project_id)::text);
+    END IF;
+    -- an update may move the goods to another project
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.project_id IS DISTINCT FROM OLD.project_id) THEN
+        PERFORM pg_notify('cache_invalidation', json_build_object(
+            'table', TG_TABLE_NAME, 'id', NEW.id, 'project_id', NEW.project_id)::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION notify_projects_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM pg_notify('cache_invalidation', json_build_object('table', TG_TABLE_NAME, 'id', NEW.id)::text);
+    ELSE
+        PERFORM pg_notify('cache_invalidation', json_build_object('table', TG_TABLE_NAME, 'id', OLD.id)::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_notify_goods_change
+    AFTER INSERT OR UPDATE OR DELETE ON goods
+    FOR EACH ROW
+    EXECUTE FUNCTION notify_goods_change();
+
+CREATE TRIGGER trigger_notify_projects_change
+    AFTER INSERT OR UPDATE OR DELETE ON projects
+    FOR EACH ROW
+    EXECUTE FUNCTION notify_projects_change();
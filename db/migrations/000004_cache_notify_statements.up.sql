@@ -0,0 +1,109 @@
+-- The row triggers of cache_notify sent a notification for every changed
+-- row, so one bulk update flooded the listeners. Statement triggers send
+-- one per project instead, carrying the changed ids in batches that stay
+-- below the 8000 byte payload limit
+DROP TRIGGER trigger_notify_projects_change ON projects;
+
+DROP TRIGGER trigger_notify_goods_change ON goods;
+
+DROP FUNCTION notify_projects_change();
+
+DROP FUNCTION notify_goods_change();
+
+-- transition tables only exist for their events, so the rows are collected
+-- per operation before they are grouped
+CREATE OR REPLACE FUNCTION notify_goods_changes()
+RETURNS TRIGGER AS $$
+DECLARE
+    changed_ids integer[];
+    changed_projects integer[];
+    batch record;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        SELECT array_agg(id), array_agg(project_id) INTO changed_ids, changed_projects FROM new_rows;
+    ELSIF TG_OP = 'DELETE' THEN
+        SELECT array_agg(id), array_agg(project_id) INTO changed_ids, changed_projects FROM old_rows;
+    ELSE
+        -- an update may move goods to another project, both are announced
+        SELECT array_agg(id), array_agg(project_id) INTO changed_ids, changed_projects
+        FROM (SELECT id, project_id FROM old_rows UNION SELECT id, project_id FROM new_rows) AS changed;
+    END IF;
+
+    FOR batch IN
+        SELECT numbered.project_id, array_agg(numbered.id ORDER BY numbered.id) AS ids
+        FROM (
+            SELECT changed.id, changed.project_id,
+                (row_number() OVER (PARTITION BY changed.project_id ORDER BY changed.id) - 1) / 500 AS chunk
+            FROM unnest(changed_ids, changed_projects) AS changed(id, project_id)
+        ) AS numbered
+        GROUP BY numbered.project_id, numbered.chunk
+    LOOP
+        PERFORM pg_notify('cache_invalidation', json_build_object(
+            'table', TG_TABLE_NAME, 'project_id', batch.project_id, 'ids', batch.ids)::text);
+    END LOOP;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION notify_projects_changes()
+RETURNS TRIGGER AS $$
+DECLARE
+    changed_ids integer[];
+    batch record;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        SELECT array_agg(id) INTO changed_ids FROM new_rows;
+    ELSE
+        SELECT array_agg(id) INTO changed_ids FROM old_rows;
+    END IF;
+
+    FOR batch IN
+        SELECT array_agg(numbered.id ORDER BY numbered.id) AS ids
+        FROM (
+            SELECT changed.id, (row_number() OVER (ORDER BY changed.id) - 1) / 500 AS chunk
+            FROM unnest(changed_ids) AS changed(id)
+        ) AS numbered
+        GROUP BY numbered.chunk
+    LOOP
+        PERFORM pg_notify('cache_invalidation', json_build_object('table', TG_TABLE_NAME, 'ids', batch.ids)::text);
+    END LOOP;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- a trigger with transition tables fires on a single event
+CREATE TRIGGER trigger_notify_goods_insert
+    AFTER INSERT ON goods
+    REFERENCING NEW TABLE AS new_rows
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION notify_goods_changes();
+
+CREATE TRIGGER trigger_notify_goods_update
+    AFTER UPDATE ON goods
+    REFERENCING OLD TABLE AS old_rows NEW TABLE AS new_rows
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION notify_goods_changes();
+
+CREATE TRIGGER trigger_notify_goods_delete
+    AFTER DELETE ON goods
+    REFERENCING OLD TABLE AS old_rows
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION notify_goods_changes();
+
+CREATE TRIGGER trigger_notify_projects_insert
+    AFTER INSERT ON projects
+    REFERENCING NEW TABLE AS new_rows
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION notify_projects_changes();
+
+CREATE TRIGGER trigger_notify_projects_update
+    AFTER UPDATE ON projects
+    REFERENCING OLD TABLE AS old_rows NEW TABLE AS new_rows
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION notify_projects_changes();
+
+CREATE TRIGGER trigger_notify_projects_delete
+    AFTER DELETE ON projects
+    REFERENCING OLD TABLE AS old_rows
+    FOR EACH STATEMENT
+    EXECUTE FUNCTION notify_projects_changes();
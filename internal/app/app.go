@@ -2,25 +2,21 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
-	"os"
+	"sync"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/joho/godotenv"
-	"github.com/nats-io/nats.go"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/redis/go-redis/v9"
-	"github.com/spf13/viper"
 	"go.uber.org/zap"
 
+	"go-service/internal/config"
+	"go-service/internal/grpcapi"
 	h "go-service/internal/handler"
+	"go-service/internal/natsapi"
 	"go-service/internal/repository"
-	"go-service/internal/service"
-	n "go-service/pkg/nats"
-	p "go-service/pkg/prometheus"
+	"go-service/internal/stream"
 	r "go-service/pkg/redis"
-	"go-service/pkg/tracer"
 )
 
 // @title Go Service API
@@ -31,106 +27,184 @@ import (
 // @BasePath /
 
 type App struct {
+	*Core
 	Server *http.Server
-	Logger *zap.Logger
-	Redis  *redis.Client
-	Nats   *nats.Conn
-	db     *pgxpool.Pool
-}
-
-func NewApp(ctx context.Context, logger *zap.Logger) *App {
-	redisClient := r.NewClient(logger)
-
-	prometheus.MustRegister(p.CacheHitsTotal)
-	prometheus.MustRegister(p.CacheMissesTotal)
-	prometheus.MustRegister(p.GoodsCounter)
-
-	if err := InitConfig(); err != nil {
-		logger.Fatal("error initializing configs: %w", zap.Error(err))
-	}
+	// GRPC is nil when grpc.port is empty
+	GRPC *grpcapi.Server
+	// Commands answers NATS requests, nil unless nats.commands.enabled is set
+	Commands *natsapi.Server
 
-	if err := godotenv.Load(); err != nil {
-		logger.Fatal("error loading env variables: %w", zap.Error(err))
-	}
-
-	db, err := repository.NewPostgresDB(ctx, repository.Config{
-		Host:     viper.GetString("db.host"),
-		Port:     viper.GetString("db.port"),
-		Username: os.Getenv("DB_USERNAME"),
-		Password: os.Getenv("DB_PASSWORD"),
-		DBName:   viper.GetString("db.dbname"),
-		SSLMode:  viper.GetString("db.sslmode"),
-	})
+	handlers *h.Handler
+	grpcAddr string
+}
 
+// NewApp wires the HTTP and gRPC servers without starting them. Server.Handler
+// serves every route, so tests can mount it on httptest.NewServer, and GRPC
+// can be served on any listener
+func NewApp(ctx context.Context, opts Options) (*App, error) {
+	core, err := NewCore(ctx, opts)
 	if err != nil {
-		logger.Fatal("failed to initialize db", zap.Error(err))
-	}
-	t, err := tracer.InitTracer(viper.GetString("tracer.url"), "go-service")
-	if err != nil {
-		logger.Fatal("failed to initialize tracer", zap.Error(err))
+		return nil, err
 	}
 
-	nc, err := n.NewNatsQueue(n.Config{
-		URL:    viper.GetString("nats.url"),
-		Logger: logger,
+	cfg := opts.Config
+	hub := stream.NewHub(stream.Config{
+		Bus:            core.Bus,
+		Buffer:         cfg.Events.Buffer,
+		ClientBuffer:   cfg.Events.ClientBuffer,
+		MaxConnections: cfg.Events.MaxConnections,
+		Retention:      cfg.Events.Retention,
+		Logger:         core.Logger,
 	})
-	if err != nil {
-		logger.Fatal("failed to connect to nats", zap.Error(err))
+	if err := hub.Listen(ctx); err != nil {
+		_ = core.Close(ctx)
+		return nil, fmt.Errorf("subscribe to goods events: %w", err)
 	}
-	defer nc.Close()
 
-	redisCache := r.New(redisClient)
-	repos := repository.New(ctx, db, redisCache, logger, nc, t)
-	services := service.New(repos)
-	handlers := h.New(services, t)
+	handlers := h.New(core.Services, core.Tracer, h.Config{
+		Debug:     cfg.Debug,
+		RPS:       cfg.RateLimit.RPS,
+		Burst:     cfg.RateLimit.Burst,
+		Gatherer:  core.gatherer,
+		Events:    hub,
+		Heartbeat: cfg.Events.Heartbeat,
+		Features:  core.Features,
+	})
 
 	srv := &http.Server{
-		Addr:           ":" + viper.GetString("port"),
+		Addr:           ":" + cfg.Port,
 		Handler:        handlers.InitRoutes(),
 		MaxHeaderBytes: 1 << 20, // 1MB
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   10 * time.Second,
 	}
+	// Shutdown neither cancels requests nor waits for hijacked connections,
+	// the event streams have to end on their own
+	srv.RegisterOnShutdown(hub.Close)
 
-	return &App{
+	a := &App{
+		Core:   core,
 		Server: srv,
-		Logger: logger,
-		Redis:  redisClient,
-		Nats:   nc,
-		db:     db,
+
+		handlers: handlers,
+	}
+	if cfg.GRPC.Port != "" {
+		a.GRPC = grpcapi.New(core.Services, grpcapi.Config{Reflection: cfg.GRPC.Reflection})
+		a.grpcAddr = ":" + cfg.GRPC.Port
+	}
+	if cfg.Nats.Commands.Enabled {
+		a.Commands = natsapi.New(core.Services, natsapi.Config{
+			Nats:        core.Nats,
+			Prefix:      cfg.Nats.Commands.Prefix,
+			Queue:       cfg.Nats.Commands.Queue,
+			Timeout:     cfg.Nats.Commands.Timeout,
+			Concurrency: cfg.Nats.Commands.Concurrency,
+			Logger:      core.Logger,
+		})
+		if err := a.Commands.Start(ctx); err != nil {
+			_ = core.Close(ctx)
+			return nil, fmt.Errorf("start NATS commands: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// Reload applies the shared settings and the API rate limit
+func (a *App) Reload(cfg *config.Config) error {
+	if err := a.Core.Reload(cfg); err != nil {
+		return err
 	}
+	a.handlers.SetRateLimit(cfg.RateLimit.RPS, cfg.RateLimit.Burst)
+	return nil
 }
 
 // TODO: ping bd and redis etc
 func (a *App) Run(ctx context.Context) error {
-	_, err := a.Redis.Ping(ctx).Result()
-	if err != nil {
-		a.Logger.Error("failed to ping redis", zap.Error(err))
+	if a.Redis != nil {
+		if _, err := a.Redis.Ping(ctx).Result(); err != nil {
+			a.Logger.Error("failed to ping redis", zap.Error(err))
+		}
+	}
+
+	// both servers return once stopped, the first error is enough
+	errs := make(chan error, 2)
+	if a.GRPC != nil {
+		lis, err := net.Listen("tcp", a.grpcAddr)
+		if err != nil {
+			return fmt.Errorf("listen gRPC: %w", err)
+		}
+		go func() {
+			errs <- a.GRPC.Serve(lis)
+		}()
 	}
+	go func() {
+		errs <- a.Server.ListenAndServe()
+	}()
 
-	return a.Server.ListenAndServe()
+	return <-errs
 }
 
+// Shutdown stops accepting requests on every server, waits for in-flight ones
+// and closes the connections
 func (a *App) Shutdown(ctx context.Context, logger *zap.Logger) error {
-	if a.Nats != nil {
-		a.Nats.Close()
+	var wg sync.WaitGroup
+	if a.Commands != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.Commands.Shutdown(ctx)
+		}()
+	}
+	if a.GRPC != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.GRPC.Shutdown(ctx)
+		}()
 	}
 
-	if a.Redis != nil {
-		if err := a.Redis.Close(); err != nil {
-			a.Logger.Error("failed to close Redis client", zap.Error(err))
-		}
+	err := a.Server.Shutdown(ctx)
+	wg.Wait()
+
+	if cerr := a.Core.Close(ctx); cerr != nil {
+		logger.Error("failed to close connections", zap.Error(cerr))
 	}
 
-	if a.db != nil {
-		a.db.Close()
+	return err
+}
+
+func dbConfig(cfg config.DBConfig) repository.Config {
+	return repository.Config{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: cfg.Password,
+		DBName:   cfg.DBName,
+		SSLMode:  cfg.SSLMode,
 	}
+}
 
-	return a.Server.Shutdown(ctx)
+func cacheConfig(cfg config.CacheConfig) repository.CacheConfig {
+	return repository.CacheConfig{
+		Goods:    cachePolicy(cfg.Goods),
+		Projects: cachePolicy(cfg.Projects),
+		Lists:    cachePolicy(cfg.Lists),
+	}
 }
 
-func InitConfig() error {
-	viper.AddConfigPath("configs")
-	viper.SetConfigName("config")
-	return viper.ReadInConfig()
+// generationTTL keeps invalidations for as long as the longest lived entry
+func generationTTL(cfg config.CacheConfig) time.Duration {
+	var ttl time.Duration
+	for _, policy := range []config.CachePolicy{cfg.Goods, cfg.Projects, cfg.Lists} {
+		ttl = max(ttl, policy.TTL+policy.Jitter+policy.Stale)
+	}
+	return ttl
+}
+
+func cachePolicy(cfg config.CachePolicy) r.Policy {
+	return r.Policy{
+		TTL:    cfg.TTL,
+		Jitter: cfg.Jitter,
+		Stale:  cfg.Stale,
+	}
 }
@@ -0,0 +1,284 @@
+package app_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+
+	"go-service/internal/app"
+	"go-service/internal/config"
+	"go-service/internal/models"
+)
+
+// instance is one App served by httptest with its own registry and spans
+type instance struct {
+	server *httptest.Server
+	spans  *tracetest.SpanRecorder
+}
+
+// demoConfig is the demo profile, everything in memory
+func demoConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	cfg, err := config.Load(config.Options{Dir: "../../configs", Profile: "demo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func newInstance(t *testing.T) *instance {
+	t.Helper()
+
+	spans := tracetest.NewSpanRecorder()
+	provider := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(spans))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	a, err := app.NewApp(context.Background(), app.Options{
+		Config:   demoConfig(t),
+		Logger:   zap.NewNop(),
+		Tracer:   provider.Tracer("test"),
+		Registry: prometheus.NewRegistry(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = a.Close(context.Background()) })
+
+	server := httptest.NewServer(a.Server.Handler)
+	t.Cleanup(server.Close)
+	return &instance{server: server, spans: spans}
+}
+
+func (i *instance) do(t *testing.T, method, path, body string) (int, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, i.server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := i.server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, data
+}
+
+// spanNames lists the names of the ended spans
+func (i *instance) spanNames() []string {
+	var names []string
+	for _, span := range i.spans.Ended() {
+		names = append(names, span.Name())
+	}
+	return names
+}
+
+func TestTwoApps(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	first, second := newInstance(t), newInstance(t)
+
+	status, body := first.do(t, http.MethodPost, "/api/projects/", `{"name":"first"}`)
+	if status != http.StatusOK {
+		t.Fatalf("create project: %d %s", status, body)
+	}
+	var project models.Project
+	if err := json.Unmarshal(body, &project); err != nil {
+		t.Fatal(err)
+	}
+
+	// the instances share nothing, the project only exists in the first
+	path := "/api/projects/" + strconv.Itoa(project.ID)
+	if status, body := first.do(t, http.MethodGet, path, ""); status != http.StatusOK {
+		t.Errorf("get project from the first app: %d %s", status, body)
+	}
+	if status, body := second.do(t, http.MethodGet, path, ""); status != http.StatusNotFound {
+		t.Errorf("get project from the second app: %d %s, want 404", status, body)
+	}
+
+	if names := first.spanNames(); !contains(names, "createProject") || !contains(names, "getProject") {
+		t.Errorf("first app spans = %v, want createProject and getProject", names)
+	}
+	if names := second.spanNames(); contains(names, "createProject") || !contains(names, "getProject") {
+		t.Errorf("second app spans = %v, want only getProject", names)
+	}
+
+	// the injected registries serve /metrics, the global one has the Go
+	// runtime metrics
+	for _, i := range []*instance{first, second} {
+		status, body := i.do(t, http.MethodGet, "/metrics", "")
+		if status != http.StatusOK || strings.Contains(string(body), "go_goroutines") {
+			t.Errorf("metrics: %d, served from the global registry", status)
+		}
+	}
+}
+
+func TestTracerStaysLocal(t *testing.T) {
+	global := otel.GetTracerProvider()
+
+	cfg := demoConfig(t)
+	cfg.Tracer.Exporter = "none"
+	core, err := app.NewCore(context.Background(), app.Options{
+		Config:   cfg,
+		Logger:   zap.NewNop(),
+		Registry: prometheus.NewRegistry(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer core.Close(context.Background())
+
+	if otel.GetTracerProvider() != global {
+		t.Error("NewCore replaced the global tracer provider without GlobalTracer")
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPrivateWebhook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	i := newInstance(t)
+
+	status, body := i.do(t, http.MethodPost, "/api/projects/", `{"name":"webhooks"}`)
+	if status != http.StatusOK {
+		t.Fatalf("create project: %d %s", status, body)
+	}
+	var project models.Project
+	if err := json.Unmarshal(body, &project); err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/api/projects/" + strconv.Itoa(project.ID) + "/webhooks"
+	for _, url := range []string{"http://127.0.0.1:8080/hook", "http://169.254.169.254/latest/meta-data", "http://localhost/hook"} {
+		status, body := i.do(t, http.MethodPost, path, `{"url":"`+url+`"}`)
+		if status != http.StatusBadRequest || !strings.Contains(string(body), "webhook.private_url") {
+			t.Errorf("create webhook for %s: %d %s, want 400 webhook.private_url", url, status, body)
+		}
+	}
+}
+
+func TestPatchUnsupportedMediaType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	i := newInstance(t)
+
+	status, body := i.do(t, http.MethodPost, "/api/projects/", `{"name":"patch"}`)
+	if status != http.StatusOK {
+		t.Fatalf("create project: %d %s", status, body)
+	}
+	var project models.Project
+	if err := json.Unmarshal(body, &project); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, i.server.URL+"/api/projects/"+strconv.Itoa(project.ID), strings.NewReader("name=renamed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res, err := i.server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("status = %d, want 415", res.StatusCode)
+	}
+	if got, want := res.Header.Get("Accept-Patch"), "application/merge-patch+json, application/json-patch+json"; got != want {
+		t.Errorf("Accept-Patch = %q, want %q", got, want)
+	}
+}
+
+func TestShutdownEndsStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	a, err := app.NewApp(context.Background(), app.Options{
+		Config:   demoConfig(t),
+		Logger:   zap.NewNop(),
+		Registry: prometheus.NewRegistry(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- a.Server.Serve(lis) }()
+	base := "http://" + lis.Addr().String()
+
+	res, err := http.Post(base+"/api/projects/", "application/json", strings.NewReader(`{"name":"streams"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var project models.Project
+	err = json.NewDecoder(res.Body).Decode(&project)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/projects/" + strconv.Itoa(project.ID) + "/events"
+
+	sse, err := http.Get(base + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sse.Body.Close()
+	events := bufio.NewReader(sse.Body)
+	if _, err := events.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws://"+lis.Addr().String()+path+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.Shutdown(ctx, zap.NewNop()); err != nil {
+		t.Fatalf("shutdown with open streams: %v", err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("serve: %v", err)
+	}
+
+	if _, err := io.ReadAll(events); err != nil {
+		t.Errorf("event stream didn't end cleanly: %v", err)
+	}
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := ws.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("WebSocket read after shutdown: %v, want close 1001", err)
+	}
+}
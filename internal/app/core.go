@@ -0,0 +1,396 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"go-service/api/events"
+	"go-service/internal/config"
+	"go-service/internal/repository"
+	"go-service/internal/service"
+	"go-service/pkg/cloudevents"
+	"go-service/pkg/eventbus"
+	l "go-service/pkg/logger"
+	n "go-service/pkg/nats"
+	p "go-service/pkg/prometheus"
+	r "go-service/pkg/redis"
+	"go-service/pkg/tracer"
+)
+
+// Options configure NewCore, NewApp and NewWorker. Only Config is required,
+// every nil dependency is created from it. Injected dependencies belong to
+// the caller and are left open by Close, so tests can run several instances
+// in one process against the same database. With db.driver memory DB,
+// Redis and Cache are not used
+type Options struct {
+	Config *config.Config
+	// Logger defaults to the process logger of pkg/logger
+	Logger *zap.Logger
+	DB     *pgxpool.Pool
+	Redis  *redis.Client
+	// Cache replaces the redis and tiered caches, locks need Redis as well
+	Cache  r.Cache
+	Nats   *nats.Conn
+	Tracer trace.Tracer
+	// GlobalTracer installs the tracer created from the config as the
+	// OpenTelemetry global. The commands set it, tests leave it off so
+	// instances don't replace each other's provider
+	GlobalTracer bool
+	// Bus replaces the bus selected by bus.driver
+	Bus eventbus.Bus
+	// Registry receives the metrics and backs /metrics, the global
+	// Prometheus registry by default
+	Registry *prometheus.Registry
+}
+
+// Core is the wiring every command shares: connections, repositories and services
+type Core struct {
+	Logger *zap.Logger
+	Redis  *redis.Client
+	// Nats is nil when neither the bus nor the commands use NATS
+	Nats *nats.Conn
+	// JetStream is nil unless nats.jetstream.enabled is set
+	JetStream *n.JetStream
+	Bus       eventbus.Bus
+	Tracer    trace.Tracer
+	Services  *service.Service
+	// Features are the feature flags, swapped on reload
+	Features *config.FeatureFlags
+	db       *pgxpool.Pool
+
+	// gatherer is the injected registry, nil for the global one
+	gatherer prometheus.Gatherer
+	policies *repository.CachePolicies
+	repos    *repository.Repository
+	// cache and loader front the Postgres repositories, nil with db.driver memory
+	cache   r.Cache
+	loader  *r.Loader
+	sampler *tracer.Sampler
+	// closers release what NewCore created, in reverse order
+	closers []func(ctx context.Context) error
+}
+
+func NewCore(ctx context.Context, opts Options) (_ *Core, err error) {
+	cfg := opts.Config
+	if cfg == nil {
+		return nil, errors.New("config is required")
+	}
+
+	c := &Core{
+		Logger: opts.Logger,
+		Redis:  opts.Redis,
+		Nats:   opts.Nats,
+		Bus:    opts.Bus,
+		Tracer: opts.Tracer,
+		db:     opts.DB,
+	}
+	if c.Logger == nil {
+		c.Logger = l.GetLogger()
+	}
+	// leave nothing open when a later step fails
+	defer func() {
+		if err != nil {
+			if cerr := c.Close(context.WithoutCancel(ctx)); cerr != nil {
+				c.Logger.Error("failed to close connections", zap.Error(cerr))
+			}
+		}
+	}()
+
+	var registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	if opts.Registry != nil {
+		registerer, c.gatherer = opts.Registry, opts.Registry
+	}
+	if err := registerMetrics(registerer); err != nil {
+		return nil, fmt.Errorf("register metrics: %w", err)
+	}
+
+	if c.Tracer == nil {
+		t, sampler, shutdown, err := tracer.InitTracer(ctx, tracer.Config{
+			Exporter:    cfg.Tracer.Exporter,
+			Endpoint:    cfg.Tracer.Endpoint,
+			Insecure:    cfg.Tracer.Insecure,
+			Sampler:     cfg.Tracer.Sampler,
+			Ratio:       cfg.Tracer.Ratio,
+			ServiceName: "go-service",
+			Version:     cfg.Tracer.Version,
+			Environment: cfg.Tracer.Environment,
+			Instance:    cfg.Tracer.Instance,
+			Global:      opts.GlobalTracer,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("initialize tracer: %w", err)
+		}
+		c.Tracer, c.sampler = t, sampler
+		c.onClose(func(ctx context.Context) error {
+			if err := shutdown(ctx); err != nil {
+				return fmt.Errorf("flush spans: %w", err)
+			}
+			return nil
+		})
+	}
+
+	if c.Nats == nil && cfg.UsesNats() {
+		c.Nats, err = n.NewNatsQueue(n.Config{
+			URL:    cfg.Nats.URL,
+			Logger: c.Logger,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("connect to nats: %w", err)
+		}
+		c.onClose(func(context.Context) error {
+			c.Nats.Close()
+			return nil
+		})
+	}
+
+	if c.Redis == nil && cfg.Bus.Driver == config.BusRedis {
+		if err := c.redis(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Bus == nil {
+		if err := c.bus(ctx, opts); err != nil {
+			return nil, err
+		}
+	}
+	// events go out as CloudEvents, subscribers get their data in either mode
+	c.Bus = cloudevents.NewBus(cloudevents.BusConfig{
+		Bus:    c.Bus,
+		Source: cfg.Events.Source,
+		Mode:   cloudevents.Mode(cfg.Events.Mode),
+		Schema: events.SchemaURL(cfg.Events.SchemaURL),
+	})
+
+	c.policies = repository.NewCachePolicies(cacheConfig(cfg.Cache))
+	c.Features = config.NewFeatureFlags(cfg.Features)
+	repos := repository.NewMemory()
+	if cfg.DB.Driver != config.DriverMemory {
+		repos, err = c.postgres(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.repos = repos
+	c.Services = service.New(repos, c.Bus, service.Config{
+		AllowPrivateWebhooks: cfg.Worker.Webhooks.AllowPrivate,
+	})
+
+	return c, nil
+}
+
+// postgres connects the Postgres repositories and the caches in front of them
+func (c *Core) postgres(ctx context.Context, opts Options) (*repository.Repository, error) {
+	cfg := opts.Config
+
+	var err error
+	if c.Redis == nil && (opts.Cache == nil || cfg.Cache.Lock.Enabled) {
+		if err := c.redis(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.db == nil {
+		c.db, err = repository.NewPostgresDB(ctx, dbConfig(cfg.DB))
+		if err != nil {
+			return nil, fmt.Errorf("initialize db: %w", err)
+		}
+		c.onClose(func(context.Context) error {
+			c.db.Close()
+			return nil
+		})
+	}
+
+	if cfg.DB.AutoMigrate {
+		migrator, err := newMigrator(c.db, c.Logger)
+		if err != nil {
+			return nil, fmt.Errorf("load migrations: %w", err)
+		}
+		if _, err := migrator.Up(ctx); err != nil {
+			return nil, fmt.Errorf("apply migrations: %w", err)
+		}
+	}
+
+	cache := opts.Cache
+	if cache == nil {
+		remoteCache := r.NewRedisCache(c.Redis)
+		cache = remoteCache
+		if cfg.Cache.Local.Enabled {
+			tiered := r.NewTieredCache(r.TieredConfig{
+				Remote:     remoteCache,
+				Bus:        c.Bus,
+				Subject:    cfg.Cache.Local.Subject,
+				TTL:        cfg.Cache.Local.TTL,
+				MaxEntries: cfg.Cache.Local.MaxEntries,
+				Logger:     c.Logger,
+			})
+			if err := tiered.Listen(ctx); err != nil {
+				return nil, fmt.Errorf("subscribe to cache invalidations: %w", err)
+			}
+			cache = tiered
+		}
+	}
+
+	var locker r.Locker
+	if cfg.Cache.Lock.Enabled {
+		locker = r.NewRedisLocker(c.Redis)
+	}
+	loader := r.NewLoader(r.LoaderConfig{
+		Cache:    cache,
+		Locker:   locker,
+		LockTTL:  cfg.Cache.Lock.TTL,
+		LockWait: cfg.Cache.Lock.Wait,
+		Logger:   c.Logger,
+
+		GenerationTTL: generationTTL(cfg.Cache),
+	})
+	c.cache, c.loader = cache, loader
+
+	return repository.New(c.db, cache, loader, c.policies, repository.Timeouts{
+		Read:  cfg.DB.Timeouts.Read,
+		Write: cfg.DB.Timeouts.Write,
+	}, c.Logger, c.Tracer), nil
+}
+
+func (c *Core) redis(cfg *config.Config) error {
+	var err error
+	c.Redis, err = r.NewClient(r.ClientConfig{
+		Addr:     cfg.Redis.Host + ":" + cfg.Redis.Port,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	if err != nil {
+		return fmt.Errorf("initialize redis: %w", err)
+	}
+	c.onClose(func(context.Context) error { return c.Redis.Close() })
+	return nil
+}
+
+// bus creates the bus selected by bus.driver, with NATS it provisions the
+// JetStream streams when JetStream is enabled
+func (c *Core) bus(ctx context.Context, opts Options) error {
+	cfg := opts.Config
+	switch cfg.Bus.Driver {
+	case config.BusRedis:
+		c.Bus = eventbus.NewRedisBus(eventbus.RedisConfig{
+			Client: c.Redis,
+			Prefix: cfg.Bus.Redis.Prefix,
+			MaxLen: cfg.Bus.Redis.MaxLen,
+			Block:  cfg.Bus.Redis.Block,
+			Logger: c.Logger,
+		})
+	case config.BusMemory:
+		c.Bus = eventbus.NewMemoryBus(c.Logger)
+	default:
+		var durable []string
+		if cfg.Nats.JetStream.Enabled {
+			var err error
+			c.JetStream, err = n.NewJetStream(c.Nats, c.Logger)
+			if err != nil {
+				return fmt.Errorf("initialize jetstream: %w", err)
+			}
+			if err := c.JetStream.Provision(ctx, streamConfigs(cfg.Nats.JetStream.Streams)...); err != nil {
+				return err
+			}
+			for _, stream := range cfg.Nats.JetStream.Streams {
+				durable = append(durable, stream.Subjects...)
+			}
+		}
+		c.Bus = eventbus.NewNatsBus(eventbus.NatsConfig{
+			Conn:      c.Nats,
+			JetStream: c.JetStream,
+			Durable:   durable,
+			Logger:    c.Logger,
+		})
+	}
+	return nil
+}
+
+func streamConfigs(streams []config.StreamConfig) []n.StreamConfig {
+	configs := make([]n.StreamConfig, 0, len(streams))
+	for _, s := range streams {
+		configs = append(configs, n.StreamConfig{
+			Name:       s.Name,
+			Subjects:   s.Subjects,
+			MaxAge:     s.MaxAge,
+			Duplicates: s.Duplicates,
+			Replicas:   s.Replicas,
+		})
+	}
+	return configs
+}
+
+// registerMetrics tolerates metrics registered before, so several instances
+// can share a registry
+func registerMetrics(registerer prometheus.Registerer) error {
+	collectors := []prometheus.Collector{
+		p.CacheHitsTotal,
+		p.CacheMissesTotal,
+		p.CacheStaleTotal,
+		p.CacheTierHitsTotal,
+		p.CacheTierMissesTotal,
+		p.CacheInvalidationsTotal,
+		p.GoodsCounter,
+		p.ConfigReloadsTotal,
+		p.EventsConnections,
+		p.EventsSentTotal,
+		p.EventsDroppedTotal,
+		p.EventsRejectedTotal,
+		p.WebhookDeliveriesTotal,
+		p.JetStreamMessagesTotal,
+		p.NatsCommandsTotal,
+		p.BusMessagesTotal,
+		p.CacheNotificationsTotal,
+	}
+	for _, collector := range collectors {
+		err := registerer.Register(collector)
+		var registered prometheus.AlreadyRegisteredError
+		if errors.As(err, &registered) && registered.ExistingCollector == collector {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Core) onClose(fn func(ctx context.Context) error) {
+	c.closers = append(c.closers, fn)
+}
+
+// Reload applies the settings every command shares: log level, cache
+// policies, feature flags and the sampling ratio
+func (c *Core) Reload(cfg *config.Config) error {
+	if err := l.SetLevel(cfg.Log.Level); err != nil {
+		return fmt.Errorf("set log level: %w", err)
+	}
+	if c.sampler != nil {
+		if err := c.sampler.SetRatio(cfg.Tracer.Ratio); err != nil {
+			return fmt.Errorf("set sampling ratio: %w", err)
+		}
+	}
+	c.policies.Store(cacheConfig(cfg.Cache))
+	c.Features.Store(cfg.Features)
+	return nil
+}
+
+// Close releases the connections NewCore opened and flushes pending spans,
+// injected ones are left to the caller
+func (c *Core) Close(ctx context.Context) error {
+	var errs []error
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		errs = append(errs, c.closers[i](ctx))
+	}
+	c.closers = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"go-service/db/migrations"
+	"go-service/internal/config"
+	"go-service/internal/repository"
+	"go-service/pkg/migrate"
+)
+
+// NewMigrator connects to the configured database for the migrate command,
+// the returned func closes the connection pool
+func NewMigrator(ctx context.Context, cfg *config.Config, logger *zap.Logger) (*migrate.Migrator, func(), error) {
+	if cfg.DB.Driver != config.DriverPostgres {
+		return nil, nil, fmt.Errorf("migrations need db.driver %s, not %s", config.DriverPostgres, cfg.DB.Driver)
+	}
+
+	db, err := repository.NewPostgresDB(ctx, dbConfig(cfg.DB))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrator, err := newMigrator(db, logger)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return migrator, db.Close, nil
+}
+
+func newMigrator(db *pgxpool.Pool, logger *zap.Logger) (*migrate.Migrator, error) {
+	return migrate.New(migrate.Config{
+		DB:     db,
+		FS:     migrations.FS,
+		Logger: logger,
+	})
+}
@@ -0,0 +1,210 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"go-service/internal/config"
+	"go-service/internal/repository"
+	p "go-service/pkg/prometheus"
+	r "go-service/pkg/redis"
+)
+
+// cacheChannel is the channel the triggers of the cache_notify migrations
+// notify on
+const cacheChannel = "cache_invalidation"
+
+const (
+	notifyEvicted = "evicted"
+	notifyFailed  = "failed"
+	notifyInvalid = "invalid"
+)
+
+// rowChange is the payload of a notification, ProjectID is only set for goods.
+// The statement triggers send the rows of one project in IDs, the row
+// triggers before them one row in ID
+type rowChange struct {
+	Table     string `json:"table"`
+	IDs       []int  `json:"ids"`
+	ID        int    `json:"id"`
+	ProjectID int    `json:"project_id"`
+}
+
+// errStandby means another listener holds the lock, this one waits to take over
+var errStandby = errors.New("another cache listener is active")
+
+type CacheListenerConfig struct {
+	// DB provides the connection settings, the listener connects on its own
+	DB     *pgxpool.Pool
+	Cache  r.Cache
+	Loader *r.Loader
+	// MinBackoff and MaxBackoff bound the waits between reconnects
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Timeout bounds the eviction of one notification, 5 seconds by default
+	Timeout time.Duration
+	Logger  *zap.Logger
+}
+
+// CacheListener evicts the cached rows the database announces on
+// cacheChannel. It holds a connection outside the pool since LISTEN lasts as
+// long as the session. Changes made while it reconnects expire with their TTL.
+//
+// Every notification is evicted once: the listener takes an advisory lock
+// on its session, listeners of other replicas wait on standby and take over
+// when the session ends
+type CacheListener struct {
+	config CacheListenerConfig
+}
+
+func NewCacheListener(config CacheListenerConfig) *CacheListener {
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = time.Second
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = config.MinBackoff
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.Logger == nil {
+		config.Logger = zap.NewNop()
+	}
+	return &CacheListener{config: config}
+}
+
+// Run listens until ctx is done, reconnecting with exponential backoff
+func (l *CacheListener) Run(ctx context.Context) {
+	delay := l.config.MinBackoff
+	for {
+		listened, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errStandby) {
+			// the active listener is fine, check back at the longest backoff
+			delay = l.config.MaxBackoff
+			l.config.Logger.Debug("cache listener on standby", zap.Duration("backoff", delay))
+		} else {
+			if listened {
+				delay = l.config.MinBackoff
+			}
+			l.config.Logger.Warn("cache notifications interrupted, reconnecting",
+				zap.Duration("backoff", delay), zap.Error(err))
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		delay = min(delay*2, l.config.MaxBackoff)
+	}
+}
+
+// listen reports whether it got as far as LISTEN, so the backoff starts over
+func (l *CacheListener) listen(ctx context.Context) (bool, error) {
+	conn, err := pgx.ConnectConfig(ctx, l.config.DB.Config().ConnConfig.Copy())
+	if err != nil {
+		return false, fmt.Errorf("connect: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	// the lock belongs to the session, it is released when conn closes
+	var active bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", cacheChannel).Scan(&active); err != nil {
+		return false, fmt.Errorf("lock: %w", err)
+	}
+	if !active {
+		return false, errStandby
+	}
+
+	// utility statements can't be prepared like the repository queries
+	if _, err := conn.Exec(ctx, "LISTEN "+cacheChannel, pgx.QueryExecModeSimpleProtocol); err != nil {
+		return false, fmt.Errorf("listen: %w", err)
+	}
+	l.config.Logger.Info("listening for cache notifications", zap.String("channel", cacheChannel))
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, fmt.Errorf("wait for notification: %w", err)
+		}
+		l.evict(ctx, notification.Payload)
+	}
+}
+
+func (l *CacheListener) evict(ctx context.Context, payload string) {
+	ctx, cancel := context.WithTimeout(ctx, l.config.Timeout)
+	defer cancel()
+
+	var change rowChange
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+		l.config.Logger.Error("invalid cache notification", zap.String("payload", payload), zap.Error(err))
+		p.CacheNotificationsTotal.WithLabelValues("unknown", notifyInvalid).Inc()
+		return
+	}
+
+	ids := change.IDs
+	if change.ID != 0 {
+		ids = append(ids, change.ID)
+	}
+
+	var err error
+	switch change.Table {
+	case "goods":
+		err = repository.InvalidateGoods(ctx, l.config.Cache, l.config.Loader, change.ProjectID, ids...)
+	case "projects":
+		err = repository.InvalidateProjects(ctx, l.config.Cache, l.config.Loader, ids...)
+	default:
+		l.config.Logger.Error("cache notification of an unknown table", zap.String("payload", payload))
+		p.CacheNotificationsTotal.WithLabelValues("unknown", notifyInvalid).Inc()
+		return
+	}
+
+	if err != nil {
+		l.config.Logger.Error("failed to evict changed rows", zap.String("payload", payload), zap.Error(err))
+		p.CacheNotificationsTotal.WithLabelValues(change.Table, notifyFailed).Inc()
+		return
+	}
+	l.config.Logger.Debug("evicted changed rows", zap.String("payload", payload))
+	p.CacheNotificationsTotal.WithLabelValues(change.Table, notifyEvicted).Inc()
+}
+
+// listenCache runs a CacheListener on the repository caches until Close
+func (c *Core) listenCache(ctx context.Context, cfg config.NotifyConfig) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	listener := NewCacheListener(CacheListenerConfig{
+		DB:         c.db,
+		Cache:      c.cache,
+		Loader:     c.loader,
+		MinBackoff: cfg.MinBackoff,
+		MaxBackoff: cfg.MaxBackoff,
+		Timeout:    cfg.Timeout,
+		Logger:     c.Logger,
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.Run(ctx)
+	}()
+	c.onClose(func(closeCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-closeCtx.Done():
+			return fmt.Errorf("stop cache listener: %w", closeCtx.Err())
+		}
+	})
+}
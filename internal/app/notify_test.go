@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	r "go-service/pkg/redis"
+)
+
+// recordingCache logs the writes of an eviction, reads always miss
+type recordingCache struct {
+	mu  sync.Mutex
+	log []string
+}
+
+func (c *recordingCache) record(op, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.log = append(c.log, op+" "+key)
+}
+
+func (c *recordingCache) Get(context.Context, string) (string, error) { return "", redis.Nil }
+func (c *recordingCache) Set(context.Context, string, string, time.Duration) error {
+	return nil
+}
+func (c *recordingCache) Delete(_ context.Context, key string) error {
+	c.record("del", key)
+	return nil
+}
+func (c *recordingCache) GetInt(context.Context, string) (int, error) { return 0, redis.Nil }
+func (c *recordingCache) SetInt(context.Context, string, int, time.Duration) error {
+	return nil
+}
+func (c *recordingCache) Incr(_ context.Context, key string, _ time.Duration) (int64, error) {
+	c.record("incr", key)
+	return 1, nil
+}
+
+func TestEvict(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    []string
+	}{
+		{
+			name:    "goods of a statement",
+			payload: `{"table":"goods","project_id":2,"ids":[5,7]}`,
+			want: []string{
+				"incr tag:goods",
+				"incr generation:goods:5:2", "del goods:5:2",
+				"incr generation:goods:7:2", "del goods:7:2",
+			},
+		},
+		{
+			name:    "goods of a row trigger",
+			payload: `{"table":"goods","id":5,"project_id":2}`,
+			want: []string{
+				"incr tag:goods",
+				"incr generation:goods:5:2", "del goods:5:2",
+			},
+		},
+		{
+			name:    "projects of a statement",
+			payload: `{"table":"projects","ids":[3,4]}`,
+			want: []string{
+				"incr tag:projects",
+				"incr generation:project:3", "del project:3",
+				"incr generation:project:4", "del project:4",
+			},
+		},
+		{
+			name:    "unknown table",
+			payload: `{"table":"webhooks","ids":[1]}`,
+		},
+		{
+			name:    "invalid payload",
+			payload: `goods`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &recordingCache{}
+			listener := NewCacheListener(CacheListenerConfig{
+				Cache:  cache,
+				Loader: r.NewLoader(r.LoaderConfig{Cache: cache}),
+			})
+
+			listener.evict(context.Background(), tt.payload)
+			if !reflect.DeepEqual(cache.log, tt.want) {
+				t.Errorf("evict(%s) wrote %q, want %q", tt.payload, cache.log, tt.want)
+			}
+		})
+	}
+}
+
+// stuckCache doesn't answer until the context is done
+type stuckCache struct{ recordingCache }
+
+func (c *stuckCache) Incr(ctx context.Context, _ string, _ time.Duration) (int64, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func TestEvictTimeout(t *testing.T) {
+	cache := &stuckCache{}
+	listener := NewCacheListener(CacheListenerConfig{
+		Cache:   cache,
+		Loader:  r.NewLoader(r.LoaderConfig{Cache: cache}),
+		Timeout: 10 * time.Millisecond,
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		listener.evict(context.Background(), `{"table":"projects","ids":[1]}`)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("evict didn't give up on an unresponsive cache")
+	}
+}
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	"go-service/internal/config"
+	"go-service/pkg/eventbus"
+	p "go-service/pkg/prometheus"
+)
+
+// ReloadSubject receives a ReloadEvent after every reload attempt
+const ReloadSubject = "config.reloaded"
+
+// ReloadEventType is the CloudEvents type of ReloadEvent
+const ReloadEventType = "config.reloaded"
+
+const (
+	reloadApplied   = "applied"
+	reloadUnchanged = "unchanged"
+	reloadRejected  = "rejected"
+	reloadFailed    = "failed"
+)
+
+// reloadDebounce waits for a changed config file to be written completely
+const reloadDebounce = 250 * time.Millisecond
+
+var ErrRestartRequired = errors.New("settings can't change without a restart")
+
+// ReloadEvent tells other services and operators what a reload did, values
+// are left out since some of them are secrets
+type ReloadEvent struct {
+	Trigger string `json:"trigger"`
+	Result  string `json:"result"`
+	// Changed lists the settings applied, Rejected the ones that wait for
+	// a restart
+	Changed  []string  `json:"changed,omitempty"`
+	Rejected []string  `json:"rejected,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Instance string    `json:"instance"`
+	At       time.Time `json:"at"`
+}
+
+// EventID is left to the bus, reload events aren't deduplicated
+func (e ReloadEvent) EventID() string {
+	return ""
+}
+
+func (e ReloadEvent) EventType() string {
+	return ReloadEventType
+}
+
+func (e ReloadEvent) EventTime() time.Time {
+	return e.At
+}
+
+type ReloaderConfig struct {
+	Options config.Options
+	// Config is the configuration the process started with
+	Config *config.Config
+	// Apply swaps the hot-reloadable settings in, see App.Reload and Core.Reload
+	Apply  func(cfg *config.Config) error
+	Bus    eventbus.Bus
+	Logger *zap.Logger
+}
+
+// Reloader loads the configuration again when a config file changes or the
+// process gets SIGHUP. The hot-reloadable settings are applied, changes to
+// the others are logged and left for the next restart
+type Reloader struct {
+	opts     config.Options
+	apply    func(cfg *config.Config) error
+	bus      eventbus.Bus
+	logger   *zap.Logger
+	instance string
+
+	mu      sync.Mutex
+	current *config.Config
+}
+
+func NewReloader(config ReloaderConfig) *Reloader {
+	instance, _ := os.Hostname()
+	return &Reloader{
+		opts:     config.Options,
+		apply:    config.Apply,
+		bus:      config.Bus,
+		logger:   config.Logger,
+		instance: instance,
+		current:  config.Config,
+	}
+}
+
+// Current returns the configuration applied last
+func (r *Reloader) Current() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Watch reloads on config file changes and SIGHUP until ctx is done
+func (r *Reloader) Watch(ctx context.Context) {
+	changes := make(chan struct{}, 1)
+	for _, file := range r.opts.Files() {
+		v := viper.New()
+		v.SetConfigFile(file)
+		v.OnConfigChange(func(e fsnotify.Event) {
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		})
+		v.WatchConfig()
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			_ = r.Reload(ctx, "sighup")
+		case <-changes:
+			// editors and config map updates write in several steps
+			settled = time.After(reloadDebounce)
+		case <-settled:
+			settled = nil
+			_ = r.Reload(ctx, "file")
+		}
+	}
+}
+
+// Reload loads the configuration and applies the hot-reloadable settings
+// that changed. It returns ErrRestartRequired when only settings that need a
+// restart changed. Every attempt is counted and published
+func (r *Reloader) Reload(ctx context.Context, trigger string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event := ReloadEvent{Trigger: trigger, Instance: r.instance, At: time.Now().UTC()}
+	err := r.reload(&event)
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	switch event.Result {
+	case reloadApplied:
+		r.logger.Info("configuration reloaded", zap.String("trigger", trigger), zap.Strings("changed", event.Changed))
+		if len(event.Rejected) > 0 {
+			r.logger.Warn("configuration partly reloaded, restart to apply the rest",
+				zap.String("trigger", trigger), zap.Strings("rejected", event.Rejected))
+		}
+	case reloadUnchanged:
+		r.logger.Debug("configuration unchanged", zap.String("trigger", trigger))
+	case reloadRejected:
+		r.logger.Warn("configuration reload rejected, restart to apply",
+			zap.String("trigger", trigger), zap.Strings("rejected", event.Rejected))
+	default:
+		r.logger.Error("failed to reload configuration", zap.String("trigger", trigger), zap.Error(err))
+	}
+
+	p.ConfigReloadsTotal.WithLabelValues(event.Result).Inc()
+	if event.Result != reloadUnchanged {
+		if perr := r.bus.Publish(ctx, ReloadSubject, event); perr != nil {
+			r.logger.Error("failed to publish reload event", zap.Error(perr))
+		}
+	}
+	return err
+}
+
+func (r *Reloader) reload(event *ReloadEvent) error {
+	event.Result = reloadFailed
+
+	next, err := config.Load(r.opts)
+	if err != nil {
+		return err
+	}
+
+	changed, err := config.Changed(r.current, next)
+	if err != nil {
+		return fmt.Errorf("compare configs: %w", err)
+	}
+	if len(changed) == 0 {
+		event.Result = reloadUnchanged
+		return nil
+	}
+
+	for _, key := range changed {
+		if config.Reloadable(key) {
+			event.Changed = append(event.Changed, key)
+		} else {
+			event.Rejected = append(event.Rejected, key)
+		}
+	}
+	if len(event.Changed) == 0 {
+		event.Result = reloadRejected
+		return fmt.Errorf("%w: %s", ErrRestartRequired, strings.Join(event.Rejected, ", "))
+	}
+
+	// the running settings that need a restart are kept, so they keep
+	// showing up as rejected until then
+	merged := config.WithHotSettings(r.current, next)
+	if err := r.apply(merged); err != nil {
+		return err
+	}
+	r.current = merged
+	event.Result = reloadApplied
+	return nil
+}
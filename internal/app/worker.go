@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go-service/internal/config"
+	"go-service/internal/models"
+	"go-service/internal/service"
+	"go-service/internal/webhook"
+	"go-service/pkg/eventbus"
+)
+
+// Job is a background task the worker runs every Interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Worker runs background jobs without serving HTTP
+type Worker struct {
+	*Core
+	jobs []Job
+}
+
+func NewWorker(ctx context.Context, opts Options) (*Worker, error) {
+	core, err := NewCore(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Worker{Core: core}
+	// one worker replica evicts what the database announces, the API
+	// replicas get the evictions of their L1 over the bus
+	if cfg := opts.Config.Cache.Notify; cfg.Enabled && core.loader != nil {
+		core.listenCache(ctx, cfg)
+	}
+	if interval := opts.Config.Worker.CacheWarmup.Interval; interval > 0 {
+		w.jobs = append(w.jobs, Job{
+			Name:     "cache-warmup",
+			Interval: interval,
+			Run:      w.warmCache,
+		})
+	}
+	if cfg := opts.Config.Worker.Webhooks; cfg.Interval > 0 {
+		if err := w.listenWebhooks(ctx, cfg.Consumer); err != nil {
+			_ = core.Close(ctx)
+			return nil, fmt.Errorf("subscribe to goods events: %w", err)
+		}
+		dispatcher := webhook.NewDispatcher(webhook.Config{
+			Repo:         core.repos.Webhooks,
+			Timeout:      cfg.Timeout,
+			MaxAttempts:  cfg.MaxAttempts,
+			MinBackoff:   cfg.MinBackoff,
+			MaxBackoff:   cfg.MaxBackoff,
+			Batch:        cfg.Batch,
+			Concurrency:  cfg.Concurrency,
+			AllowPrivate: cfg.AllowPrivate,
+			Logger:       core.Logger,
+			Tracer:       core.Tracer,
+		})
+		w.jobs = append(w.jobs, Job{
+			Name:     "webhook-delivery",
+			Interval: cfg.Interval,
+			Run: func(ctx context.Context) error {
+				_, err := dispatcher.Deliver(ctx)
+				return err
+			},
+		})
+	}
+	return w, nil
+}
+
+// Run starts every job and blocks until ctx is done
+func (w *Worker) Run(ctx context.Context) error {
+	if len(w.jobs) == 0 {
+		w.Logger.Warn("no background jobs enabled")
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range w.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			w.loop(ctx, job)
+		}(job)
+	}
+
+	<-ctx.Done()
+	wg.Wait()
+	return nil
+}
+
+func (w *Worker) Shutdown(ctx context.Context) error {
+	return w.Core.Close(ctx)
+}
+
+func (w *Worker) loop(ctx context.Context, job Job) {
+	w.Logger.Info("job started", zap.String("job", job.Name), zap.Duration("interval", job.Interval))
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+			w.Logger.Error("job failed", zap.String("job", job.Name), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// warmCache keeps the first pages of the lists cached, so readers rarely pay for a miss
+func (w *Worker) warmCache(ctx context.Context) error {
+	ctx, span := w.Tracer.Start(ctx, "warmCache")
+	defer span.End()
+
+	if _, err := w.Services.Projects.GetAll(ctx, 0, 0); err != nil {
+		return err
+	}
+	_, err := w.Services.Goods.GetAll(ctx, 0, 0)
+	return err
+}
+
+// listenWebhooks queues deliveries for the goods events, the delivery job
+// sends them. Durable buses keep the events the worker missed while it was
+// down, core NATS drops them
+func (w *Worker) listenWebhooks(ctx context.Context, consumer config.ConsumerConfig) error {
+	return w.Bus.SubscribeGroup(ctx, service.GoodsEventsSubjects, eventbus.Group{
+		Name:       consumer.Durable,
+		AckWait:    consumer.AckWait,
+		MaxDeliver: consumer.MaxDeliver,
+		Backoff:    consumer.Backoff,
+		DeadLetter: consumer.DeadLetter,
+	}, func(ctx context.Context, msg eventbus.Message) error {
+		return w.queueWebhooks(ctx, msg.Subject, msg.Data)
+	})
+}
+
+func (w *Worker) queueWebhooks(ctx context.Context, subject string, data []byte) error {
+	var event models.GoodsEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return eventbus.Permanent(fmt.Errorf("malformed goods event on %s: %w", subject, err))
+	}
+	if _, err := w.Services.Webhooks.Notify(ctx, event); err != nil {
+		return fmt.Errorf("queue webhook deliveries for %s: %w", event.Type, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind classifies an error independently of transport
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindPrecondition
+	KindUnavailable
+	KindRateLimited
+	KindUnsupportedMediaType
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindValidation:
+		return "validation"
+	case KindPrecondition:
+		return "precondition"
+	case KindUnavailable:
+		return "unavailable"
+	case KindRateLimited:
+		return "rate_limited"
+	case KindUnsupportedMediaType:
+		return "unsupported_media_type"
+	default:
+		return "internal"
+	}
+}
+
+// FieldError describes one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error returned by repositories and services.
+//
+// Code is stable and meant for clients, e.g. "goods.not_found". Message is
+// safe to show to the caller, the wrapped Err is for logs only.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors of the same kind and code, so errors.Is works against
+// sentinel values like repository.ErrNotFound after wrapping
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Kind == t.Kind && e.Code == t.Code
+}
+
+// Wrap returns a copy of e that carries err as its cause
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Validation(code, message string, fields ...FieldError) *Error {
+	e := New(KindValidation, code, message)
+	e.Fields = fields
+	return e
+}
+
+func Precondition(code, message string) *Error {
+	return New(KindPrecondition, code, message)
+}
+
+func Unavailable(code, message string, err error) *Error {
+	e := New(KindUnavailable, code, message)
+	e.Err = err
+	return e
+}
+
+func RateLimited(code, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+func UnsupportedMediaType(code, message string) *Error {
+	return New(KindUnsupportedMediaType, code, message)
+}
+
+func Internal(err error) *Error {
+	e := New(KindInternal, "internal", "internal server error")
+	e.Err = err
+	return e
+}
+
+// From returns the domain error in err's chain, anything else is internal
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal(err)
+}
@@ -0,0 +1,380 @@
+package config
+
+import (
+	"maps"
+	"sync/atomic"
+	"time"
+)
+
+// Config is the whole service configuration, loaded once by Load and passed
+// to constructors explicitly
+type Config struct {
+	Port      string          `mapstructure:"port"`
+	GRPC      GRPCConfig      `mapstructure:"grpc"`
+	Debug     bool            `mapstructure:"debug"`
+	Log       LogConfig       `mapstructure:"log"`
+	DB        DBConfig        `mapstructure:"db"`
+	Redis     RedisConfig     `mapstructure:"rdb"`
+	Nats      NatsConfig      `mapstructure:"nats"`
+	Bus       BusConfig       `mapstructure:"bus"`
+	Tracer    TracerConfig    `mapstructure:"tracer"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	RateLimit RateLimitConfig `mapstructure:"ratelimit"`
+	Events    EventsConfig    `mapstructure:"events"`
+	Features  Features        `mapstructure:"features"`
+	Worker    WorkerConfig    `mapstructure:"worker"`
+
+	// ShutdownTimeout bounds the wait for in-flight requests and jobs on stop
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+}
+
+type GRPCConfig struct {
+	// Port of the gRPC API, empty turns it off
+	Port string `mapstructure:"port"`
+	// Reflection lets clients like grpcurl discover the services
+	Reflection bool `mapstructure:"reflection"`
+}
+
+type LogConfig struct {
+	// Level is one of debug, info, warn or error
+	Level string `mapstructure:"level"`
+}
+
+const (
+	DriverPostgres = "postgres"
+	// DriverMemory keeps data in process memory, for tests and demo environments
+	DriverMemory = "memory"
+)
+
+type DBConfig struct {
+	Driver      string     `mapstructure:"driver"`
+	Host        string     `mapstructure:"host"`
+	Port        string     `mapstructure:"port"`
+	DBName      string     `mapstructure:"dbname"`
+	SSLMode     string     `mapstructure:"sslmode"`
+	Username    string     `mapstructure:"username"`
+	Password    string     `mapstructure:"password"`
+	AutoMigrate bool       `mapstructure:"auto_migrate"`
+	Timeouts    DBTimeouts `mapstructure:"timeouts"`
+}
+
+type DBTimeouts struct {
+	Read  time.Duration `mapstructure:"read"`
+	Write time.Duration `mapstructure:"write"`
+}
+
+type RedisConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     string `mapstructure:"port"`
+	DB       int    `mapstructure:"dbname"`
+	Password string `mapstructure:"password"`
+}
+
+type NatsConfig struct {
+	URL       string          `mapstructure:"url"`
+	JetStream JetStreamConfig `mapstructure:"jetstream"`
+	Commands  CommandsConfig  `mapstructure:"commands"`
+}
+
+// CommandsConfig turns on the request-reply API for workers without HTTP access
+type CommandsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Prefix comes before the subjects, svc answers on svc.goods.create
+	Prefix string `mapstructure:"prefix"`
+	// Queue is the queue group the replicas share the requests in
+	Queue   string        `mapstructure:"queue"`
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Concurrency is how many requests of one subject a replica handles at once
+	Concurrency int `mapstructure:"concurrency"`
+}
+
+// JetStreamConfig turns on durable events, the streams are provisioned on start
+type JetStreamConfig struct {
+	Enabled bool           `mapstructure:"enabled"`
+	Streams []StreamConfig `mapstructure:"streams"`
+}
+
+type StreamConfig struct {
+	Name     string        `mapstructure:"name"`
+	Subjects []string      `mapstructure:"subjects"`
+	MaxAge   time.Duration `mapstructure:"max_age"`
+	// Duplicates is the window message IDs are deduplicated in
+	Duplicates time.Duration `mapstructure:"duplicates"`
+	Replicas   int           `mapstructure:"replicas"`
+}
+
+const (
+	BusNats  = "nats"
+	BusRedis = "redis"
+	// BusMemory only reaches subscribers of the same process, for tests
+	BusMemory = "memory"
+)
+
+// BusConfig selects the message bus every producer and consumer uses
+type BusConfig struct {
+	Driver string         `mapstructure:"driver"`
+	Redis  RedisBusConfig `mapstructure:"redis"`
+}
+
+// RedisBusConfig keeps the messages in Redis Streams of the rdb connection
+type RedisBusConfig struct {
+	// Prefix comes before the stream keys, a stream per first subject token
+	Prefix string `mapstructure:"prefix"`
+	// MaxLen trims the streams to about this many messages
+	MaxLen int64 `mapstructure:"max_len"`
+	// Block is how long a read waits for messages
+	Block time.Duration `mapstructure:"block"`
+}
+
+// ConsumerConfig is a consumer group of the bus, durable with JetStream and
+// Redis Streams
+type ConsumerConfig struct {
+	Durable    string          `mapstructure:"durable"`
+	AckWait    time.Duration   `mapstructure:"ack_wait"`
+	MaxDeliver int             `mapstructure:"max_deliver"`
+	Backoff    []time.Duration `mapstructure:"backoff"`
+	// DeadLetter is the subject of messages that ran out of deliveries
+	DeadLetter string `mapstructure:"dead_letter"`
+}
+
+type TracerConfig struct {
+	Exporter    string  `mapstructure:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	Sampler     string  `mapstructure:"sampler"`
+	Ratio       float64 `mapstructure:"ratio"`
+	Version     string  `mapstructure:"version"`
+	Environment string  `mapstructure:"environment"`
+	Instance    string  `mapstructure:"instance"`
+}
+
+type CacheConfig struct {
+	Local    LocalCacheConfig `mapstructure:"local"`
+	Lock     LockConfig       `mapstructure:"lock"`
+	Notify   NotifyConfig     `mapstructure:"notify"`
+	Goods    CachePolicy      `mapstructure:"goods"`
+	Projects CachePolicy      `mapstructure:"projects"`
+	Lists    CachePolicy      `mapstructure:"lists"`
+}
+
+type LocalCacheConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	TTL        time.Duration `mapstructure:"ttl"`
+	MaxEntries int           `mapstructure:"max_entries"`
+	Subject    string        `mapstructure:"subject"`
+}
+
+type LockConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	TTL     time.Duration `mapstructure:"ttl"`
+	Wait    time.Duration `mapstructure:"wait"`
+}
+
+// NotifyConfig evicts the rows the database triggers announce, changed by
+// direct SQL, migrations or other services. One worker listens at a time,
+// the others wait on standby
+type NotifyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// MinBackoff and MaxBackoff bound the waits between reconnects
+	MinBackoff time.Duration `mapstructure:"min_backoff"`
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+	// Timeout bounds the eviction of one notification
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+type CachePolicy struct {
+	TTL    time.Duration `mapstructure:"ttl"`
+	Jitter time.Duration `mapstructure:"jitter"`
+	Stale  time.Duration `mapstructure:"stale"`
+}
+
+type RateLimitConfig struct {
+	// RPS is requests per second the API accepts per replica, 0 turns the limit off
+	RPS   float64 `mapstructure:"rps"`
+	Burst int     `mapstructure:"burst"`
+}
+
+// EventsConfig tunes the events the service publishes and the goods event
+// streams of the API
+type EventsConfig struct {
+	// Mode is the CloudEvents content mode, binary or structured
+	Mode string `mapstructure:"mode"`
+	// Source is the source attribute of the events
+	Source string `mapstructure:"source"`
+	// SchemaURL is where /api/events/schemas is reachable from consumers,
+	// the dataschema of the events points below it
+	SchemaURL string `mapstructure:"schema_url"`
+	// Buffer is how many recent events per project are kept to resume streams
+	Buffer int `mapstructure:"buffer"`
+	// ClientBuffer is how many events wait for one client before it is dropped
+	ClientBuffer   int           `mapstructure:"client_buffer"`
+	MaxConnections int           `mapstructure:"max_connections"`
+	Heartbeat      time.Duration `mapstructure:"heartbeat"`
+	// Retention is how long the events of a project without streams are kept
+	Retention time.Duration `mapstructure:"retention"`
+}
+
+// Features switches optional behaviour on by name
+type Features map[string]bool
+
+// Enabled reports whether the feature is switched on, unknown features are off
+func (f Features) Enabled(name string) bool {
+	return f[name]
+}
+
+// FeatureFlags holds the features of a running service, a reload swaps them
+// as a whole
+type FeatureFlags struct {
+	current atomic.Pointer[Features]
+}
+
+func NewFeatureFlags(features Features) *FeatureFlags {
+	f := &FeatureFlags{}
+	f.Store(features)
+	return f
+}
+
+func (f *FeatureFlags) Load() Features {
+	return *f.current.Load()
+}
+
+func (f *FeatureFlags) Store(features Features) {
+	features = maps.Clone(features)
+	f.current.Store(&features)
+}
+
+// Enabled reports whether the feature is switched on right now
+func (f *FeatureFlags) Enabled(name string) bool {
+	return f.Load().Enabled(name)
+}
+
+type WorkerConfig struct {
+	CacheWarmup CacheWarmupConfig `mapstructure:"cache_warmup"`
+	Webhooks    WebhooksConfig    `mapstructure:"webhooks"`
+}
+
+type CacheWarmupConfig struct {
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// WebhooksConfig tunes the webhook delivery job, a zero interval turns it off
+type WebhooksConfig struct {
+	Interval time.Duration `mapstructure:"interval"`
+	// Timeout bounds one delivery attempt
+	Timeout     time.Duration `mapstructure:"timeout"`
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	MinBackoff  time.Duration `mapstructure:"min_backoff"`
+	MaxBackoff  time.Duration `mapstructure:"max_backoff"`
+	Batch       int           `mapstructure:"batch"`
+	Concurrency int           `mapstructure:"concurrency"`
+	// AllowPrivate accepts receivers on private, loopback and link-local
+	// addresses, the API and the worker refuse them otherwise
+	AllowPrivate bool `mapstructure:"allow_private"`
+	// Consumer queues the goods events for delivery
+	Consumer ConsumerConfig `mapstructure:"consumer"`
+}
+
+// defaults also tell viper which keys exist, so every key can be
+// overridden from the environment
+var defaults = map[string]any{
+	"port":             "8000",
+	"shutdown_timeout": "15s",
+	"debug":            false,
+
+	"grpc.port":       "9000",
+	"grpc.reflection": true,
+
+	"log.level": "info",
+
+	"db.driver":         DriverPostgres,
+	"db.host":           "localhost",
+	"db.port":           "5432",
+	"db.dbname":         "postgres",
+	"db.sslmode":        "disable",
+	"db.username":       "",
+	"db.password":       "",
+	"db.auto_migrate":   false,
+	"db.timeouts.read":  "2s",
+	"db.timeouts.write": "5s",
+
+	"rdb.host":     "localhost",
+	"rdb.port":     "6379",
+	"rdb.dbname":   0,
+	"rdb.password": "",
+
+	"nats.url":               "nats://localhost:4222",
+	"nats.jetstream.enabled": false,
+	"nats.jetstream.streams": []map[string]any{
+		{"name": "GOODS_EVENTS", "subjects": []string{"goods.events.*"}, "max_age": "72h", "duplicates": "2m", "replicas": 1},
+		{"name": "DEAD_LETTERS", "subjects": []string{"dlq.>"}, "max_age": "336h", "duplicates": "2m", "replicas": 1},
+	},
+	"nats.commands.enabled":     false,
+	"nats.commands.prefix":      "svc",
+	"nats.commands.queue":       "go-service",
+	"nats.commands.timeout":     "5s",
+	"nats.commands.concurrency": 16,
+
+	"bus.driver":        BusNats,
+	"bus.redis.prefix":  "bus:",
+	"bus.redis.max_len": 100000,
+	"bus.redis.block":   "2s",
+
+	"tracer.exporter":    "none",
+	"tracer.endpoint":    "",
+	"tracer.insecure":    false,
+	"tracer.sampler":     "parent-based",
+	"tracer.ratio":       1.0,
+	"tracer.version":     "",
+	"tracer.environment": "",
+	"tracer.instance":    "",
+
+	"cache.local.enabled":      false,
+	"cache.local.ttl":          "10s",
+	"cache.local.max_entries":  10000,
+	"cache.local.subject":      "cache.invalidate",
+	"cache.lock.enabled":       false,
+	"cache.lock.ttl":           "5s",
+	"cache.lock.wait":          "2s",
+	"cache.notify.enabled":     true,
+	"cache.notify.min_backoff": "1s",
+	"cache.notify.max_backoff": "30s",
+	"cache.notify.timeout":     "5s",
+	"cache.goods.ttl":          "1m",
+	"cache.goods.jitter":       "0s",
+	"cache.goods.stale":        "0s",
+	"cache.projects.ttl":       "1m",
+	"cache.projects.jitter":    "0s",
+	"cache.projects.stale":     "0s",
+	"cache.lists.ttl":          "30s",
+	"cache.lists.jitter":       "0s",
+	"cache.lists.stale":        "0s",
+
+	"ratelimit.rps":   0.0,
+	"ratelimit.burst": 0,
+
+	"events.mode":            "binary",
+	"events.source":          "go-service",
+	"events.schema_url":      "http://localhost:8000/api/events/schemas",
+	"events.buffer":          256,
+	"events.client_buffer":   64,
+	"events.max_connections": 1000,
+	"events.heartbeat":       "15s",
+	"events.retention":       "5m",
+
+	"features": map[string]bool{},
+
+	"worker.cache_warmup.interval":         "0s",
+	"worker.webhooks.interval":             "0s",
+	"worker.webhooks.timeout":              "10s",
+	"worker.webhooks.max_attempts":         8,
+	"worker.webhooks.min_backoff":          "10s",
+	"worker.webhooks.max_backoff":          "1h",
+	"worker.webhooks.batch":                100,
+	"worker.webhooks.concurrency":          8,
+	"worker.webhooks.allow_private":        false,
+	"worker.webhooks.consumer.durable":     "webhooks",
+	"worker.webhooks.consumer.ack_wait":    "30s",
+	"worker.webhooks.consumer.max_deliver": 5,
+	"worker.webhooks.consumer.backoff":     []string{"1s", "10s", "1m"},
+	"worker.webhooks.consumer.dead_letter": "dlq.webhooks",
+}
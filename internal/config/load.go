@@ -0,0 +1,127 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/spf13/viper"
+)
+
+const (
+	// EnvPrefix prefixes environment overrides, GOSERVICE_DB_HOST sets db.host
+	EnvPrefix = "GOSERVICE"
+	// fileSuffix marks variables that name a file holding the value, like GOSERVICE_DB_PASSWORD_FILE
+	fileSuffix = "_FILE"
+)
+
+type Options struct {
+	// Dir holds config.yml and the profile overlays, configs by default
+	Dir string
+	// Profile selects config.<profile>.yml on top of config.yml, GOSERVICE_PROFILE by default
+	Profile string
+}
+
+// Files lists config.yml and the profile overlay, if one is selected
+func (o Options) Files() []string {
+	o = o.withDefaults()
+	files := []string{filepath.Join(o.Dir, "config.yml")}
+	if o.Profile != "" {
+		files = append(files, filepath.Join(o.Dir, "config."+o.Profile+".yml"))
+	}
+	return files
+}
+
+func (o Options) withDefaults() Options {
+	if o.Dir == "" {
+		o.Dir = "configs"
+	}
+	if o.Profile == "" {
+		o.Profile = os.Getenv(EnvPrefix + "_PROFILE")
+	}
+	return o
+}
+
+// legacyEnv are the variables the service read before GOSERVICE_* overrides existed
+var legacyEnv = map[string]string{
+	"DB_USERNAME": "db.username",
+	"DB_PASSWORD": "db.password",
+}
+
+// Load builds the configuration from, lowest precedence first: defaults,
+// config.yml, config.<profile>.yml, an optional .env file, GOSERVICE_*
+// variables and GOSERVICE_*_FILE secrets. Unknown keys and invalid values
+// are reported together, the config decoded so far is returned along with
+// the error
+func Load(opts Options) (*Config, error) {
+	opts = opts.withDefaults()
+
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("load .env: %w", err)
+	}
+
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+
+	files := opts.Files()
+	v.SetConfigFile(files[0])
+	if err := v.ReadInConfig(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	if len(files) > 1 {
+		v.SetConfigFile(files[1])
+		if err := v.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("read profile %q: %w", opts.Profile, err)
+		}
+	}
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	for env, key := range legacyEnv {
+		if value, ok := os.LookupEnv(env); ok && os.Getenv(envName(key)) == "" {
+			v.Set(key, value)
+		}
+	}
+
+	if err := readSecretFiles(v); err != nil {
+		return nil, err
+	}
+
+	var config Config
+	var decodeErr error
+	if err := v.UnmarshalExact(&config); err != nil {
+		decodeErr = fmt.Errorf("decode config: %w", err)
+	}
+	return &config, errors.Join(decodeErr, config.Validate())
+}
+
+// readSecretFiles replaces a key with the contents of the file named by its
+// _FILE variable, the way container secrets are usually mounted
+func readSecretFiles(v *viper.Viper) error {
+	var errs []error
+	for _, key := range v.AllKeys() {
+		path := os.Getenv(envName(key) + fileSuffix)
+		if path == "" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		v.Set(key, strings.TrimRight(string(data), "\r\n"))
+	}
+	return errors.Join(errs...)
+}
+
+func envName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
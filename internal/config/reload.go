@@ -0,0 +1,103 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// hotSettings can change while the service runs, a key with a trailing dot
+// covers a whole section. Anything else, like db.host, needs a restart
+var hotSettings = []struct {
+	key  string
+	copy func(dst, src *Config)
+}{
+	{"log.level", func(dst, src *Config) { dst.Log.Level = src.Log.Level }},
+	{"cache.goods.", func(dst, src *Config) { dst.Cache.Goods = src.Cache.Goods }},
+	{"cache.projects.", func(dst, src *Config) { dst.Cache.Projects = src.Cache.Projects }},
+	{"cache.lists.", func(dst, src *Config) { dst.Cache.Lists = src.Cache.Lists }},
+	{"ratelimit.", func(dst, src *Config) { dst.RateLimit = src.RateLimit }},
+	{"tracer.ratio", func(dst, src *Config) { dst.Tracer.Ratio = src.Tracer.Ratio }},
+	{"features.", func(dst, src *Config) { dst.Features = src.Features }},
+}
+
+// Reloadable reports whether key can be applied without a restart
+func Reloadable(key string) bool {
+	for _, hot := range hotSettings {
+		if key == hot.key || strings.HasSuffix(hot.key, ".") && strings.HasPrefix(key, hot.key) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithHotSettings returns a copy of c that has the hot-reloadable settings
+// of next, the settings that need a restart stay as they are in c
+func WithHotSettings(c, next *Config) *Config {
+	merged := *c
+	for _, hot := range hotSettings {
+		hot.copy(&merged, next)
+	}
+	return &merged
+}
+
+// Changed lists the keys whose values differ between two configs
+func Changed(old, new *Config) ([]string, error) {
+	before, err := flatten(old)
+	if err != nil {
+		return nil, err
+	}
+	after, err := flatten(new)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for key, value := range after {
+		if prev, ok := before[key]; !ok || !reflect.DeepEqual(prev, value) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// flatten keys every leaf setting by its dotted path, the way viper does
+func flatten(c *Config) (map[string]any, error) {
+	settings := make(map[string]any)
+	if err := mapstructure.Decode(c, &settings); err != nil {
+		return nil, err
+	}
+
+	flat := make(map[string]any)
+	var walk func(prefix string, value reflect.Value)
+	walk = func(prefix string, value reflect.Value) {
+		if value.Kind() == reflect.Interface {
+			value = value.Elem()
+		}
+		if !value.IsValid() {
+			flat[prefix] = nil
+			return
+		}
+		if value.Kind() != reflect.Map || value.Type().Key().Kind() != reflect.String {
+			flat[prefix] = value.Interface()
+			return
+		}
+		for _, key := range value.MapKeys() {
+			path := key.String()
+			if prefix != "" {
+				path = prefix + "." + path
+			}
+			walk(path, value.MapIndex(key))
+		}
+	}
+	walk("", reflect.ValueOf(settings))
+	return flat, nil
+}
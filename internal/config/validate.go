@@ -0,0 +1,301 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"go.uber.org/zap/zapcore"
+
+	"go-service/pkg/cloudevents"
+	"go-service/pkg/tracer"
+)
+
+const redacted = "[redacted]"
+
+// Validate reports every invalid key at once
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		fail("port", "%q is not a valid port", c.Port)
+	}
+	if c.GRPC.Port != "" {
+		if port, err := strconv.Atoi(c.GRPC.Port); err != nil || port < 1 || port > 65535 {
+			fail("grpc.port", "%q is not a valid port", c.GRPC.Port)
+		} else if c.GRPC.Port == c.Port {
+			fail("grpc.port", "%q is already used by the HTTP server", c.GRPC.Port)
+		}
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		fail("shutdown_timeout", "must be positive")
+	}
+
+	if _, err := zapcore.ParseLevel(c.Log.Level); err != nil {
+		fail("log.level", "unknown level %q", c.Log.Level)
+	}
+
+	required := map[string]string{}
+	if c.UsesNats() {
+		required["nats.url"] = c.Nats.URL
+	}
+	switch c.Bus.Driver {
+	case BusNats:
+	case BusRedis:
+		required["rdb.host"] = c.Redis.Host
+		required["rdb.port"] = c.Redis.Port
+		if c.Bus.Redis.Block <= 0 {
+			fail("bus.redis.block", "must be positive")
+		}
+		if c.Bus.Redis.MaxLen < 1 {
+			fail("bus.redis.max_len", "must be positive")
+		}
+	case BusMemory:
+	default:
+		fail("bus.driver", "unknown driver %q", c.Bus.Driver)
+	}
+	if c.Nats.JetStream.Enabled && c.Bus.Driver != BusNats {
+		fail("nats.jetstream.enabled", "needs bus.driver %s", BusNats)
+	}
+	switch c.DB.Driver {
+	case DriverPostgres:
+		required["db.host"] = c.DB.Host
+		required["db.port"] = c.DB.Port
+		required["db.dbname"] = c.DB.DBName
+		required["db.username"] = c.DB.Username
+		required["db.password"] = c.DB.Password
+		required["rdb.host"] = c.Redis.Host
+		required["rdb.port"] = c.Redis.Port
+	case DriverMemory:
+	default:
+		fail("db.driver", "unknown driver %q", c.DB.Driver)
+	}
+	for key, value := range required {
+		if value == "" {
+			fail(key, "is required")
+		}
+	}
+
+	if _, err := url.Parse(c.Nats.URL); err != nil && c.UsesNats() {
+		fail("nats.url", "%v", err)
+	}
+
+	if c.Nats.JetStream.Enabled {
+		if len(c.Nats.JetStream.Streams) == 0 {
+			fail("nats.jetstream.streams", "is required when JetStream is enabled")
+		}
+		for i, stream := range c.Nats.JetStream.Streams {
+			key := fmt.Sprintf("nats.jetstream.streams[%d]", i)
+			if stream.Name == "" || strings.ContainsAny(stream.Name, ".*> ") {
+				fail(key+".name", "%q is not a valid stream name", stream.Name)
+			}
+			if len(stream.Subjects) == 0 {
+				fail(key+".subjects", "is required")
+			}
+			if stream.MaxAge < 0 || stream.Duplicates < 0 || stream.Replicas < 0 {
+				fail(key, "max_age, duplicates and replicas must not be negative")
+			}
+		}
+	}
+
+	if commands := c.Nats.Commands; commands.Enabled {
+		if commands.Prefix == "" || strings.ContainsAny(commands.Prefix, "*> ") {
+			fail("nats.commands.prefix", "%q is not a valid subject prefix", commands.Prefix)
+		}
+		if commands.Queue == "" || strings.ContainsAny(commands.Queue, "*> ") {
+			fail("nats.commands.queue", "%q is not a valid queue group", commands.Queue)
+		}
+		if commands.Timeout <= 0 {
+			fail("nats.commands.timeout", "must be positive")
+		}
+		if commands.Concurrency < 1 {
+			fail("nats.commands.concurrency", "must be positive")
+		}
+	}
+
+	durations := map[string]time.Duration{
+		"db.timeouts.read":             c.DB.Timeouts.Read,
+		"db.timeouts.write":            c.DB.Timeouts.Write,
+		"cache.local.ttl":              c.Cache.Local.TTL,
+		"cache.lock.ttl":               c.Cache.Lock.TTL,
+		"cache.lock.wait":              c.Cache.Lock.Wait,
+		"cache.goods.ttl":              c.Cache.Goods.TTL,
+		"cache.goods.jitter":           c.Cache.Goods.Jitter,
+		"cache.goods.stale":            c.Cache.Goods.Stale,
+		"cache.projects.ttl":           c.Cache.Projects.TTL,
+		"cache.projects.jitter":        c.Cache.Projects.Jitter,
+		"cache.projects.stale":         c.Cache.Projects.Stale,
+		"cache.lists.ttl":              c.Cache.Lists.TTL,
+		"cache.lists.jitter":           c.Cache.Lists.Jitter,
+		"cache.lists.stale":            c.Cache.Lists.Stale,
+		"worker.cache_warmup.interval": c.Worker.CacheWarmup.Interval,
+		"worker.webhooks.interval":     c.Worker.Webhooks.Interval,
+	}
+	for key, d := range durations {
+		if d < 0 {
+			fail(key, "must not be negative")
+		}
+	}
+	if c.Cache.Local.Enabled && c.Cache.Local.MaxEntries <= 0 {
+		fail("cache.local.max_entries", "must be positive when the local cache is enabled")
+	}
+	if c.Cache.Notify.Enabled {
+		if c.Cache.Notify.MinBackoff <= 0 {
+			fail("cache.notify.min_backoff", "must be positive")
+		}
+		if c.Cache.Notify.MaxBackoff < c.Cache.Notify.MinBackoff {
+			fail("cache.notify.max_backoff", "must not be less than min_backoff")
+		}
+		if c.Cache.Notify.Timeout <= 0 {
+			fail("cache.notify.timeout", "must be positive")
+		}
+	}
+
+	if c.RateLimit.RPS < 0 {
+		fail("ratelimit.rps", "must not be negative")
+	}
+	if c.RateLimit.RPS > 0 && c.RateLimit.Burst < 1 {
+		fail("ratelimit.burst", "must be positive when the rate limit is on")
+	}
+
+	switch cloudevents.Mode(c.Events.Mode) {
+	case cloudevents.ModeBinary, cloudevents.ModeStructured:
+	default:
+		fail("events.mode", "unknown mode %q", c.Events.Mode)
+	}
+	if c.Events.Source == "" {
+		fail("events.source", "is required")
+	}
+	if u, err := url.Parse(c.Events.SchemaURL); err != nil || !u.IsAbs() {
+		fail("events.schema_url", "%q is not an absolute URL", c.Events.SchemaURL)
+	}
+	if c.Events.Buffer < 1 {
+		fail("events.buffer", "must be positive")
+	}
+	if c.Events.ClientBuffer < 1 {
+		fail("events.client_buffer", "must be positive")
+	}
+	if c.Events.MaxConnections < 0 {
+		fail("events.max_connections", "must not be negative")
+	}
+	if c.Events.Heartbeat <= 0 {
+		fail("events.heartbeat", "must be positive")
+	}
+
+	if c.Worker.Webhooks.Interval > 0 {
+		if c.Worker.Webhooks.Timeout <= 0 {
+			fail("worker.webhooks.timeout", "must be positive")
+		}
+		if c.Worker.Webhooks.MaxAttempts < 1 {
+			fail("worker.webhooks.max_attempts", "must be positive")
+		}
+		if c.Worker.Webhooks.MinBackoff <= 0 {
+			fail("worker.webhooks.min_backoff", "must be positive")
+		}
+		if c.Worker.Webhooks.MaxBackoff < c.Worker.Webhooks.MinBackoff {
+			fail("worker.webhooks.max_backoff", "must not be less than min_backoff")
+		}
+		if c.Worker.Webhooks.Batch < 1 {
+			fail("worker.webhooks.batch", "must be positive")
+		}
+		if c.Worker.Webhooks.Concurrency < 1 {
+			fail("worker.webhooks.concurrency", "must be positive")
+		}
+		if consumer := c.Worker.Webhooks.Consumer; c.Nats.JetStream.Enabled {
+			if consumer.Durable == "" || strings.ContainsAny(consumer.Durable, ".*> ") {
+				fail("worker.webhooks.consumer.durable", "%q is not a valid consumer name", consumer.Durable)
+			}
+			if consumer.AckWait <= 0 {
+				fail("worker.webhooks.consumer.ack_wait", "must be positive")
+			}
+			if consumer.MaxDeliver < 1 {
+				fail("worker.webhooks.consumer.max_deliver", "must be positive")
+			} else if len(consumer.Backoff) >= consumer.MaxDeliver {
+				fail("worker.webhooks.consumer.backoff", "needs fewer steps than max_deliver")
+			}
+		}
+	}
+
+	switch c.Tracer.Exporter {
+	case "", tracer.ExporterOTLPGRPC, tracer.ExporterOTLPHTTP, tracer.ExporterStdout, tracer.ExporterNone:
+	default:
+		fail("tracer.exporter", "unknown exporter %q", c.Tracer.Exporter)
+	}
+	switch c.Tracer.Sampler {
+	case "", tracer.SamplerAlways, tracer.SamplerNever, tracer.SamplerRatio, tracer.SamplerParentBased:
+	default:
+		fail("tracer.sampler", "unknown sampler %q", c.Tracer.Sampler)
+	}
+	if c.Tracer.Ratio < 0 || c.Tracer.Ratio > 1 {
+		fail("tracer.ratio", "%v is not within [0, 1]", c.Tracer.Ratio)
+	}
+
+	// map iteration is random, keep the report stable
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
+
+// UsesNats reports whether the service connects to NATS at all
+func (c *Config) UsesNats() bool {
+	return c.Bus.Driver == BusNats || c.Nats.Commands.Enabled
+}
+
+// Redacted returns the settings keyed like the config file, with secrets
+// masked and durations written the way they are configured
+func (c *Config) Redacted() (map[string]any, error) {
+	settings := make(map[string]any)
+	if err := mapstructure.Decode(c, &settings); err != nil {
+		return nil, err
+	}
+	redact(settings)
+	return settings, nil
+}
+
+func redact(settings map[string]any) {
+	for key, value := range settings {
+		switch v := value.(type) {
+		case map[string]any:
+			redact(v)
+		case time.Duration:
+			settings[key] = v.String()
+		case []time.Duration:
+			durations := make([]string, len(v))
+			for i, d := range v {
+				durations[i] = d.String()
+			}
+			settings[key] = durations
+		case []StreamConfig:
+			// mapstructure leaves slices of structs alone, decode them like the rest
+			streams := make([]map[string]any, len(v))
+			for i, stream := range v {
+				streams[i] = make(map[string]any)
+				_ = mapstructure.Decode(stream, &streams[i])
+				redact(streams[i])
+			}
+			settings[key] = streams
+		case string:
+			if isSecret(key) && v != "" {
+				settings[key] = redacted
+			}
+		}
+	}
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range []string{"password", "secret", "token"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
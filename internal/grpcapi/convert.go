@@ -0,0 +1,35 @@
+package grpcapi
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	goservicev1 "go-service/api/goservice/v1"
+	"go-service/internal/models"
+)
+
+func projectToProto(project models.Project) *goservicev1.Project {
+	return &goservicev1.Project{
+		Id:        int64(project.ID),
+		Name:      project.Name,
+		CreatedAt: timestamppb.New(project.CreatedAt),
+	}
+}
+
+func goodsToProto(goods models.Goods) *goservicev1.Goods {
+	return &goservicev1.Goods{
+		Id:          int64(goods.ID),
+		ProjectId:   int64(goods.ProjectID),
+		Name:        goods.Name,
+		Description: goods.Description,
+		Priority:    int64(goods.Priority),
+		Removed:     goods.Removed,
+		CreatedAt:   timestamppb.New(goods.CreatedAt),
+	}
+}
+
+func pageFromProto(page *goservicev1.Page) models.Page {
+	return models.Page{
+		Limit:  int(page.GetLimit()),
+		Offset: int(page.GetOffset()),
+	}
+}
@@ -0,0 +1,90 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+
+	"go-service/internal/apperror"
+	"go-service/pkg/logger"
+)
+
+const errorDomain = "go-service"
+
+// codeFor maps error kinds the way statusFor of the REST handlers does, so
+// both APIs fail the same way
+func codeFor(kind apperror.Kind) codes.Code {
+	switch kind {
+	case apperror.KindNotFound:
+		return codes.NotFound
+	case apperror.KindConflict:
+		return codes.Aborted
+	case apperror.KindValidation, apperror.KindUnsupportedMediaType:
+		return codes.InvalidArgument
+	case apperror.KindPrecondition:
+		return codes.FailedPrecondition
+	case apperror.KindUnavailable:
+		return codes.Unavailable
+	case apperror.KindRateLimited:
+		return codes.ResourceExhausted
+	default:
+		return codes.Internal
+	}
+}
+
+// toStatus carries the stable error code in ErrorInfo and invalid fields in
+// BadRequest details
+func toStatus(err *apperror.Error) *status.Status {
+	st := status.New(codeFor(err.Kind), err.Message)
+
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{Reason: err.Code, Domain: errorDomain},
+	}
+	if len(err.Fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(err.Fields))
+		for _, f := range err.Fields {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+			})
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	if withDetails, derr := st.WithDetails(details...); derr == nil {
+		st = withDetails
+	}
+	return st
+}
+
+// errorInterceptor turns errors of the services into gRPC statuses
+func errorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, status.FromContextError(err).Err()
+		}
+
+		e := apperror.From(err)
+		if e.Kind == apperror.KindInternal || e.Kind == apperror.KindUnavailable {
+			logger.GetLogger().Error(e.Message,
+				zap.String("code", e.Code),
+				zap.String("method", info.FullMethod),
+				zap.Error(e),
+			)
+		}
+		return nil, toStatus(e).Err()
+	}
+}
@@ -0,0 +1,112 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	goservicev1 "go-service/api/goservice/v1"
+	"go-service/internal/models"
+	"go-service/internal/service"
+)
+
+type goodsServer struct {
+	goservicev1.UnimplementedGoodsServiceServer
+	goods service.Goods
+}
+
+func (s *goodsServer) CreateGoods(ctx context.Context, req *goservicev1.CreateGoodsRequest) (*goservicev1.CreateGoodsResponse, error) {
+	input := models.Goods{
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+	}
+	if err := validateRequest(input); err != nil {
+		return nil, err
+	}
+	if input.Description == "" {
+		input.Description = input.Name
+	}
+
+	id, err := s.goods.Create(ctx, int(req.GetProjectId()), input)
+	if err != nil {
+		return nil, err
+	}
+	return &goservicev1.CreateGoodsResponse{Id: int64(id)}, nil
+}
+
+func (s *goodsServer) GetGoods(ctx context.Context, req *goservicev1.GetGoodsRequest) (*goservicev1.Goods, error) {
+	goods, err := s.goods.GetOne(ctx, int(req.GetId()), int(req.GetProjectId()))
+	if err != nil {
+		return nil, err
+	}
+	return goodsToProto(goods), nil
+}
+
+func (s *goodsServer) ListGoods(ctx context.Context, req *goservicev1.ListGoodsRequest) (*goservicev1.ListGoodsResponse, error) {
+	page := pageFromProto(req.GetPage())
+	if err := validateRequest(page); err != nil {
+		return nil, err
+	}
+
+	goods, err := s.goods.GetAll(ctx, page.Limit, page.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &goservicev1.ListGoodsResponse{
+		Meta: &goservicev1.ListGoodsResponse_Meta{
+			Total:   int64(goods.Meta.Total),
+			Removed: int64(goods.Meta.Removed),
+			Limit:   int32(goods.Meta.Limit),
+			Offset:  int32(goods.Meta.Offset),
+		},
+		Goods: make([]*goservicev1.Goods, 0, len(goods.Goods)),
+	}
+	for _, item := range goods.Goods {
+		resp.Goods = append(resp.Goods, goodsToProto(item))
+	}
+	return resp, nil
+}
+
+func (s *goodsServer) UpdateGoods(ctx context.Context, req *goservicev1.UpdateGoodsRequest) (*emptypb.Empty, error) {
+	var input models.UpdateGoods
+	if req.Name != nil {
+		input.Name = models.Some(req.GetName())
+	}
+	if req.Description != nil {
+		input.Description = models.Some(req.GetDescription())
+	}
+	if req.Priority != nil {
+		input.Priority = models.Some(int(req.GetPriority()))
+	}
+	if req.Removed != nil {
+		input.Removed = models.Some(req.GetRemoved())
+	}
+	if err := validateRequest(input); err != nil {
+		return nil, err
+	}
+
+	if err := s.goods.Update(ctx, int(req.GetId()), int(req.GetProjectId()), input); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *goodsServer) DeleteGoods(ctx context.Context, req *goservicev1.DeleteGoodsRequest) (*emptypb.Empty, error) {
+	if err := s.goods.Delete(ctx, int(req.GetId()), int(req.GetProjectId())); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *goodsServer) ReprioritizeGoods(ctx context.Context, req *goservicev1.ReprioritizeGoodsRequest) (*emptypb.Empty, error) {
+	query := models.PriorityQuery{Priority: int(req.GetPriority())}
+	if err := validateRequest(query); err != nil {
+		return nil, err
+	}
+
+	if err := s.goods.Reprioritize(ctx, int(req.GetId()), int(req.GetProjectId()), query.Priority); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
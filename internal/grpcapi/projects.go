@@ -0,0 +1,84 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	goservicev1 "go-service/api/goservice/v1"
+	"go-service/internal/models"
+	"go-service/internal/service"
+)
+
+type projectsServer struct {
+	goservicev1.UnimplementedProjectsServiceServer
+	projects service.Projects
+}
+
+func (s *projectsServer) CreateProject(ctx context.Context, req *goservicev1.CreateProjectRequest) (*goservicev1.CreateProjectResponse, error) {
+	input := models.Project{Name: req.GetName()}
+	if err := validateRequest(input); err != nil {
+		return nil, err
+	}
+
+	id, err := s.projects.Create(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return &goservicev1.CreateProjectResponse{Id: int64(id)}, nil
+}
+
+func (s *projectsServer) GetProject(ctx context.Context, req *goservicev1.GetProjectRequest) (*goservicev1.Project, error) {
+	project, err := s.projects.GetByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+	return projectToProto(project), nil
+}
+
+func (s *projectsServer) ListProjects(ctx context.Context, req *goservicev1.ListProjectsRequest) (*goservicev1.ListProjectsResponse, error) {
+	page := pageFromProto(req.GetPage())
+	if err := validateRequest(page); err != nil {
+		return nil, err
+	}
+
+	projects, err := s.projects.GetAll(ctx, page.Limit, page.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &goservicev1.ListProjectsResponse{
+		Meta: &goservicev1.ListProjectsResponse_Meta{
+			Total:  int64(projects.Meta.Total),
+			Limit:  int32(projects.Meta.Limit),
+			Offset: int32(projects.Meta.Offset),
+		},
+		Projects: make([]*goservicev1.Project, 0, len(projects.Projects)),
+	}
+	for _, project := range projects.Projects {
+		resp.Projects = append(resp.Projects, projectToProto(project))
+	}
+	return resp, nil
+}
+
+func (s *projectsServer) UpdateProject(ctx context.Context, req *goservicev1.UpdateProjectRequest) (*emptypb.Empty, error) {
+	var input models.UpdateProject
+	if req.Name != nil {
+		input.Name = models.Some(req.GetName())
+	}
+	if err := validateRequest(input); err != nil {
+		return nil, err
+	}
+
+	if err := s.projects.Update(ctx, int(req.GetId()), input); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *projectsServer) DeleteProject(ctx context.Context, req *goservicev1.DeleteProjectRequest) (*emptypb.Empty, error) {
+	if err := s.projects.Delete(ctx, int(req.GetId())); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,86 @@
+package grpcapi
+
+import (
+	"context"
+
+	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	goservicev1 "go-service/api/goservice/v1"
+	"go-service/internal/models"
+	"go-service/internal/service"
+)
+
+type Config struct {
+	// Reflection registers the server reflection service
+	Reflection bool
+}
+
+// Server serves the same services as the REST handlers over gRPC, with the
+// standard health service next to them
+type Server struct {
+	*grpc.Server
+	health *health.Server
+}
+
+func New(services *service.Service, config Config) *Server {
+	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(errorInterceptor()),
+	)
+
+	goservicev1.RegisterProjectsServiceServer(srv, &projectsServer{projects: services.Projects})
+	goservicev1.RegisterGoodsServiceServer(srv, &goodsServer{goods: services.Goods})
+
+	hs := health.NewServer()
+	for name := range srv.GetServiceInfo() {
+		hs.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthpb.RegisterHealthServer(srv, hs)
+
+	if config.Reflection {
+		reflection.Register(srv)
+	}
+
+	return &Server{Server: srv, health: hs}
+}
+
+// Shutdown reports NOT_SERVING to health checks and waits for in-flight
+// calls, the ones still running when ctx is done are cancelled
+func (s *Server) Shutdown(ctx context.Context) {
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
+// validate checks requests against the binding tags of the models, like
+// gin does for the REST handlers
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	models.RegisterValidations(v)
+	return v
+}
+
+func validateRequest(obj any) error {
+	if err := validate.Struct(obj); err != nil {
+		return models.ValidationError("request.invalid", "request is invalid", err)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-service/internal/apperror"
+	"go-service/internal/stream"
+	p "go-service/pkg/prometheus"
+)
+
+const (
+	// sseRetry tells EventSource clients how long to wait before reconnecting
+	sseRetry = 3 * time.Second
+	// wsMaxMessage bounds what clients may send, they only send control frames
+	wsMaxMessage = 512
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// eventMessage is one WebSocket message
+type eventMessage struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// @Summary Stream goods events
+// @Tags Projects
+// @Description Server-Sent Events of goods changes in the project. Event types are goods.created,
+// @Description goods.updated, goods.removed and goods.reprioritized with a models.GoodsEvent as data.
+// @Description A stream resumed with an ID the instance no longer has starts with a reset event,
+// @Description the goods should be fetched again
+// @ID stream-goods-events
+// @Produce  text/event-stream
+// @Param project_id path int true "project_id"
+// @Param Last-Event-ID header string false "resume after this event"
+// @Param last_event_id query string false "resume after this event, for clients that can't set headers"
+// @Success 200 {object} models.GoodsEvent
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 429 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure 503 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/projects/{project_id}/events [get]
+func (h *Handler) streamEvents(c *gin.Context) {
+	sub, backlog, ok := h.openStream(c, "streamEvents")
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	connections := p.EventsConnections.WithLabelValues("sse")
+	connections.Inc()
+	defer connections.Dec()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// a client that doesn't read within a heartbeat is gone or too slow, the
+	// server write timeout would end healthy streams instead
+	rc := http.NewResponseController(c.Writer)
+	write := func(fn func(w io.Writer) error) error {
+		if err := rc.SetWriteDeadline(time.Now().Add(h.config.Heartbeat)); err != nil {
+			return err
+		}
+		if err := fn(c.Writer); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	err := write(func(w io.Writer) error {
+		if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds()); err != nil {
+			return err
+		}
+		for _, event := range backlog {
+			if err := writeSSE(w, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	p.EventsSentTotal.WithLabelValues("sse").Add(float64(len(backlog)))
+
+	heartbeat := time.NewTicker(h.config.Heartbeat)
+	defer heartbeat.Stop()
+
+	for err == nil {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, open := <-sub.Events():
+			if !open {
+				// dropped by the hub or shut down, EventSource reconnects
+				// with Last-Event-ID
+				return
+			}
+			err = write(func(w io.Writer) error { return writeSSE(w, event) })
+			p.EventsSentTotal.WithLabelValues("sse").Inc()
+		case <-heartbeat.C:
+			err = write(func(w io.Writer) error {
+				_, err := io.WriteString(w, ": heartbeat\n\n")
+				return err
+			})
+		}
+	}
+}
+
+// @Summary Stream goods events over WebSocket
+// @Tags Projects
+// @Description The events of /api/projects/{project_id}/events as JSON messages with id, type and data.
+// @Description Slow clients are closed with 1013 (try again later) and should resume with last_event_id,
+// @Description on shutdown streams are closed with 1001 (going away)
+// @ID stream-goods-events-ws
+// @Param project_id path int true "project_id"
+// @Param last_event_id query string false "resume after this event"
+// @Success 101
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 429 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure 503 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/projects/{project_id}/events/ws [get]
+func (h *Handler) streamEventsWS(c *gin.Context) {
+	sub, backlog, ok := h.openStream(c, "streamEventsWS")
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader has answered already
+		return
+	}
+	defer conn.Close()
+
+	connections := p.EventsConnections.WithLabelValues("websocket")
+	connections.Inc()
+	defer connections.Dec()
+
+	// the reader handles pongs and notices when the client goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(wsMaxMessage)
+		_ = conn.SetReadDeadline(time.Now().Add(2 * h.config.Heartbeat))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * h.config.Heartbeat))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(event stream.Event) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(h.config.Heartbeat))
+		p.EventsSentTotal.WithLabelValues("websocket").Inc()
+		return conn.WriteJSON(eventMessage{ID: event.ID, Type: event.Type, Data: event.Data})
+	}
+
+	for _, event := range backlog {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(h.config.Heartbeat)
+	defer heartbeat.Stop()
+
+	for err == nil {
+		select {
+		case <-closed:
+			return
+		case event, open := <-sub.Events():
+			if !open {
+				_ = conn.WriteControl(websocket.CloseMessage, closeMessage(sub.Err()), time.Now().Add(time.Second))
+				return
+			}
+			err = send(event)
+		case <-heartbeat.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.config.Heartbeat))
+		}
+	}
+}
+
+// openStream checks the project and subscribes to its events. The span only
+// covers the setup, streams live much longer than requests
+func (h *Handler) openStream(c *gin.Context, name string) (*stream.Subscription, []stream.Event, bool) {
+	ctx, span := h.tracer.Start(c.Request.Context(), name)
+	defer span.End()
+
+	projectID, err := GetProjectId(c)
+	if err != nil {
+		abort(c, span, err)
+		return nil, nil, false
+	}
+
+	if _, err := h.services.Projects.GetByID(ctx, projectID); err != nil {
+		abort(c, span, err)
+		return nil, nil, false
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	sub, backlog, err := h.config.Events.Subscribe(projectID, lastEventID)
+	switch {
+	case errors.Is(err, stream.ErrTooManyConnections):
+		c.Header("Retry-After", "5")
+		err = apperror.RateLimited("events.too_many_connections", err.Error())
+	case errors.Is(err, stream.ErrClosed):
+		err = apperror.Unavailable("events.shutting_down", "the server is shutting down", err)
+	}
+	if err != nil {
+		abort(c, span, err)
+		return nil, nil, false
+	}
+
+	span.AddEvent("subscribed", trace.WithAttributes(
+		attribute.Int("project_id", projectID),
+		attribute.Int("backlog", len(backlog)),
+	))
+	return sub, backlog, true
+}
+
+// closeMessage tells WebSocket clients why the hub dropped the stream
+func closeMessage(err error) []byte {
+	if errors.Is(err, stream.ErrClosed) {
+		return websocket.FormatCloseMessage(websocket.CloseGoingAway, err.Error())
+	}
+	return websocket.FormatCloseMessage(websocket.CloseTryAgainLater, stream.ErrSlowClient.Error())
+}
+
+func writeSSE(w io.Writer, event stream.Event) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+type featuresResponse struct {
+	// Enabled lists the features switched on, sorted by name
+	Enabled []string `json:"enabled"`
+}
+
+// @Summary Get features
+// @Tags Features
+// @Description List the feature flags switched on. They are reloaded with the configuration,
+// @Description so the list can change while the service runs
+// @ID get-features
+// @Produce  json
+// @Success 200 {object} featuresResponse
+// @Failure default {object} problemResponse
+// @Router /api/features [get]
+func (h *Handler) getFeatures(c *gin.Context) {
+	_, span := h.tracer.Start(c.Request.Context(), "getFeatures")
+	defer span.End()
+
+	response := featuresResponse{Enabled: []string{}}
+	if h.config.Features != nil {
+		for name, on := range h.config.Features.Load() {
+			if on {
+				response.Enabled = append(response.Enabled, name)
+			}
+		}
+	}
+	sort.Strings(response.Enabled)
+	c.JSON(http.StatusOK, response)
+}
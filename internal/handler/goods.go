@@ -1,20 +1,15 @@
 package handler
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 
 	"go-service/internal/models"
-	"go-service/internal/repository"
 	p "go-service/pkg/prometheus"
 )
 
@@ -24,64 +19,48 @@ import (
 // @ID create-goods
 // @Accept  json
 // @Produce  json
-// @Param input body models.UpdateGoods true "goods info"
+// @Param input body models.Goods true "goods info"
 // @Param project_id path int true "project_id"
 // @Success 200 {object} models.Goods
-// @Failure 400 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/goods/{project_id} [post]
 func (h *Handler) createGoods(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "createGoods")
+	defer span.End()
+
 	projectID, err := GetProjectId(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
 	var input models.Goods
-	if err := c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
-		return
-	}
-
-	if input.Name == "" {
-		newErrorResponse(c, http.StatusBadRequest, "name is required")
+	if err := bindJSON(c, &input); err != nil {
+		abort(c, span, err)
 		return
 	}
 	if input.Description == "" {
 		input.Description = input.Name
 	}
 
-	ctx, span := h.tracer.Start(c.Request.Context(), "createGoods")
-	defer span.End()
 	span.AddEvent("create goods", trace.WithAttributes(attribute.String("name", input.Name)))
 
 	id, err := h.services.Goods.Create(ctx, projectID, input)
 	if err != nil {
-		span.RecordError(err, trace.WithAttributes(
-			attribute.String("error", err.Error())))
-		span.SetStatus(codes.Error, err.Error())
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
-	//createdGoods, err := h.services.Goods.GetOne(id, projectID)
-	//if err != nil {
-	//	newErrorResponse(c, http.StatusInternalServerError, err.Error())
-	//	return
-	//}
-
-	response := &models.Goods{
-		ID:          id,
-		ProjectID:   projectID,
-		Name:        input.Name,
-		Description: input.Description,
-		Priority:    input.Priority,
-		Removed:     input.Removed,
-		CreatedAt:   time.Now(),
+	createdGoods, err := h.services.Goods.GetOne(ctx, id, projectID)
+	if err != nil {
+		abort(c, span, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, createdGoods)
 }
 
 // @Summary Get list of goods
@@ -92,26 +71,26 @@ func (h *Handler) createGoods(c *gin.Context) {
 // @Produce  json
 // @Param limit query int false "limit"
 // @Param offset query int false "offset"
-// @Success 200 {array} models.Goods
-// @Failure 400 {object} errorResponse
-// @Failure 404 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Success 200 {object} models.GetAllGoods
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/goods/list [get]
 func (h *Handler) getAllGoods(c *gin.Context) {
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
-
 	ctx, span := h.tracer.Start(c.Request.Context(), "getAllGoods")
 	defer span.End()
 
-	goods, err := h.services.Goods.GetAll(ctx, limit, offset)
+	var query models.Page
+	if err := bindQuery(c, &query); err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	goods, err := h.services.Goods.GetAll(ctx, query.Limit, query.Offset)
 	span.AddEvent("get all goods", trace.WithAttributes(attribute.String("count", fmt.Sprint(len(goods.Goods)))))
 	if err != nil {
-		span.RecordError(err, trace.WithAttributes(
-			attribute.String("error", err.Error())))
-		span.SetStatus(codes.Error, err.Error())
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
@@ -127,104 +106,97 @@ func (h *Handler) getAllGoods(c *gin.Context) {
 // @Param project_id path int true "project_id"
 // @Param id path int true "id"
 // @Success 200 {object} models.Goods
-// @Failure 400 {object} errorResponse
-// @Failure 404 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/goods/{project_id}/{id} [get]
 func (h *Handler) getOne(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "getOne")
+	defer span.End()
+
 	goodsID, err := GetGoodsId(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
 	projectID, err := GetProjectId(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
-	ctx, span := h.tracer.Start(c.Request.Context(), "getOne")
-	defer span.End()
 	span.AddEvent("getOne", trace.WithAttributes(attribute.String("id", fmt.Sprint(goodsID))))
 
 	goods, err := h.services.Goods.GetOne(ctx, goodsID, projectID)
 	if err != nil {
-		span.RecordError(err, trace.WithAttributes(
-			attribute.String("error", err.Error())))
-		span.SetStatus(codes.Error, err.Error())
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
-	} else {
-		p.GoodsCounter.With(prometheus.Labels{"project_id": fmt.Sprint(projectID)}).Inc()
 	}
+	p.GoodsCounter.With(prometheus.Labels{"project_id": fmt.Sprint(projectID)}).Inc()
 
 	c.JSON(http.StatusOK, goods)
 }
 
 // @Summary Update item
 // @Tags Goods
-// @Description Update one item of goods
+// @Description Update one item of goods with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902)
 // @ID update-item
 // @Accept  json
+// @Accept  application/merge-patch+json
+// @Accept  application/json-patch+json
 // @Produce  json
 // @Param input body models.UpdateGoods true "goods info"
 // @Param project_id path int true "project_id"
 // @Param id path int true "id"
 // @Success 200 {object} models.Goods
-// @Failure 400 {object} errorResponse
-// @Failure 404 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 409 {object} problemResponse
+// @Failure 415 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/goods/{project_id}/{id} [patch]
 func (h *Handler) updateGoods(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "updateGoods")
+	defer span.End()
+
 	goodsID, err := GetGoodsId(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
 	projectID, err := GetProjectId(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
-	var input models.UpdateGoods
-	if err := c.BindJSON(&input); err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
-		return
-	}
-
-	ctx, span := h.tracer.Start(c.Request.Context(), "updateGoods")
-	defer span.End()
 	span.AddEvent("updateGoods", trace.WithAttributes(attribute.String("goodsID", fmt.Sprintf("%d", goodsID))))
 
-	if err := h.services.Goods.Update(ctx, goodsID, projectID, input); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			span.RecordError(err, trace.WithAttributes(
-				attribute.String("error", err.Error())))
-			span.SetStatus(codes.Error, err.Error())
-			newDetailedErrorResponse(c, http.StatusNotFound, 3, "errors.good.NotFound", "record not found")
-			return
-		}
-		span.RecordError(err, trace.WithAttributes(
-			attribute.String("error", err.Error())))
-		span.SetStatus(codes.Error, err.Error())
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	err = patchUpdate(c,
+		func(input models.UpdateGoods) error {
+			return h.services.Goods.Update(ctx, goodsID, projectID, input)
+		},
+		func(patch func(models.Goods) (models.UpdateGoods, error)) error {
+			return h.services.Goods.Patch(ctx, goodsID, projectID, patch)
+		})
+	if err != nil {
+		abort(c, span, err)
 		return
 	}
 
 	updatedGoods, err := h.services.Goods.GetOne(ctx, goodsID, projectID)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
 	span.AddEvent("updated goods",
 		trace.WithAttributes(
 			attribute.String("id", fmt.Sprint(updatedGoods.ID)),
 			attribute.String("name", updatedGoods.Name),
 		))
-	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
-		return
-	}
 
 	c.JSON(http.StatusOK, updatedGoods)
 }
@@ -237,47 +209,38 @@ func (h *Handler) updateGoods(c *gin.Context) {
 // @Produce  json
 // @Param project_id path int true "project_id"
 // @Param id path int true "id"
-// @Success 200
-// @Failure 400 {object} errorResponse
-// @Failure 404 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Success 200 {object} models.Goods
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/goods/{project_id}/{id} [delete]
 func (h *Handler) deleteGoods(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "deleteGoods")
+	defer span.End()
+
 	goodsID, err := GetGoodsId(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
 	projectID, err := GetProjectId(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
-	ctx, span := h.tracer.Start(c.Request.Context(), "deleteGoods")
 
-	defer span.End()
 	span.AddEvent("deleteGoods", trace.WithAttributes(attribute.String("goodsID", fmt.Sprintf("%d", goodsID))))
 
 	if err := h.services.Goods.Delete(ctx, goodsID, projectID); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			span.RecordError(err, trace.WithAttributes(
-				attribute.String("error", err.Error())))
-			span.SetStatus(codes.Error, err.Error())
-			newDetailedErrorResponse(c, http.StatusNotFound, 3, "errors.good.NotFound", "record not found")
-			return
-		}
-		span.RecordError(err, trace.WithAttributes(
-			attribute.String("error", err.Error())))
-		span.SetStatus(codes.Error, err.Error())
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
 	updatedGoods, err := h.services.Goods.GetOne(ctx, goodsID, projectID)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
@@ -294,53 +257,44 @@ func (h *Handler) deleteGoods(c *gin.Context) {
 // @Param project_id path int true "project_id"
 // @Param id path int true "id"
 // @Success 200 {object} models.Goods
-// @Failure 400 {object} errorResponse
-// @Failure 404 {object} errorResponse
-// @Failure 500 {object} errorResponse
-// @Failure default {object} errorResponse
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
 // @Router /api/goods/prioritize/{project_id}/{id} [patch]
 func (h *Handler) reprioritize(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "reprioritize")
+	defer span.End()
+
 	goodsID, err := GetGoodsId(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
 	projectID, err := GetProjectId(c)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
-	priority, err := strconv.Atoi(c.Query("priority"))
-	if err != nil {
-		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	var query models.PriorityQuery
+	if err := bindQuery(c, &query); err != nil {
+		abort(c, span, err)
 		return
 	}
 
-	ctx, span := h.tracer.Start(c.Request.Context(), "reprioritize")
-	defer span.End()
 	span.AddEvent("reprioritize")
 
-	err = h.services.Goods.Reprioritize(ctx, goodsID, projectID, priority)
+	err = h.services.Goods.Reprioritize(ctx, goodsID, projectID, query.Priority)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			span.RecordError(err, trace.WithAttributes(
-				attribute.String("error", err.Error())))
-			span.SetStatus(codes.Error, err.Error())
-			newDetailedErrorResponse(c, http.StatusNotFound, 3, "errors.good.NotFound", "record not found")
-			return
-		}
-		span.RecordError(err, trace.WithAttributes(
-			attribute.String("error", err.Error())))
-		span.SetStatus(codes.Error, err.Error())
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
 	updatedGoods, err := h.services.Goods.GetOne(ctx, goodsID, projectID)
 	if err != nil {
-		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		abort(c, span, err)
 		return
 	}
 
@@ -1,47 +1,95 @@
 package handler
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/spf13/viper"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 
 	"github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
 
 	_ "go-service/docs"
 
+	"go-service/internal/config"
 	"go-service/internal/service"
+	"go-service/internal/stream"
 )
 
+type Config struct {
+	// Debug exposes pprof under /debug/pprof
+	Debug bool
+	// RPS limits requests per second to the API, 0 turns the limit off
+	RPS   float64
+	Burst int
+	// Gatherer backs /metrics, the global Prometheus registry by default
+	Gatherer prometheus.Gatherer
+	// Events serves the goods event streams, nil leaves them out
+	Events *stream.Hub
+	// Heartbeat is how often idle streams are kept alive
+	Heartbeat time.Duration
+	// Features are read on every request, so reloads apply right away.
+	// Nil switches every feature off
+	Features *config.FeatureFlags
+}
+
 type Handler struct {
 	services *service.Service
 	tracer   trace.Tracer
+	config   Config
+	limiter  *rate.Limiter
 }
 
-func New(services *service.Service, tracer trace.Tracer) *Handler {
-	return &Handler{
+func New(services *service.Service, tracer trace.Tracer, config Config) *Handler {
+	if config.Heartbeat <= 0 {
+		config.Heartbeat = 15 * time.Second
+	}
+	h := &Handler{
 		services: services,
 		tracer:   tracer,
+		config:   config,
+		limiter:  rate.NewLimiter(rate.Inf, 0),
+	}
+	h.SetRateLimit(config.RPS, config.Burst)
+	return h
+}
+
+func (h *Handler) metricsHandler() http.Handler {
+	if h.config.Gatherer == nil {
+		return promhttp.Handler()
+	}
+	return promhttp.HandlerFor(h.config.Gatherer, promhttp.HandlerOpts{})
+}
+
+// SetRateLimit changes the API rate limit of a running handler
+func (h *Handler) SetRateLimit(rps float64, burst int) {
+	limit := rate.Limit(rps)
+	if rps <= 0 {
+		limit = rate.Inf
 	}
+	h.limiter.SetLimit(limit)
+	h.limiter.SetBurst(burst)
 }
 
 func (h *Handler) InitRoutes() *gin.Engine {
 	router := gin.New()
 
-	router.Use(otelgin.Middleware("go-service"))
+	router.Use(otelgin.Middleware("go-service"), errorHandler())
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.GET("/metrics", gin.WrapH(h.metricsHandler()))
 
-	debugMode := viper.GetBool("debug")
-	if debugMode {
+	if h.config.Debug {
 		pprof.Register(router, "/debug/pprof")
 	}
 
-	api := router.Group("/api")
+	api := router.Group("/api", rateLimit(h.limiter))
 	{
 		projects := api.Group("/projects")
 		{
@@ -50,6 +98,19 @@ func (h *Handler) InitRoutes() *gin.Engine {
 			projects.GET("/:project_id", h.getProject)
 			projects.PATCH("/:project_id", h.updateProject)
 			projects.DELETE("/:project_id", h.deleteProject)
+
+			projects.POST("/:project_id/webhooks", h.createWebhook)
+			projects.GET("/:project_id/webhooks", h.getWebhooks)
+			projects.GET("/:project_id/webhooks/:webhook_id", h.getWebhook)
+			projects.PATCH("/:project_id/webhooks/:webhook_id", h.updateWebhook)
+			projects.DELETE("/:project_id/webhooks/:webhook_id", h.deleteWebhook)
+			projects.GET("/:project_id/webhooks/:webhook_id/deliveries", h.getWebhookDeliveries)
+			projects.POST("/:project_id/webhooks/:webhook_id/deliveries/:delivery_id/redeliver", h.redeliverWebhook)
+
+			if h.config.Events != nil {
+				projects.GET("/:project_id/events", h.streamEvents)
+				projects.GET("/:project_id/events/ws", h.streamEventsWS)
+			}
 		}
 
 		goods := api.Group("/goods")
@@ -61,6 +122,14 @@ func (h *Handler) InitRoutes() *gin.Engine {
 			goods.DELETE("/:project_id/:id", h.deleteGoods)
 			goods.GET("/:project_id/:id", h.getOne)
 		}
+
+		api.GET("/features", h.getFeatures)
+
+		events := api.Group("/events")
+		{
+			events.GET("/schemas", h.getEventSchemas)
+			events.GET("/schemas/:name/:version", h.getEventSchema)
+		}
 	}
 
 	return router
@@ -1,41 +1,106 @@
 package handler
 
 import (
-	"errors"
-	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"go-service/internal/apperror"
+	"go-service/internal/models"
+	"go-service/pkg/logger"
 )
 
 func GetGoodsId(c *gin.Context) (int, error) {
-	goodsIDStr := c.Param("id")
-	if goodsIDStr == "" {
-		newErrorResponse(c, http.StatusBadRequest, "Goods ID is required")
-		return 0, errors.New("goods id not found")
+	return pathID(c, "id")
+}
+
+func GetProjectId(c *gin.Context) (int, error) {
+	return pathID(c, "project_id")
+}
+
+func pathID(c *gin.Context, name string) (int, error) {
+	value := c.Param(name)
+	if value == "" {
+		return 0, apperror.Validation("request.invalid_path", "path parameter is required", apperror.FieldError{
+			Field:   name,
+			Message: "is required",
+		})
 	}
 
-	goodsID, err := strconv.Atoi(goodsIDStr)
+	id, err := strconv.Atoi(value)
 	if err != nil {
-		newErrorResponse(c, http.StatusBadRequest, "Invalid Goods ID format")
-		return 0, errors.New("goods id not found")
+		return 0, apperror.Validation("request.invalid_path", "path parameter must be an integer", apperror.FieldError{
+			Field:   name,
+			Message: "must be an integer",
+		})
 	}
 
-	return goodsID, nil
+	return id, nil
 }
 
-func GetProjectId(c *gin.Context) (int, error) {
-	projectIDStr := c.Param("project_id")
-	if projectIDStr == "" {
-		newErrorResponse(c, http.StatusBadRequest, "Project ID is required")
-		return 0, errors.New("project id not found")
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		models.RegisterValidations(v)
 	}
+}
 
-	projectID, err := strconv.Atoi(projectIDStr)
-	if err != nil {
-		newErrorResponse(c, http.StatusBadRequest, "Invalid Project ID format")
-		return 0, errors.New("project id not found")
+// bindJSON decodes and validates the request body into obj
+func bindJSON(c *gin.Context, obj any) error {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		return models.ValidationError("request.invalid_body", "request body is invalid", err)
+	}
+	return nil
+}
+
+// bindQuery decodes and validates the query string into obj
+func bindQuery(c *gin.Context, obj any) error {
+	if err := c.ShouldBindQuery(obj); err != nil {
+		return models.ValidationError("request.invalid_query", "query parameters are invalid", err)
 	}
+	return nil
+}
+
+// abort records err on the handler span and leaves the response to errorHandler
+func abort(c *gin.Context, span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	_ = c.Error(err)
+	c.Abort()
+}
 
-	return projectID, nil
+// rateLimit rejects requests over the limit with 429 instead of queueing them
+func rateLimit(limiter *rate.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limiter.Allow() {
+			c.Header("Retry-After", "1")
+			_ = c.Error(apperror.RateLimited("request.rate_limited", "too many requests"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// errorHandler writes the last error of the request as application/problem+json
+func errorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := apperror.From(c.Errors.Last().Err)
+		if err.Kind == apperror.KindInternal || err.Kind == apperror.KindUnavailable {
+			logger.GetLogger().Error(err.Message, zap.String("code", err.Code), zap.Error(err))
+		}
+
+		newProblemResponse(c, err)
+	}
 }
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"go-service/internal/apperror"
+	"go-service/internal/models"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+	acceptPatch           = mergePatchContentType + ", " + jsonPatchContentType
+)
+
+// patchUpdate runs a PATCH request through update or patch.
+//
+// application/json and application/merge-patch+json bodies are RFC 7396
+// documents, they are decoded into U right away and passed to update.
+// application/json-patch+json bodies (RFC 6902) go to patch instead, which
+// applies them to the entity it reads and locks for the update, so test
+// operations check the state the update replaces. The difference becomes the
+// merge patch. Members U doesn't declare are rejected, so read-only fields
+// like id can't be patched
+func patchUpdate[E, U any](c *gin.Context, update func(U) error, patch func(func(E) (U, error)) error) error {
+	c.Header("Accept-Patch", acceptPatch)
+
+	body, err := c.GetRawData()
+	if err != nil {
+		return apperror.Validation("request.invalid_body", err.Error())
+	}
+
+	switch c.ContentType() {
+	case "", binding.MIMEJSON, mergePatchContentType:
+		var input U
+		if err := decodePatch(body, &input); err != nil {
+			return err
+		}
+		return update(input)
+	case jsonPatchContentType:
+		operations, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			return apperror.Validation("request.invalid_patch", err.Error())
+		}
+		return patch(func(current E) (U, error) {
+			var input U
+			doc, err := applyJSONPatch(operations, current)
+			if err != nil {
+				return input, err
+			}
+			return input, decodePatch(doc, &input)
+		})
+	default:
+		return apperror.UnsupportedMediaType("request.unsupported_media_type", "PATCH accepts "+acceptPatch)
+	}
+}
+
+// decodePatch decodes a merge patch into obj, a merge patch model
+func decodePatch(doc []byte, obj any) error {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(obj); err != nil {
+		return apperror.Validation("request.invalid_body", err.Error())
+	}
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return models.ValidationError("request.invalid_body", "request body is invalid", err)
+	}
+	return nil
+}
+
+func applyJSONPatch(patch jsonpatch.Patch, entity any) ([]byte, error) {
+	original, err := json.Marshal(entity)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	modified, err := patch.Apply(original)
+	switch {
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return nil, apperror.Conflict("request.patch_test_failed", err.Error())
+	case err != nil:
+		return nil, apperror.Validation("request.invalid_patch", err.Error())
+	}
+
+	doc, err := jsonpatch.CreateMergePatch(original, modified)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return doc, nil
+}
@@ -1,37 +1,69 @@
 package handler
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 
-	"go-service/pkg/logger"
+	"go-service/internal/apperror"
 )
 
-type errorResponse struct {
-	Message string `json:"message"`
-}
+const problemContentType = "application/problem+json"
 
 type statusResponse struct {
 	Status string `json:"status"`
 }
 
-type detailedErrorResponse struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Status  int    `json:"status"`
-	Detail  string `json:"detail"`
+// problemResponse is an RFC 7807 problem details document
+type problemResponse struct {
+	Type     string                `json:"type"`
+	Title    string                `json:"title"`
+	Status   int                   `json:"status"`
+	Detail   string                `json:"detail,omitempty"`
+	Instance string                `json:"instance,omitempty"`
+	Code     string                `json:"code"`
+	Errors   []apperror.FieldError `json:"errors,omitempty"`
+	TraceID  string                `json:"trace_id,omitempty"`
 }
 
-func newErrorResponse(c *gin.Context, statusCode int, message string) {
-	logger.GetLogger().Error(message)
-	c.AbortWithStatusJSON(statusCode, errorResponse{message})
+func statusFor(kind apperror.Kind) int {
+	switch kind {
+	case apperror.KindNotFound:
+		return http.StatusNotFound
+	case apperror.KindConflict:
+		return http.StatusConflict
+	case apperror.KindValidation:
+		return http.StatusBadRequest
+	case apperror.KindPrecondition:
+		return http.StatusPreconditionFailed
+	case apperror.KindUnavailable:
+		return http.StatusServiceUnavailable
+	case apperror.KindRateLimited:
+		return http.StatusTooManyRequests
+	case apperror.KindUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
-func newDetailedErrorResponse(c *gin.Context, statusCode, errorCode int, message, detail string) {
-	logger.GetLogger().Error(message)
-	c.AbortWithStatusJSON(statusCode, detailedErrorResponse{
-		Code:    errorCode,
-		Message: message,
-		Status:  statusCode,
-		Detail:  detail,
-	})
+func newProblemResponse(c *gin.Context, err *apperror.Error) {
+	status := statusFor(err.Kind)
+
+	problem := problemResponse{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   err.Message,
+		Instance: c.Request.URL.Path,
+		Code:     err.Code,
+		Errors:   err.Fields,
+	}
+	if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+		problem.TraceID = sc.TraceID().String()
+	}
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(status, problem)
 }
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go-service/api/events"
+	"go-service/internal/apperror"
+)
+
+const schemaContentType = "application/schema+json"
+
+// eventSchema is one version of the data of some event types
+type eventSchema struct {
+	Name    string   `json:"name"`
+	Version int      `json:"version"`
+	Types   []string `json:"types"`
+	// URL is the path of the JSON Schema document
+	URL string `json:"url"`
+}
+
+type eventSchemasResponse struct {
+	Schemas []eventSchema `json:"schemas"`
+}
+
+// @Summary Get event schemas
+// @Tags Events
+// @Description List the JSON Schemas of the data of the published CloudEvents with the event types
+// @Description they describe. The dataschema attribute of an event points to its schema
+// @ID get-event-schemas
+// @Produce  json
+// @Success 200 {object} eventSchemasResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/events/schemas [get]
+func (h *Handler) getEventSchemas(c *gin.Context) {
+	_, span := h.tracer.Start(c.Request.Context(), "getEventSchemas")
+	defer span.End()
+
+	response := eventSchemasResponse{Schemas: make([]eventSchema, 0, len(events.Catalog))}
+	for _, s := range events.Catalog {
+		response.Schemas = append(response.Schemas, eventSchema{
+			Name:    s.Name,
+			Version: s.Version,
+			Types:   s.Types,
+			URL:     "/api/events/schemas/" + s.Path(),
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Get event schema
+// @Tags Events
+// @Description Get one version of an event schema as a JSON Schema document. Published versions
+// @Description only change in ways their consumers accept
+// @ID get-event-schema
+// @Produce  application/schema+json
+// @Param name path string true "schema name"
+// @Param version path string true "version, like v1"
+// @Success 200 {object} object
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/events/schemas/{name}/{version} [get]
+func (h *Handler) getEventSchema(c *gin.Context) {
+	_, span := h.tracer.Start(c.Request.Context(), "getEventSchema")
+	defer span.End()
+
+	notFound := apperror.NotFound("event_schema.not_found", "event schema not found")
+	version, err := strconv.Atoi(strings.TrimPrefix(c.Param("version"), "v"))
+	if err != nil || !strings.HasPrefix(c.Param("version"), "v") {
+		abort(c, span, notFound)
+		return
+	}
+	schema, ok := events.Lookup(c.Param("name"), version)
+	if !ok {
+		abort(c, span, notFound)
+		return
+	}
+
+	data, err := schema.File()
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+	c.Data(http.StatusOK, schemaContentType, data)
+}
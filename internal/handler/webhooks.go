@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-service/internal/models"
+)
+
+// @Summary Create webhook
+// @Tags Webhooks
+// @Description Subscribe a URL to the goods events of the project. Deliveries are signed with the secret, which is only returned here.
+// @Description The URL must resolve to public addresses, private, loopback and link-local ones are rejected
+// @ID create-webhook
+// @Accept  json
+// @Produce  json
+// @Param input body models.CreateWebhook true "webhook info"
+// @Param project_id path int true "project_id"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/projects/{project_id}/webhooks [post]
+func (h *Handler) createWebhook(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "createWebhook")
+	defer span.End()
+
+	projectID, err := GetProjectId(c)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	var input models.CreateWebhook
+	if err := bindJSON(c, &input); err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	span.AddEvent("create webhook", trace.WithAttributes(attribute.String("url", input.URL)))
+
+	webhook, err := h.services.Webhooks.Create(ctx, projectID, input)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// @Summary Get webhooks
+// @Tags Webhooks
+// @Description Get the webhooks of the project
+// @ID get-webhooks
+// @Accept  json
+// @Produce  json
+// @Param project_id path int true "project_id"
+// @Success 200 {object} models.GetAllWebhooks
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/projects/{project_id}/webhooks [get]
+func (h *Handler) getWebhooks(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "getWebhooks")
+	defer span.End()
+
+	projectID, err := GetProjectId(c)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	if _, err := h.services.Projects.GetByID(ctx, projectID); err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	webhooks, err := h.services.Webhooks.GetAll(ctx, projectID)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// @Summary Get webhook
+// @Tags Webhooks
+// @Description Get webhook
+// @ID get-webhook
+// @Accept  json
+// @Produce  json
+// @Param project_id path int true "project_id"
+// @Param webhook_id path int true "webhook_id"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/projects/{project_id}/webhooks/{webhook_id} [get]
+func (h *Handler) getWebhook(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "getWebhook")
+	defer span.End()
+
+	projectID, webhookID, err := webhookPath(c)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	webhook, err := h.services.Webhooks.GetOne(ctx, webhookID, projectID)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// @Summary Update webhook
+// @Tags Webhooks
+// @Description Update webhook with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902), a null event_types clears the filter
+// @ID update-webhook
+// @Accept  json
+// @Accept  application/merge-patch+json
+// @Accept  application/json-patch+json
+// @Produce  json
+// @Param input body models.UpdateWebhook true "webhook info"
+// @Param project_id path int true "project_id"
+// @Param webhook_id path int true "webhook_id"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 409 {object} problemResponse
+// @Failure 415 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/projects/{project_id}/webhooks/{webhook_id} [patch]
+func (h *Handler) updateWebhook(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "updateWebhook")
+	defer span.End()
+
+	projectID, webhookID, err := webhookPath(c)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	span.AddEvent("update webhook", trace.WithAttributes(attribute.String("id", fmt.Sprint(webhookID))))
+
+	err = patchUpdate(c,
+		func(input models.UpdateWebhook) error {
+			return h.services.Webhooks.Update(ctx, webhookID, projectID, input)
+		},
+		func(patch func(models.Webhook) (models.UpdateWebhook, error)) error {
+			return h.services.Webhooks.Patch(ctx, webhookID, projectID, patch)
+		})
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	webhook, err := h.services.Webhooks.GetOne(ctx, webhookID, projectID)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// @Summary Delete webhook
+// @Tags Webhooks
+// @Description Delete webhook together with its delivery log
+// @ID delete-webhook
+// @Accept  json
+// @Produce  json
+// @Param project_id path int true "project_id"
+// @Param webhook_id path int true "webhook_id"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/projects/{project_id}/webhooks/{webhook_id} [delete]
+func (h *Handler) deleteWebhook(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "deleteWebhook")
+	defer span.End()
+
+	projectID, webhookID, err := webhookPath(c)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	span.AddEvent("delete webhook", trace.WithAttributes(attribute.String("id", fmt.Sprint(webhookID))))
+
+	if err := h.services.Webhooks.Delete(ctx, webhookID, projectID); err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+// @Summary Get webhook deliveries
+// @Tags Webhooks
+// @Description Get the delivery log of a webhook, newest first
+// @ID get-webhook-deliveries
+// @Accept  json
+// @Produce  json
+// @Param project_id path int true "project_id"
+// @Param webhook_id path int true "webhook_id"
+// @Param limit query int false "limit"
+// @Param offset query int false "offset"
+// @Success 200 {object} models.GetAllDeliveries
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/projects/{project_id}/webhooks/{webhook_id}/deliveries [get]
+func (h *Handler) getWebhookDeliveries(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "getWebhookDeliveries")
+	defer span.End()
+
+	projectID, webhookID, err := webhookPath(c)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	var query models.Page
+	if err := bindQuery(c, &query); err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	deliveries, err := h.services.Webhooks.GetDeliveries(ctx, webhookID, projectID, query.Limit, query.Offset)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// @Summary Redeliver
+// @Tags Webhooks
+// @Description Send a delivery again with a fresh attempt budget, dead ones included
+// @ID redeliver-webhook
+// @Accept  json
+// @Produce  json
+// @Param project_id path int true "project_id"
+// @Param webhook_id path int true "webhook_id"
+// @Param delivery_id path int true "delivery_id"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} problemResponse
+// @Failure 404 {object} problemResponse
+// @Failure 500 {object} problemResponse
+// @Failure default {object} problemResponse
+// @Router /api/projects/{project_id}/webhooks/{webhook_id}/deliveries/{delivery_id}/redeliver [post]
+func (h *Handler) redeliverWebhook(c *gin.Context) {
+	ctx, span := h.tracer.Start(c.Request.Context(), "redeliverWebhook")
+	defer span.End()
+
+	projectID, webhookID, err := webhookPath(c)
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+	deliveryID, err := pathID(c, "delivery_id")
+	if err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	span.AddEvent("redeliver", trace.WithAttributes(attribute.String("id", fmt.Sprint(deliveryID))))
+
+	if err := h.services.Webhooks.Redeliver(ctx, deliveryID, webhookID, projectID); err != nil {
+		abort(c, span, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{"ok"})
+}
+
+func webhookPath(c *gin.Context) (projectID, webhookID int, err error) {
+	if projectID, err = GetProjectId(c); err != nil {
+		return 0, 0, err
+	}
+	webhookID, err = pathID(c, "webhook_id")
+	return projectID, webhookID, err
+}
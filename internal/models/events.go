@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	GoodsCreated       = "goods.created"
+	GoodsUpdated       = "goods.updated"
+	GoodsRemoved       = "goods.removed"
+	GoodsReprioritized = "goods.reprioritized"
+)
+
+// GoodsEvent is published after every change of goods, as the data of a
+// CloudEvent of the same type
+type GoodsEvent struct {
+	// ID is unique per change, consumers and JetStream deduplicate on it
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	ProjectID int    `json:"project_id"`
+	GoodsID   int    `json:"goods_id"`
+	// Goods is the state after the change
+	Goods Goods     `json:"goods"`
+	At    time.Time `json:"at"`
+}
+
+func (e GoodsEvent) MessageID() string {
+	return e.ID
+}
+
+func (e GoodsEvent) EventID() string {
+	return e.ID
+}
+
+func (e GoodsEvent) EventType() string {
+	return e.Type
+}
+
+func (e GoodsEvent) EventTime() time.Time {
+	return e.At
+}
@@ -8,16 +8,19 @@ import (
 type Goods struct {
 	ID          int       `json:"id" db:"id"`
 	ProjectID   int       `json:"project_id" db:"project_id"`
-	Name        string    `json:"name" db:"name"`
+	Name        string    `json:"name" db:"name" binding:"required,max=255"`
 	Description string    `json:"description" db:"description"`
-	Priority    int       `json:"priority" db:"priority"`
+	Priority    int       `json:"priority" db:"priority" binding:"omitempty,min=1"`
 	Removed     bool      `json:"removed" db:"removed"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
+// UpdateGoods is a merge patch of goods, a null description clears it
 type UpdateGoods struct {
-	Name        *string `json:"name" db:"name"`
-	Description *string `json:"description" db:"description"`
+	Name        Field[string] `json:"name" db:"name" binding:"omitnil,min=1,max=255" swaggertype:"string"`
+	Description Field[string] `json:"description" db:"description" swaggertype:"string"`
+	Priority    Field[int]    `json:"priority" db:"priority" binding:"omitnil,min=1,max=2147483647" swaggertype:"integer"`
+	Removed     Field[bool]   `json:"removed" db:"removed" swaggertype:"boolean"`
 }
 
 type GetAllGoods struct {
@@ -33,8 +36,12 @@ type Meta struct {
 }
 
 func (i UpdateGoods) Validate() error {
-	if i.Description == nil {
-		return errors.New("empty description")
+	if !i.Name.Set && !i.Description.Set && !i.Priority.Set && !i.Removed.Set {
+		return ErrEmptyUpdate
 	}
-	return nil
+	return errors.Join(
+		notNull("name", i.Name.Null),
+		notNull("priority", i.Priority.Null),
+		notNull("removed", i.Removed.Null),
+	)
 }
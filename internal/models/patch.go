@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// Field is one member of a JSON Merge Patch document (RFC 7396), it tells an
+// absent member (leave unchanged) from an explicit null (clear)
+type Field[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// Some returns a field set to v
+func Some[T any](v T) Field[T] {
+	return Field[T]{Set: true, Value: v}
+}
+
+// Null returns a field that clears the member
+func Null[T any]() Field[T] {
+	return Field[T]{Set: true, Null: true}
+}
+
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if string(data) == "null" {
+		f.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &f.Value)
+}
+
+func (f Field[T]) MarshalJSON() ([]byte, error) {
+	if !f.Set || f.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(f.Value)
+}
+
+// value is what the validator sees, a nil pointer for absent and null members
+func (f Field[T]) value() any {
+	if !f.Set || f.Null {
+		return (*T)(nil)
+	}
+	return &f.Value
+}
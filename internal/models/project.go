@@ -1,18 +1,18 @@
 package models
 
 import (
-	"errors"
 	"time"
 )
 
 type Project struct {
 	ID        int       `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
+	Name      string    `json:"name" db:"name" binding:"required,max=255"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
+// UpdateProject is a merge patch of a project
 type UpdateProject struct {
-	Name *string `json:"name" db:"name"`
+	Name Field[string] `json:"name" db:"name" binding:"omitnil,min=1,max=255" swaggertype:"string"`
 }
 
 type GetAllProjects struct {
@@ -20,15 +20,11 @@ type GetAllProjects struct {
 	Projects []Project    `json:"projects"`
 }
 
-type UpdateProjects struct {
-	Name *string `json:"name" db:"name"`
-}
-
 func (i UpdateProject) Validate() error {
-	if i.Name == nil {
-		return errors.New("empty name")
+	if !i.Name.Set {
+		return ErrEmptyUpdate
 	}
-	return nil
+	return notNull("name", i.Name.Null)
 }
 
 type MetaProjects struct {
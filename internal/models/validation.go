@@ -0,0 +1,147 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"go-service/internal/apperror"
+)
+
+const (
+	// TagRequireOne is reported on update models that carry no fields
+	TagRequireOne = "require_one"
+	// TagNotNull is reported on merge patch members that can't be cleared
+	TagNotNull = "notnull"
+)
+
+// Violation is returned by Validate methods to point at the offending field
+type Violation struct {
+	Field string
+	Tag   string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("%s: failed on %q", v.Field, v.Tag)
+}
+
+// ErrEmptyUpdate is returned by update models that carry no fields
+var ErrEmptyUpdate = &Violation{Field: "body", Tag: TagRequireOne}
+
+// Validatable is implemented by models with rules that span several fields
+type Validatable interface {
+	Validate() error
+}
+
+// Page is the common limit/offset query of list endpoints
+type Page struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int `form:"offset" binding:"omitempty,min=0"`
+}
+
+type PriorityQuery struct {
+	Priority int `form:"priority" binding:"required,min=1,max=2147483647"`
+}
+
+// RegisterValidations reports fields by their json/form name and hooks the
+// Validate methods of update models into struct level validation
+func RegisterValidations(v *validator.Validate) {
+	v.RegisterTagNameFunc(fieldName)
+	v.RegisterCustomTypeFunc(fieldValue, Field[string]{}, Field[int]{}, Field[bool]{}, Field[[]string]{})
+	v.RegisterStructValidation(validateModel, UpdateGoods{}, UpdateProject{}, UpdateWebhook{})
+}
+
+func validateModel(sl validator.StructLevel) {
+	m, ok := sl.Current().Interface().(Validatable)
+	if !ok {
+		return
+	}
+	err := m.Validate()
+	if err == nil {
+		return
+	}
+
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, err := range errs {
+		var v *Violation
+		if !errors.As(err, &v) {
+			v = ErrEmptyUpdate
+		}
+		sl.ReportError(nil, v.Field, v.Field, v.Tag, "")
+	}
+}
+
+func notNull(field string, null bool) error {
+	if null {
+		return &Violation{Field: field, Tag: TagNotNull}
+	}
+	return nil
+}
+
+func fieldValue(v reflect.Value) any {
+	if f, ok := v.Interface().(interface{ value() any }); ok {
+		return f.value()
+	}
+	return nil
+}
+
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// ValidationError turns validator errors into a single validation error
+// listing every violated field, decoding errors are reported as is
+func ValidationError(code, message string, err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return apperror.Validation(code, err.Error())
+	}
+
+	fields := make([]apperror.FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fields = append(fields, apperror.FieldError{
+			Field:   fe.Field(),
+			Message: fieldMessage(fe),
+		})
+	}
+	return apperror.Validation(code, message, fields...)
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case TagRequireOne:
+		return "at least one field must be set"
+	case TagNotNull:
+		return "can't be null"
+	case "min":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if isString {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	default:
+		return fmt.Sprintf("failed on %q", fe.Tag())
+	}
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	// DeliveryDead ran out of attempts, only a redeliver sends it again
+	DeliveryDead = "dead"
+)
+
+// Webhook posts the goods events of a project to URL
+type Webhook struct {
+	ID        int    `json:"id" db:"id"`
+	ProjectID int    `json:"project_id" db:"project_id"`
+	URL       string `json:"url" db:"url"`
+	// EventTypes filters the events, empty means all of them
+	EventTypes []string `json:"event_types" db:"event_types"`
+	// Secret signs the deliveries, it is only returned when the webhook is created
+	Secret    string    `json:"secret,omitempty" db:"secret"`
+	Active    bool      `json:"active" db:"active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type CreateWebhook struct {
+	URL        string   `json:"url" binding:"required,http_url,max=2048"`
+	EventTypes []string `json:"event_types" binding:"omitempty,dive,oneof=goods.created goods.updated goods.removed goods.reprioritized"`
+	// Secret is generated when empty
+	Secret string `json:"secret" binding:"omitempty,min=16,max=255"`
+}
+
+// UpdateWebhook is a merge patch of a webhook, a null event_types clears the filter
+type UpdateWebhook struct {
+	URL        Field[string]   `json:"url" db:"url" binding:"omitnil,http_url,max=2048" swaggertype:"string"`
+	EventTypes Field[[]string] `json:"event_types" db:"event_types" binding:"omitnil,dive,oneof=goods.created goods.updated goods.removed goods.reprioritized" swaggertype:"array,string"`
+	Active     Field[bool]     `json:"active" db:"active" swaggertype:"boolean"`
+}
+
+func (i UpdateWebhook) Validate() error {
+	if !i.URL.Set && !i.EventTypes.Set && !i.Active.Set {
+		return ErrEmptyUpdate
+	}
+	return errors.Join(
+		notNull("url", i.URL.Null),
+		notNull("active", i.Active.Null),
+	)
+}
+
+// Accepts reports whether the webhook wants events of eventType
+func (w Webhook) Accepts(eventType string) bool {
+	if !w.Active {
+		return false
+	}
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type GetAllWebhooks struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook
+type WebhookDelivery struct {
+	ID            int             `json:"id" db:"id"`
+	WebhookID     int             `json:"webhook_id" db:"webhook_id"`
+	EventType     string          `json:"event_type" db:"event_type"`
+	Payload       json.RawMessage `json:"payload" db:"payload" swaggertype:"object"`
+	Status        string          `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	// ResponseStatus and LastError describe the last attempt
+	ResponseStatus int        `json:"response_status,omitempty" db:"response_status"`
+	LastError      string     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+type GetAllDeliveries struct {
+	Meta       MetaDeliveries    `json:"meta"`
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+type MetaDeliveries struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// DueDelivery is a claimed delivery with what it takes to send it
+type DueDelivery struct {
+	WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// DeliveryAttempt is the outcome of sending a delivery once
+type DeliveryAttempt struct {
+	// Status is DeliverySucceeded, DeliveryPending to retry at NextAttemptAt or DeliveryDead
+	Status         string
+	NextAttemptAt  time.Time
+	ResponseStatus int
+	Error          string
+}
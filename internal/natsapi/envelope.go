@@ -0,0 +1,97 @@
+package natsapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-service/internal/apperror"
+	"go-service/internal/models"
+)
+
+// Version is the envelope version this server speaks
+const Version = "v1"
+
+// Request is the envelope of every command, Data is the payload of the subject
+type Request struct {
+	Version string          `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Reply carries either the result in Data or what went wrong in Error
+type Reply struct {
+	Version string      `json:"version"`
+	Data    any         `json:"data,omitempty"`
+	Error   *ReplyError `json:"error,omitempty"`
+}
+
+// ReplyError is the error of a command, Code is stable like the codes of
+// the REST and gRPC APIs
+type ReplyError struct {
+	Code    string                `json:"code"`
+	Kind    string                `json:"kind"`
+	Message string                `json:"message"`
+	Fields  []apperror.FieldError `json:"fields,omitempty"`
+	TraceID string                `json:"trace_id,omitempty"`
+}
+
+// decode unwraps the envelope and decodes its data into obj, then checks
+// obj against its binding tags
+func decode(body []byte, obj any) error {
+	var req Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return apperror.Validation("request.invalid_body", "request is not a JSON envelope")
+	}
+	if req.Version != Version {
+		return apperror.Validation("request.unsupported_version", "version must be "+Version)
+	}
+	if len(req.Data) == 0 {
+		req.Data = []byte("{}")
+	}
+	if err := json.Unmarshal(req.Data, obj); err != nil {
+		return models.ValidationError("request.invalid_body", "request data is invalid", err)
+	}
+	if err := validate.Struct(obj); err != nil {
+		return models.ValidationError("request.invalid_body", "request data is invalid", err)
+	}
+	return nil
+}
+
+// appError turns errors of the services into domain errors, running out of
+// time is reported as unavailable
+func appError(err error) *apperror.Error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return apperror.Unavailable("request.timeout", "request timed out", err)
+	case errors.Is(err, context.Canceled):
+		return apperror.Unavailable("request.canceled", "request was canceled", err)
+	}
+	return apperror.From(err)
+}
+
+func newReplyError(ctx context.Context, err *apperror.Error) *ReplyError {
+	reply := &ReplyError{
+		Code:    err.Code,
+		Kind:    err.Kind.String(),
+		Message: err.Message,
+		Fields:  err.Fields,
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		reply.TraceID = sc.TraceID().String()
+	}
+	return reply
+}
+
+// validate checks requests against the binding tags of the models, like
+// gin does for the REST handlers
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	models.RegisterValidations(v)
+	return v
+}
@@ -0,0 +1,102 @@
+package natsapi
+
+import (
+	"context"
+
+	"go-service/internal/models"
+	"go-service/internal/service"
+)
+
+type goodsCommands struct {
+	goods service.Goods
+}
+
+type goodsRef struct {
+	ProjectID int `json:"project_id" binding:"required,min=1"`
+	ID        int `json:"id" binding:"required,min=1"`
+}
+
+type createGoodsRequest struct {
+	ProjectID   int    `json:"project_id" binding:"required,min=1"`
+	Name        string `json:"name" binding:"required,max=255"`
+	Description string `json:"description"`
+}
+
+type listGoodsRequest struct {
+	Limit  int `json:"limit" binding:"omitempty,min=1,max=1000"`
+	Offset int `json:"offset" binding:"omitempty,min=0"`
+}
+
+type updateGoodsRequest struct {
+	goodsRef
+	// Changes is a merge patch like the body of PATCH /api/goods
+	Changes models.UpdateGoods `json:"changes"`
+}
+
+type reprioritizeGoodsRequest struct {
+	goodsRef
+	Priority int `json:"priority" binding:"required,min=1,max=2147483647"`
+}
+
+// create answers with the created goods, like the REST handler
+func (c *goodsCommands) create(ctx context.Context, body []byte) (any, error) {
+	var req createGoodsRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.Description == "" {
+		req.Description = req.Name
+	}
+
+	id, err := c.goods.Create(ctx, req.ProjectID, models.Goods{Name: req.Name, Description: req.Description})
+	if err != nil {
+		return nil, err
+	}
+	return c.goods.GetOne(ctx, id, req.ProjectID)
+}
+
+func (c *goodsCommands) get(ctx context.Context, body []byte) (any, error) {
+	var req goodsRef
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	return c.goods.GetOne(ctx, req.ID, req.ProjectID)
+}
+
+func (c *goodsCommands) list(ctx context.Context, body []byte) (any, error) {
+	var req listGoodsRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	return c.goods.GetAll(ctx, req.Limit, req.Offset)
+}
+
+func (c *goodsCommands) update(ctx context.Context, body []byte) (any, error) {
+	var req updateGoodsRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if err := c.goods.Update(ctx, req.ID, req.ProjectID, req.Changes); err != nil {
+		return nil, err
+	}
+	return c.goods.GetOne(ctx, req.ID, req.ProjectID)
+}
+
+func (c *goodsCommands) delete(ctx context.Context, body []byte) (any, error) {
+	var req goodsRef
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	return nil, c.goods.Delete(ctx, req.ID, req.ProjectID)
+}
+
+func (c *goodsCommands) reprioritize(ctx context.Context, body []byte) (any, error) {
+	var req reprioritizeGoodsRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if err := c.goods.Reprioritize(ctx, req.ID, req.ProjectID, req.Priority); err != nil {
+		return nil, err
+	}
+	return c.goods.GetOne(ctx, req.ID, req.ProjectID)
+}
@@ -0,0 +1,129 @@
+package natsapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"go-service/internal/apperror"
+	"go-service/internal/service"
+	n "go-service/pkg/nats"
+	p "go-service/pkg/prometheus"
+)
+
+type Config struct {
+	Nats *nats.Conn
+	// Prefix comes before the subjects, svc answers on svc.goods.create
+	Prefix string
+	// Queue is the queue group the replicas share the requests in
+	Queue string
+	// Timeout bounds one request
+	Timeout time.Duration
+	// Concurrency is how many requests of one subject are handled at once
+	Concurrency int
+	Logger      *zap.Logger
+}
+
+// command handles the data of one request and returns the data of the reply
+type command func(ctx context.Context, body []byte) (any, error)
+
+// Server answers the commands of the NATS request-reply API with the same
+// services as the REST handlers
+type Server struct {
+	config     Config
+	commands   map[string]command
+	responders []*n.Responder
+}
+
+func New(services *service.Service, config Config) *Server {
+	if config.Prefix == "" {
+		config.Prefix = "svc"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.Logger == nil {
+		config.Logger = zap.NewNop()
+	}
+
+	goods := &goodsCommands{goods: services.Goods}
+	return &Server{
+		config: config,
+		commands: map[string]command{
+			"goods.create":       goods.create,
+			"goods.get":          goods.get,
+			"goods.list":         goods.list,
+			"goods.update":       goods.update,
+			"goods.delete":       goods.delete,
+			"goods.reprioritize": goods.reprioritize,
+		},
+	}
+}
+
+// Start subscribes to every command, requests are answered until Shutdown
+func (s *Server) Start(ctx context.Context) error {
+	client := n.NewNatsClient(s.config.Nats)
+	for name, cmd := range s.commands {
+		name, cmd := name, cmd
+		subject := s.config.Prefix + "." + name
+		responder, err := client.Respond(ctx, subject, s.config.Queue, s.config.Concurrency, func(ctx context.Context, msg *nats.Msg) []byte {
+			return s.handle(ctx, name, cmd, msg.Data)
+		})
+		if err != nil {
+			s.Shutdown(ctx)
+			return fmt.Errorf("subscribe to %s: %w", subject, err)
+		}
+		s.responders = append(s.responders, responder)
+	}
+	return nil
+}
+
+// Shutdown stops taking requests and waits for the pending ones until ctx is done
+func (s *Server) Shutdown(ctx context.Context) {
+	for _, responder := range s.responders {
+		if err := responder.Drain(ctx); err != nil {
+			s.config.Logger.Warn("failed to drain NATS commands", zap.Error(err))
+		}
+	}
+	s.responders = nil
+}
+
+func (s *Server) handle(ctx context.Context, name string, cmd command, body []byte) []byte {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	data, err := cmd(ctx, body)
+	reply := Reply{Version: Version, Data: data}
+	result := "ok"
+	if err != nil {
+		e := appError(err)
+		reply = Reply{Version: Version, Error: newReplyError(ctx, e)}
+		result = e.Kind.String()
+
+		span := trace.SpanFromContext(ctx)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		if e.Kind == apperror.KindInternal || e.Kind == apperror.KindUnavailable {
+			s.config.Logger.Error(e.Message,
+				zap.String("code", e.Code),
+				zap.String("command", name),
+				zap.Error(e),
+			)
+		}
+	}
+	p.NatsCommandsTotal.WithLabelValues(name, result).Inc()
+
+	out, err := json.Marshal(reply)
+	if err != nil {
+		// the data of every command marshals, this is a bug
+		s.config.Logger.Error("failed to marshal NATS reply", zap.String("command", name), zap.Error(err))
+		out, _ = json.Marshal(Reply{Version: Version, Error: newReplyError(ctx, apperror.Internal(err))})
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"go-service/internal/apperror"
+)
+
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+var (
+	ErrGoodsNotFound    = apperror.NotFound("goods.not_found", "goods not found")
+	ErrProjectNotFound  = apperror.NotFound("project.not_found", "project not found")
+	ErrProjectHasGoods  = apperror.Conflict("project.has_goods", "project still has goods")
+	ErrWebhookNotFound  = apperror.NotFound("webhook.not_found", "webhook not found")
+	ErrDeliveryNotFound = apperror.NotFound("delivery.not_found", "delivery not found")
+	ErrAlreadyExists    = apperror.Conflict("record.already_exists", "record already exists")
+	ErrUnavailable      = apperror.Unavailable("database.unavailable", "database is unavailable", nil)
+	ErrEmptyUpdate      = apperror.Validation("request.empty_update", "at least one field must be set")
+)
+
+// dbError turns driver errors into domain errors, notFound is returned for
+// missing rows and may be nil where no rows is not an error
+func dbError(err error, notFound *apperror.Error) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) && notFound != nil {
+		return notFound.Wrap(err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return ErrAlreadyExists.Wrap(err)
+	}
+
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) || errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return ErrUnavailable.Wrap(err)
+	}
+
+	return err
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation
+}
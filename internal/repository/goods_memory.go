@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-service/internal/models"
+)
+
+type GoodsMemory struct {
+	store *memoryStore
+}
+
+// GetAll get all Goods. Removed goods are listed and counted, as in Postgres
+func (r *GoodsMemory) GetAll(ctx context.Context, limit, offset int) (models.GetAllGoods, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	limit, offset = normalizePage(limit, offset)
+
+	var (
+		goods   []models.Goods
+		removed int
+	)
+	for _, item := range s.goods {
+		goods = append(goods, item)
+		if item.Removed {
+			removed++
+		}
+	}
+
+	return models.GetAllGoods{
+		Meta: models.Meta{
+			Total:   len(goods),
+			Removed: removed,
+			Limit:   limit,
+			Offset:  offset,
+		},
+		Goods: page(goods, func(g models.Goods) int { return g.ID }, limit, offset),
+	}, nil
+}
+
+func (r *GoodsMemory) GetOne(ctx context.Context, goodsID, projectID int) (models.Goods, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	goods, ok := s.find(goodsID, projectID)
+	if !ok {
+		return models.Goods{}, ErrGoodsNotFound
+	}
+	return goods, nil
+}
+
+// Create adds goods at the end of the priority order, like the insert
+// trigger the requested priority is ignored
+func (r *GoodsMemory) Create(ctx context.Context, projectID int, goods models.Goods) (int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.projects[projectID]; !ok {
+		return 0, ErrProjectNotFound
+	}
+
+	priority := 0
+	for _, item := range s.goods {
+		priority = max(priority, item.Priority)
+	}
+
+	s.goodsSeq++
+	goods.ID = s.goodsSeq
+	goods.ProjectID = projectID
+	goods.Priority = priority + 1
+	goods.CreatedAt = time.Now()
+	s.goods[goods.ID] = goods
+
+	return goods.ID, nil
+}
+
+func (r *GoodsMemory) Update(ctx context.Context, goodsID, projectID int, input models.UpdateGoods) error {
+	return r.Patch(ctx, goodsID, projectID, func(models.Goods) (models.UpdateGoods, error) { return input, nil })
+}
+
+// Patch holds the store lock from reading the goods for patch until the update is applied
+func (r *GoodsMemory) Patch(ctx context.Context, goodsID, projectID int, patch func(models.Goods) (models.UpdateGoods, error)) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goods, ok := s.find(goodsID, projectID)
+	if !ok {
+		return ErrGoodsNotFound
+	}
+	input, err := patch(goods)
+	if err != nil {
+		return err
+	}
+	if !input.Name.Set && !input.Description.Set && !input.Priority.Set && !input.Removed.Set {
+		return ErrEmptyUpdate
+	}
+	if input.Name.Null {
+		return notNullViolation(goodsTable, "name")
+	}
+	if input.Removed.Null {
+		return notNullViolation(goodsTable, "removed")
+	}
+
+	if input.Name.Set {
+		goods.Name = input.Name.Value
+	}
+	if input.Description.Set {
+		// a null description reads back as empty
+		goods.Description = input.Description.Value
+	}
+	if input.Priority.Set {
+		goods.Priority = input.Priority.Value
+	}
+	if input.Removed.Set {
+		goods.Removed = input.Removed.Value
+	}
+	s.goods[goodsID] = goods
+
+	if input.Priority.Set {
+		s.shiftPriorities(projectID, input.Priority.Value)
+	}
+	return nil
+}
+
+// Delete marks item of Goods as deleted
+func (r *GoodsMemory) Delete(ctx context.Context, goodsID, projectID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goods, ok := s.find(goodsID, projectID)
+	if !ok {
+		return ErrGoodsNotFound
+	}
+
+	goods.Removed = true
+	s.goods[goodsID] = goods
+	return nil
+}
+
+func (r *GoodsMemory) Reprioritize(ctx context.Context, goodsID, projectID int, priority int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	goods, ok := s.find(goodsID, projectID)
+	if !ok {
+		return ErrGoodsNotFound
+	}
+
+	goods.Priority = priority
+	s.goods[goodsID] = goods
+	s.shiftPriorities(projectID, priority)
+	return nil
+}
+
+func (s *memoryStore) find(goodsID, projectID int) (models.Goods, bool) {
+	goods, ok := s.goods[goodsID]
+	if !ok || goods.ProjectID != projectID {
+		return models.Goods{}, false
+	}
+	return goods, true
+}
+
+// shiftPriorities moves the goods of the project at priority and below down by
+// one, like the Postgres update
+func (s *memoryStore) shiftPriorities(projectID, priority int) {
+	for id, item := range s.goods {
+		if item.ProjectID == projectID && item.Priority >= priority {
+			item.Priority++
+			s.goods[id] = item
+		}
+	}
+}
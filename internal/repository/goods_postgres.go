@@ -3,13 +3,10 @@ package repository
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"strings"
-	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/nats-io/nats.go"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
@@ -22,94 +19,94 @@ import (
 	r "go-service/pkg/redis"
 )
 
-var ErrNotFound = errors.New("record not found")
-
 type GoodsPostgres struct {
-	ctx    context.Context
-	db     *pgxpool.Pool
-	cache  r.Cache
-	logger *zap.Logger
-	nats   *nats.Conn
-	tracer trace.Tracer
+	db       *pgxpool.Pool
+	cache    r.Cache
+	loader   *r.Loader
+	policies *CachePolicies
+	timeouts Timeouts
+	logger   *zap.Logger
+	tracer   trace.Tracer
 }
 
-func NewGoodsPostgres(ctx context.Context, db *pgxpool.Pool, cache r.Cache, logger *zap.Logger, nats *nats.Conn, tracer trace.Tracer) *GoodsPostgres {
+func NewGoodsPostgres(db *pgxpool.Pool, cache r.Cache, loader *r.Loader, policies *CachePolicies, timeouts Timeouts, logger *zap.Logger, tracer trace.Tracer) *GoodsPostgres {
 	return &GoodsPostgres{
-		ctx:    ctx,
-		db:     db,
-		cache:  cache,
-		logger: logger,
-		nats:   nats,
-		tracer: tracer,
+		db:       db,
+		cache:    cache,
+		loader:   loader,
+		policies: policies,
+		timeouts: timeouts,
+		logger:   logger,
+		tracer:   tracer,
 	}
 }
 
 // GetAll get all Goods
 func (r *GoodsPostgres) GetAll(ctx context.Context, limit, offset int) (models.GetAllGoods, error) {
-	var goods []models.Goods
+	var response models.GetAllGoods
 
-	_, span := r.tracer.Start(ctx, "GetAllGoods")
+	ctx, span := r.tracer.Start(ctx, "GetAllGoods")
 	defer span.End()
 
-	query := fmt.Sprintf(`SELECT gp.id, gp.project_id, gp.name, gp.description, gp.priority, gp.removed, gp.created_at FROM %s gp LIMIT $1 OFFSET $2`, goodsTable)
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
 
-	span.AddEvent("getAll", trace.WithAttributes(attribute.String("query", query)))
-	conn, err := r.db.Acquire(r.ctx)
-	if err != nil {
-		return models.GetAllGoods{}, err
-	}
-	defer conn.Release()
+	limit, offset = normalizePage(limit, offset)
 
-	pgxConn := conn.Conn()
-
-	_, err = pgxConn.Prepare(r.ctx, "getAllGoods", query)
+	key, err := goodsListKey(ctx, r.cache, limit, offset)
 	if err != nil {
-		return models.GetAllGoods{}, err
+		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
+		span.SetStatus(codes.Error, err.Error())
+		return response, dbError(err, nil)
 	}
 
-	rows, err := pgxConn.Query(r.ctx, "getAllGoods", limit, offset)
+	span.AddEvent("redis get", trace.WithAttributes(attribute.String("key", key)))
+	cachedGoods, status, err := r.loader.Get(ctx, key, r.policies.Load().Lists, func(ctx context.Context) (string, error) {
+		return r.loadAll(ctx, limit, offset)
+	})
 	if err != nil {
-		return models.GetAllGoods{}, err
+		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
+		span.SetStatus(codes.Error, err.Error())
+		return response, dbError(err, nil)
 	}
-	defer rows.Close()
+	span.AddEvent("cache lookup", trace.WithAttributes(attribute.Int("status", int(status))))
 
-	for rows.Next() {
-		var good models.Goods
-		if err := rows.Scan(&good.ID, &good.ProjectID, &good.Name, &good.Description, &good.Priority, &good.Removed, &good.CreatedAt); err != nil {
-			return models.GetAllGoods{}, err
-		}
-		goods = append(goods, good)
+	if err := json.Unmarshal([]byte(cachedGoods), &response); err != nil {
+		r.logger.Error("Failed to unmarshal cached goods list: %v", zap.Error(err))
+		return response, dbError(err, nil)
 	}
 
-	if err := rows.Err(); err != nil {
-		return models.GetAllGoods{}, err
-	}
+	return response, nil
+}
 
-	var total int
-	countQuery := fmt.Sprintf(`SELECT COUNT(gp.id) FROM %s gp`, goodsTable)
-	_, err = pgxConn.Prepare(r.ctx, "countAllGoods", countQuery)
-	if err != nil {
-		return models.GetAllGoods{}, err
-	}
+// loadAll reads one page of Goods with its meta from the database and encodes it for the cache
+func (r *GoodsPostgres) loadAll(ctx context.Context, limit, offset int) (string, error) {
+	var goods []models.Goods
 
-	err = pgxConn.QueryRow(r.ctx, "countAllGoods").Scan(&total)
-	if err != nil {
-		return models.GetAllGoods{}, err
-	}
-	span.AddEvent("countAll", trace.WithAttributes(attribute.Int("total", total)))
+	query := fmt.Sprintf(`SELECT gp.id, gp.project_id, gp.name, COALESCE(gp.description, ''), gp.priority, gp.removed, gp.created_at FROM %s gp LIMIT $1 OFFSET $2`, goodsTable)
 
-	var removed int
-	removedQuery := fmt.Sprintf(`SELECT COUNT(gp.id) FROM %s gp WHERE gp.removed = true`, goodsTable)
-	_, err = pgxConn.Prepare(r.ctx, "countRemovedGoods", removedQuery)
-	if err != nil {
-		return models.GetAllGoods{}, err
-	}
+	countQuery := fmt.Sprintf(`SELECT COUNT(gp.id), COUNT(gp.id) FILTER (WHERE gp.removed) FROM %s gp`, goodsTable)
 
-	err = pgxConn.QueryRow(r.ctx, "countRemovedGoods").Scan(&removed)
-	if err != nil {
-		return models.GetAllGoods{}, err
+	// the page and both counts go out in one round trip
+	var total, removed int
+	batch := &pgx.Batch{}
+	batch.Queue(query, limit, offset).Query(func(rows pgx.Rows) error {
+		for rows.Next() {
+			var good models.Goods
+			if err := rows.Scan(&good.ID, &good.ProjectID, &good.Name, &good.Description, &good.Priority, &good.Removed, &good.CreatedAt); err != nil {
+				return err
+			}
+			goods = append(goods, good)
+		}
+		return rows.Err()
+	})
+	batch.Queue(countQuery).QueryRow(func(row pgx.Row) error {
+		return row.Scan(&total, &removed)
+	})
+
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		return "", err
 	}
-	span.AddEvent("countRemoved", trace.WithAttributes(attribute.Int("removed", removed)))
 
 	meta := models.Meta{
 		Total:   total,
@@ -123,248 +120,289 @@ func (r *GoodsPostgres) GetAll(ctx context.Context, limit, offset int) (models.G
 		Goods: goods,
 	}
 
-	return response, nil
+	goodsJson, err := json.Marshal(response)
+	if err != nil {
+		r.logger.Error("Failed to marshal goods list: %v", zap.Error(err))
+		return "", err
+	}
+
+	return string(goodsJson), nil
 }
 
 // GetOne one item from Goods
 func (r *GoodsPostgres) GetOne(ctx context.Context, goodsID, projectID int) (models.Goods, error) {
 	var goods models.Goods
 
-	_, span := r.tracer.Start(ctx, "GetOneItem")
+	ctx, span := r.tracer.Start(ctx, "GetOneItem")
 	defer span.End()
 
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
 	span.AddEvent("redis get", trace.WithAttributes(attribute.String("key", fmt.Sprintf("goods:%d:%d", goodsID, projectID))))
 	key := fmt.Sprintf("goods:%d:%d", goodsID, projectID)
-	cachedGoods, err := r.cache.Get(r.ctx, key)
-	if err == nil {
-		p.CacheHitsTotal.WithLabelValues(fmt.Sprintf("goods:%d", goodsID), fmt.Sprintf("project:%d", projectID)).Inc()
-		err := json.Unmarshal([]byte(cachedGoods), &goods)
-		if err != nil {
-			r.logger.Error("Failed to unmarshal cached goods: %v", zap.Error(err))
-		} else {
-			return goods, nil
-		}
-	} else {
-		p.CacheMissesTotal.WithLabelValues(fmt.Sprintf("goods:%d", goodsID), fmt.Sprintf("project:%d", projectID)).Inc()
+	cachedGoods, status, err := r.loader.Get(ctx, key, r.policies.Load().Goods, func(ctx context.Context) (string, error) {
+		return r.loadOne(ctx, goodsID, projectID)
+	})
+	if err != nil {
+		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
+		span.SetStatus(codes.Error, err.Error())
+		return goods, dbError(err, ErrGoodsNotFound)
 	}
 
-	query := fmt.Sprintf(`SELECT gp.id, gp.project_id, gp.name, gp.description, gp.priority, gp.removed, gp.created_at FROM %s gp WHERE gp.id = $1 AND gp.project_id = $2`, goodsTable)
+	countCacheLookup(status, goodsID, projectID)
+	span.AddEvent("cache lookup", trace.WithAttributes(attribute.Int("status", int(status))))
 
-	conn, err := r.db.Acquire(r.ctx)
-	if err != nil {
-		return goods, err
+	if err := json.Unmarshal([]byte(cachedGoods), &goods); err != nil {
+		r.logger.Error("Failed to unmarshal cached goods: %v", zap.Error(err))
+		return goods, dbError(err, ErrGoodsNotFound)
 	}
-	defer conn.Release()
 
-	pgxConn := conn.Conn()
+	return goods, nil
+}
 
-	_, err = pgxConn.Prepare(r.ctx, "getOneItem", query)
-	if err != nil {
-		return goods, err
+func countCacheLookup(status r.Status, goodsID, projectID int) {
+	goodsLabel, projectLabel := fmt.Sprintf("goods:%d", goodsID), fmt.Sprintf("project:%d", projectID)
+	switch status {
+	case r.Hit:
+		p.CacheHitsTotal.WithLabelValues(goodsLabel, projectLabel).Inc()
+	case r.StaleHit:
+		p.CacheHitsTotal.WithLabelValues(goodsLabel, projectLabel).Inc()
+		p.CacheStaleTotal.WithLabelValues(goodsLabel, projectLabel).Inc()
+	default:
+		p.CacheMissesTotal.WithLabelValues(goodsLabel, projectLabel).Inc()
 	}
+}
 
-	err = pgxConn.QueryRow(r.ctx, "getOneItem", goodsID, projectID).Scan(&goods.ID, &goods.ProjectID, &goods.Name, &goods.Description, &goods.Priority, &goods.Removed, &goods.CreatedAt)
-	if err != nil {
-		return goods, err
-	}
-	span.AddEvent("write goods in JSON")
-	goodsJson, err := json.Marshal(goods)
+// loadOne reads one item of Goods from the database and encodes it for the cache
+func (r *GoodsPostgres) loadOne(ctx context.Context, goodsID, projectID int) (string, error) {
+	var goods models.Goods
 
+	query := fmt.Sprintf(`SELECT gp.id, gp.project_id, gp.name, COALESCE(gp.description, ''), gp.priority, gp.removed, gp.created_at FROM %s gp WHERE gp.id = $1 AND gp.project_id = $2`, goodsTable)
+
+	err := r.db.QueryRow(ctx, query, goodsID, projectID).Scan(&goods.ID, &goods.ProjectID, &goods.Name, &goods.Description, &goods.Priority, &goods.Removed, &goods.CreatedAt)
 	if err != nil {
-		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
-		span.SetStatus(codes.Error, err.Error())
-		r.logger.Error("Failed to marshal goods: %v", zap.Error(err))
-		return goods, err
+		return "", err
 	}
 
-	span.AddEvent("redis set", trace.WithAttributes(attribute.String("key", fmt.Sprintf("goods:%d:%d", goodsID, projectID))))
-	err = r.cache.Set(r.ctx, key, string(goodsJson), 1*time.Minute)
+	goodsJson, err := json.Marshal(goods)
 	if err != nil {
-		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
-		span.SetStatus(codes.Error, err.Error())
-		r.logger.Error("Failed to cache goods: %v", zap.Error(err))
+		r.logger.Error("Failed to marshal goods: %v", zap.Error(err))
+		return "", err
 	}
 
-	return goods, nil
+	return string(goodsJson), nil
 }
 
 // Create method creates a new item of Goods
 func (r *GoodsPostgres) Create(ctx context.Context, projectID int, goods models.Goods) (int, error) {
 	var id int
 
-	_, span := r.tracer.Start(ctx, "CreateItem")
+	ctx, span := r.tracer.Start(ctx, "CreateItem")
 	defer span.End()
 
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
 	query := fmt.Sprintf(`INSERT INTO %s (project_id, name, description, priority, removed) VALUES ($1, $2, $3, $4, $5) RETURNING id`, goodsTable)
 
-	conn, err := r.db.Acquire(r.ctx)
-	if err != nil {
-		return 0, err
+	span.AddEvent("create item", trace.WithAttributes(attribute.String("query", query)))
+	err := r.db.QueryRow(ctx, query, projectID, goods.Name, goods.Description, goods.Priority, goods.Removed).Scan(&id)
+	if isForeignKeyViolation(err) {
+		return 0, ErrProjectNotFound.Wrap(err)
 	}
-	defer conn.Release()
-
-	pgxConn := conn.Conn()
-
-	_, err = pgxConn.Prepare(r.ctx, "createItem", query)
 	if err != nil {
-		return 0, err
+		return 0, dbError(err, nil)
 	}
 
-	span.AddEvent("create item", trace.WithAttributes(attribute.String("query", query)))
-	err = pgxConn.QueryRow(r.ctx, "createItem", projectID, goods.Name, goods.Description, goods.Priority, goods.Removed).Scan(&id)
-	if err != nil {
-		return 0, err
-	}
+	r.invalidateLists(ctx, span, projectID)
 
 	return id, nil
 }
 
 // Update method updates item of Goods
 func (r *GoodsPostgres) Update(ctx context.Context, goodsID, projectID int, input models.UpdateGoods) error {
-	tx, err := r.db.Begin(r.ctx)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(r.ctx)
+	return r.Patch(ctx, goodsID, projectID, func(models.Goods) (models.UpdateGoods, error) { return input, nil })
+}
 
-	_, span := r.tracer.Start(ctx, "UpdateItem")
+// Patch updates item of Goods with the update patch builds from the item,
+// read and locked in the transaction of the update
+func (r *GoodsPostgres) Patch(ctx context.Context, goodsID, projectID int, patch func(models.Goods) (models.UpdateGoods, error)) error {
+	ctx, span := r.tracer.Start(ctx, "UpdateItem")
 	defer span.End()
 
-	var exists bool
-	err = tx.QueryRow(r.ctx, fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1 AND project_id = $2)", goodsTable), goodsID, projectID).Scan(&exists)
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return err
+		return dbError(err, ErrGoodsNotFound)
 	}
-	if !exists {
-		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
+	defer tx.Rollback(ctx)
+
+	var current models.Goods
+	query := fmt.Sprintf("SELECT gp.id, gp.project_id, gp.name, COALESCE(gp.description, ''), gp.priority, gp.removed, gp.created_at FROM %s gp WHERE gp.id = $1 AND gp.project_id = $2 FOR UPDATE", goodsTable)
+	err = tx.QueryRow(ctx, query, goodsID, projectID).Scan(&current.ID, &current.ProjectID, &current.Name, &current.Description, &current.Priority, &current.Removed, &current.CreatedAt)
+	if err != nil {
+		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-		return ErrNotFound
+		return dbError(err, ErrGoodsNotFound)
 	}
-	span.AddEvent("update item", trace.WithAttributes(attribute.Int("goodsID", goodsID), attribute.Int("projectID", projectID), attribute.Bool("exists", exists)))
+	span.AddEvent("update item", trace.WithAttributes(attribute.Int("goodsID", goodsID), attribute.Int("projectID", projectID)))
 
-	_, err = tx.Exec(r.ctx, fmt.Sprintf("SELECT gp.id, gp.project_id, gp.name, gp.description, gp.priority, gp.removed, gp.created_at FROM %s gp WHERE gp.id = $1 AND gp.project_id = $2 FOR UPDATE", goodsTable), goodsID, projectID)
+	input, err := patch(current)
 	if err != nil {
 		return err
 	}
 
-	setValues := make([]string, 0)
-	args := make([]interface{}, 0)
-	argID := 1
+	update := newUpdateSet(goodsTable, "name", "description", "priority", "removed")
+	setField(update, "name", input.Name)
+	setField(update, "description", input.Description)
+	setField(update, "priority", input.Priority)
+	setField(update, "removed", input.Removed)
+	update.Where("id", goodsID)
+	update.Where("project_id", projectID)
 
-	if input.Name != nil {
-		setValues = append(setValues, fmt.Sprintf("name = $%d", argID))
-		args = append(args, *input.Name)
-		argID++
-	}
-	if input.Description != nil {
-		setValues = append(setValues, fmt.Sprintf("description = $%d", argID))
-		args = append(args, *input.Description)
-		argID++
+	query, args, err := update.SQL()
+	if err != nil {
+		return err
 	}
 
-	setQuery := strings.Join(setValues, ", ")
-
-	span.AddEvent("set query", trace.WithAttributes(attribute.String("setQuery", setQuery)))
-	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d`, goodsTable, setQuery, argID)
-	args = append(args, goodsID)
-	_, err = tx.Exec(r.ctx, query, args...)
+	span.AddEvent("update query", trace.WithAttributes(attribute.String("query", query)))
+	_, err = tx.Exec(ctx, query, args...)
 	if err != nil {
-		return err
+		return dbError(err, ErrGoodsNotFound)
+	}
+
+	if input.Priority.Set {
+		if err := shiftPriorities(ctx, tx, projectID, input.Priority.Value); err != nil {
+			return err
+		}
 	}
 
-	err = tx.Commit(r.ctx)
+	err = tx.Commit(ctx)
 	if err != nil {
-		return err
+		return dbError(err, ErrGoodsNotFound)
 	}
 
 	span.AddEvent("invalidate goods in cache", trace.WithAttributes(attribute.String("key", fmt.Sprintf("goods:%d:%d", goodsID, projectID))))
 	key := fmt.Sprintf("goods:%d:%d", goodsID, projectID)
-	err = r.cache.Delete(r.ctx, key)
+	err = r.loader.Invalidate(context.WithoutCancel(ctx), key)
 	if err != nil {
 		span.RecordError(err, trace.WithAttributes(attribute.String("Invalidate error", err.Error())))
 		span.SetStatus(codes.Error, err.Error())
 		r.logger.Error("Failed to invalidate cache for key %s: %v", zap.String("key", key), zap.Error(err))
 	}
 
+	r.invalidateLists(ctx, span, projectID)
+
 	return nil
 }
 
 // Delete marks item of Goods as deleted
 func (r *GoodsPostgres) Delete(ctx context.Context, goodsID, projectID int) error {
-	_, span := r.tracer.Start(ctx, "DeleteItem")
+	ctx, span := r.tracer.Start(ctx, "DeleteItem")
 	defer span.End()
 
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
 	query := fmt.Sprintf(`UPDATE %s SET removed = true WHERE id = $1 AND project_id = $2`, goodsTable)
 	span.AddEvent("delete item", trace.WithAttributes(attribute.String("query", query)))
-	commandTag, err := r.db.Exec(r.ctx, query, goodsID, projectID)
+	commandTag, err := r.db.Exec(ctx, query, goodsID, projectID)
 	if err != nil {
 		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
 		span.SetStatus(codes.Error, err.Error())
-		return err
+		return dbError(err, ErrGoodsNotFound)
 	}
 
 	rowsAffected := commandTag.RowsAffected()
 	if rowsAffected == 0 {
-		return ErrNotFound
+		return ErrGoodsNotFound
 	}
 
 	span.AddEvent("invalidate goods in cache", trace.WithAttributes(attribute.String("key", fmt.Sprintf("goods:%d:%d", goodsID, projectID))))
 	key := fmt.Sprintf("goods:%d:%d", goodsID, projectID)
-	err = r.cache.Delete(r.ctx, key)
+	err = r.loader.Invalidate(context.WithoutCancel(ctx), key)
 	if err != nil {
 		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
 		span.SetStatus(codes.Error, err.Error())
 		r.logger.Error("Failed to invalidate cache for key %s: %v", zap.String("key", key), zap.Error(err))
 	}
 
+	r.invalidateLists(ctx, span, projectID)
+
 	return nil
 }
 
 // Reprioritize method changes priority of item of Goods
 func (r *GoodsPostgres) Reprioritize(ctx context.Context, goodsID, projectID int, priority int) error {
-	tx, err := r.db.Begin(r.ctx)
+	ctx, span := r.tracer.Start(ctx, "ReprioritizeItem")
+	defer span.End()
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return err
+		return dbError(err, ErrGoodsNotFound)
 	}
-	defer tx.Rollback(r.ctx) // Убедитесь, что вызывается Rollback в случае ошибки
-
-	_, span := r.tracer.Start(ctx, "ReprioritizeItem")
-	defer span.End()
+	defer tx.Rollback(ctx)
 
 	var exists bool
-	err = tx.QueryRow(r.ctx, fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1 AND project_id = $2)", goodsTable), goodsID, projectID).Scan(&exists)
+	err = tx.QueryRow(ctx, fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1 AND project_id = $2)", goodsTable), goodsID, projectID).Scan(&exists)
 	if err != nil {
-		return err
+		return dbError(err, ErrGoodsNotFound)
 	}
 	if !exists {
-		return ErrNotFound // Предполагается, что ErrNotFound определен где-то в вашем коде
+		return ErrGoodsNotFound
 	}
 
 	query := fmt.Sprintf(`UPDATE %s SET priority = $1 WHERE id = $2 AND project_id = $3`, goodsTable)
-	_, err = tx.Exec(r.ctx, query, priority, goodsID, projectID)
+	_, err = tx.Exec(ctx, query, priority, goodsID, projectID)
 	if err != nil {
-		return err
+		return dbError(err, ErrGoodsNotFound)
 	}
 
-	query = fmt.Sprintf(`UPDATE %s SET priority = priority + 1 WHERE project_id = $1 AND priority >= $2`, goodsTable)
-	_, err = tx.Exec(r.ctx, query, projectID, priority)
-	if err != nil {
+	if err := shiftPriorities(ctx, tx, projectID, priority); err != nil {
 		return err
 	}
 
-	err = tx.Commit(r.ctx)
+	err = tx.Commit(ctx)
 	if err != nil {
-		return err
+		return dbError(err, ErrGoodsNotFound)
 	}
 
 	span.AddEvent("invalidate goods in cache", trace.WithAttributes(attribute.String("key", fmt.Sprintf("goods:%d:%d", goodsID, projectID))))
 	key := fmt.Sprintf("goods:%d:%d", goodsID, projectID)
-	err = r.cache.Delete(r.ctx, key)
+	err = r.loader.Invalidate(context.WithoutCancel(ctx), key)
 	if err != nil {
 		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
 		span.SetStatus(codes.Error, err.Error())
 		r.logger.Error("Failed to invalidate cache for key %s: %v", zap.String("key", key), zap.Error(err))
 	}
 
+	r.invalidateLists(ctx, span, projectID)
+
+	return nil
+}
+
+// shiftPriorities moves the goods of the project at priority and below down by one
+func shiftPriorities(ctx context.Context, tx pgx.Tx, projectID, priority int) error {
+	query := fmt.Sprintf(`UPDATE %s SET priority = priority + 1 WHERE project_id = $1 AND priority >= $2`, goodsTable)
+	_, err := tx.Exec(ctx, query, projectID, priority)
+	if err != nil {
+		return dbError(err, ErrGoodsNotFound)
+	}
 	return nil
 }
+
+// invalidateLists drops every cached page that may contain goods of the project
+func (r *GoodsPostgres) invalidateLists(ctx context.Context, span trace.Span, projectID int) {
+	span.AddEvent("invalidate goods lists in cache", trace.WithAttributes(attribute.Int("projectID", projectID)))
+	if err := invalidateGoodsLists(context.WithoutCancel(ctx), r.cache); err != nil {
+		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
+		span.SetStatus(codes.Error, err.Error())
+		r.logger.Error("Failed to invalidate goods lists: %v", zap.Int("projectID", projectID), zap.Error(err))
+	}
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	r "go-service/pkg/redis"
+)
+
+// InvalidateGoods drops the cached goods of the project and the list pages
+// that may contain them, for changes made around the repositories
+func InvalidateGoods(ctx context.Context, cache r.Cache, loader *r.Loader, projectID int, goodsIDs ...int) error {
+	errs := []error{invalidateGoodsLists(ctx, cache)}
+	for _, goodsID := range goodsIDs {
+		errs = append(errs, loader.Invalidate(ctx, fmt.Sprintf("goods:%d:%d", goodsID, projectID)))
+	}
+	return errors.Join(errs...)
+}
+
+// InvalidateProjects drops the cached projects and the project list pages,
+// for changes made around the repositories
+func InvalidateProjects(ctx context.Context, cache r.Cache, loader *r.Loader, projectIDs ...int) error {
+	errs := []error{invalidateProjectsLists(ctx, cache)}
+	for _, projectID := range projectIDs {
+		errs = append(errs, loader.Invalidate(ctx, fmt.Sprintf("project:%d", projectID)))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	r "go-service/pkg/redis"
+)
+
+const (
+	defaultLimit = 10
+	maxLimit     = 1000
+)
+
+const (
+	goodsTag    = "goods"
+	projectsTag = "projects"
+)
+
+// CachePatterns match every item and list key the repositories write. Tag and
+// version counters aren't included, so running replicas keep ordering their invalidations
+var CachePatterns = []string{"goods:*", "project:*", "projects:*"}
+
+// normalizePage keeps equivalent list requests on the same cache key
+func normalizePage(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func goodsListKey(ctx context.Context, cache r.Cache, limit, offset int) (string, error) {
+	return r.TaggedKey(ctx, cache, fmt.Sprintf("goods:list:%d:%d", limit, offset), goodsTag)
+}
+
+func projectsListKey(ctx context.Context, cache r.Cache, limit, offset int) (string, error) {
+	return r.TaggedKey(ctx, cache, fmt.Sprintf("projects:list:%d:%d", limit, offset), projectsTag)
+}
+
+func invalidateGoodsLists(ctx context.Context, cache r.Cache) error {
+	return r.InvalidateTags(ctx, cache, goodsTag)
+}
+
+func invalidateProjectsLists(ctx context.Context, cache r.Cache) error {
+	return r.InvalidateTags(ctx, cache, projectsTag)
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"go-service/internal/models"
+)
+
+// memoryStore is the state shared by the in-memory repositories. Goods
+// reference projects the way the foreign key does in Postgres
+type memoryStore struct {
+	mu          sync.RWMutex
+	projects    map[int]models.Project
+	goods       map[int]models.Goods
+	webhooks    map[int]models.Webhook
+	deliveries  map[int]models.WebhookDelivery
+	projectSeq  int
+	goodsSeq    int
+	webhookSeq  int
+	deliverySeq int
+}
+
+// NewMemory returns repositories that keep everything in process memory,
+// for tests and demo environments. The data is lost on restart and isn't
+// shared between replicas
+func NewMemory() *Repository {
+	store := &memoryStore{
+		projects:   make(map[int]models.Project),
+		goods:      make(map[int]models.Goods),
+		webhooks:   make(map[int]models.Webhook),
+		deliveries: make(map[int]models.WebhookDelivery),
+	}
+	return &Repository{
+		Goods:    &GoodsMemory{store: store},
+		Projects: &ProjectMemory{store: store},
+		Webhooks: &WebhooksMemory{store: store},
+	}
+}
+
+// page returns items[offset:offset+limit] after sorting by id, the order
+// Postgres returns rows of a freshly filled table in
+func page[T any](items []T, id func(T) int, limit, offset int) []T {
+	sort.Slice(items, func(i, j int) bool { return id(items[i]) < id(items[j]) })
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// notNullViolation mirrors the error Postgres returns for a null in a NOT NULL column
+func notNullViolation(table, column string) error {
+	return fmt.Errorf("null value in column %q of relation %q violates not-null constraint", column, table)
+}
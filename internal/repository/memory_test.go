@@ -0,0 +1,14 @@
+package repository_test
+
+import (
+	"testing"
+
+	"go-service/internal/repository"
+	"go-service/internal/repository/repotest"
+)
+
+func TestMemory(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) *repository.Repository {
+		return repository.NewMemory()
+	})
+}
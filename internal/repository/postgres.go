@@ -3,14 +3,18 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const (
-	goodsTable    = "goods"
-	projectsTable = "projects"
+	goodsTable      = "goods"
+	projectsTable   = "projects"
+	webhooksTable   = "webhooks"
+	deliveriesTable = "webhook_deliveries"
 )
 
 type Config struct {
@@ -22,6 +26,19 @@ type Config struct {
 	SSLMode  string
 }
 
+// Timeouts bound every repository call, zero means no deadline besides the caller's own
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 func NewPostgresDB(ctx context.Context, config Config) (*pgxpool.Pool, error) {
 	const op = "storage.postgres.New"
 	connString := fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=%s", config.Host, config.Port, config.Username, config.DBName, config.Password, config.SSLMode)
@@ -32,6 +49,9 @@ func NewPostgresDB(ctx context.Context, config Config) (*pgxpool.Pool, error) {
 	}
 
 	cfg.ConnConfig.Tracer = otelpgx.NewTracer()
+	// repository queries have fixed SQL text, so each connection prepares a
+	// statement on first use and reuses it from its cache afterwards
+	cfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
 
 	conn, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
@@ -0,0 +1,214 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"go-service/internal/models"
+	"go-service/internal/repository"
+)
+
+// The benchmarks compare the repository reads with the path they replaced,
+// which acquired a connection and prepared every statement on it before
+// running the same queries one after another. Both use the same pool, the
+// difference grows with the round trip time to the database:
+//
+//	GOSERVICE_TEST_POSTGRES_DSN=postgres://... go test ./internal/repository -run '^$' -bench .
+
+const benchGoods = 100
+
+func BenchmarkGoodsGetAll(b *testing.B) {
+	db := postgresDB(b)
+	repo := newPostgres(db)
+	seedGoods(b, repo, benchGoods)
+	ctx := context.Background()
+
+	b.Run("acquire_prepare", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			must(b, acquirePrepareGetAllGoods(ctx, db, 10, 0))
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := repo.Goods.GetAll(ctx, 10, 0)
+			must(b, err)
+		}
+	})
+}
+
+func BenchmarkGoodsGetOne(b *testing.B) {
+	db := postgresDB(b)
+	repo := newPostgres(db)
+	projectID := seedGoods(b, repo, 0)
+	ctx := context.Background()
+	goodsID, err := repo.Goods.Create(ctx, projectID, models.Goods{Name: "goods"})
+	must(b, err)
+
+	b.Run("acquire_prepare", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			must(b, acquirePrepareGetOneGoods(ctx, db, goodsID, projectID))
+		}
+	})
+	b.Run("pool", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := repo.Goods.GetOne(ctx, goodsID, projectID)
+			must(b, err)
+		}
+	})
+}
+
+func BenchmarkProjectsGetAll(b *testing.B) {
+	db := postgresDB(b)
+	repo := newPostgres(db)
+	seedGoods(b, repo, 0)
+	ctx := context.Background()
+
+	b.Run("acquire_prepare", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			must(b, acquirePrepareGetAllProjects(ctx, db, 10, 0))
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := repo.Projects.GetAll(ctx, 10, 0)
+			must(b, err)
+		}
+	})
+}
+
+// seedGoods creates a project with n goods and returns its id
+func seedGoods(b *testing.B, repo *repository.Repository, n int) int {
+	b.Helper()
+	ctx := context.Background()
+
+	projectID, err := repo.Projects.Create(ctx, models.Project{Name: "bench"})
+	must(b, err)
+	for i := 0; i < n; i++ {
+		_, err := repo.Goods.Create(ctx, projectID, models.Goods{Name: fmt.Sprintf("goods %d", i)})
+		must(b, err)
+	}
+	return projectID
+}
+
+func acquirePrepareGetAllGoods(ctx context.Context, db *pgxpool.Pool, limit, offset int) error {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	pgxConn := conn.Conn()
+
+	query := `SELECT gp.id, gp.project_id, gp.name, COALESCE(gp.description, ''), gp.priority, gp.removed, gp.created_at FROM goods gp LIMIT $1 OFFSET $2`
+	if _, err := pgxConn.Prepare(ctx, "getAllGoods", query); err != nil {
+		return err
+	}
+	rows, err := pgxConn.Query(ctx, "getAllGoods", limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var goods []models.Goods
+	for rows.Next() {
+		var good models.Goods
+		if err := rows.Scan(&good.ID, &good.ProjectID, &good.Name, &good.Description, &good.Priority, &good.Removed, &good.CreatedAt); err != nil {
+			return err
+		}
+		goods = append(goods, good)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var total, removed int
+	countQuery := `SELECT COUNT(gp.id) FROM goods gp`
+	if _, err := pgxConn.Prepare(ctx, "countAllGoods", countQuery); err != nil {
+		return err
+	}
+	if err := pgxConn.QueryRow(ctx, "countAllGoods").Scan(&total); err != nil {
+		return err
+	}
+	removedQuery := `SELECT COUNT(gp.id) FROM goods gp WHERE gp.removed = true`
+	if _, err := pgxConn.Prepare(ctx, "countRemovedGoods", removedQuery); err != nil {
+		return err
+	}
+	if err := pgxConn.QueryRow(ctx, "countRemovedGoods").Scan(&removed); err != nil {
+		return err
+	}
+
+	_, err = json.Marshal(models.GetAllGoods{
+		Meta:  models.Meta{Total: total, Removed: removed, Limit: limit, Offset: offset},
+		Goods: goods,
+	})
+	return err
+}
+
+func acquirePrepareGetOneGoods(ctx context.Context, db *pgxpool.Pool, goodsID, projectID int) error {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	pgxConn := conn.Conn()
+
+	query := `SELECT gp.id, gp.project_id, gp.name, COALESCE(gp.description, ''), gp.priority, gp.removed, gp.created_at FROM goods gp WHERE gp.id = $1 AND gp.project_id = $2`
+	if _, err := pgxConn.Prepare(ctx, "getOneItem", query); err != nil {
+		return err
+	}
+	var goods models.Goods
+	err = pgxConn.QueryRow(ctx, "getOneItem", goodsID, projectID).Scan(&goods.ID, &goods.ProjectID, &goods.Name, &goods.Description, &goods.Priority, &goods.Removed, &goods.CreatedAt)
+	if err != nil {
+		return err
+	}
+	_, err = json.Marshal(goods)
+	return err
+}
+
+func acquirePrepareGetAllProjects(ctx context.Context, db *pgxpool.Pool, limit, offset int) error {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	pgxConn := conn.Conn()
+
+	query := `SELECT p.id, p.name, p.created_at FROM projects p LIMIT $1 OFFSET $2`
+	if _, err := pgxConn.Prepare(ctx, "getAllProjects", query); err != nil {
+		return err
+	}
+	rows, err := pgxConn.Query(ctx, "getAllProjects", limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var projects []models.Project
+	for rows.Next() {
+		var project models.Project
+		if err := rows.Scan(&project.ID, &project.Name, &project.CreatedAt); err != nil {
+			return err
+		}
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var total int
+	if _, err := pgxConn.Prepare(ctx, "countAllProjects", `SELECT COUNT(p.id) FROM projects p`); err != nil {
+		return err
+	}
+	if err := pgxConn.QueryRow(ctx, "countAllProjects").Scan(&total); err != nil {
+		return err
+	}
+
+	_, err = json.Marshal(models.GetAllProjects{
+		Meta:     models.MetaProjects{Total: total, Limit: limit, Offset: offset},
+		Projects: projects,
+	})
+	return err
+}
@@ -0,0 +1,96 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+
+	"go-service/db/migrations"
+	"go-service/internal/repository"
+	"go-service/internal/repository/repotest"
+	"go-service/pkg/migrate"
+	r "go-service/pkg/redis"
+)
+
+// postgresDSN names the database the Postgres tests and benchmarks run
+// against, they are skipped without it. Its tables are emptied on every run
+const postgresDSN = "GOSERVICE_TEST_POSTGRES_DSN"
+
+func TestPostgres(t *testing.T) {
+	if os.Getenv(postgresDSN) == "" {
+		t.Skipf("%s is not set", postgresDSN)
+	}
+	repotest.Run(t, func(t *testing.T) *repository.Repository {
+		return newPostgres(postgresDB(t))
+	})
+}
+
+// postgresDB connects to the test database, migrates it and empties the tables
+func postgresDB(tb testing.TB) *pgxpool.Pool {
+	tb.Helper()
+
+	dsn := os.Getenv(postgresDSN)
+	if dsn == "" {
+		tb.Skipf("%s is not set", postgresDSN)
+	}
+	ctx := context.Background()
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	// the mode NewPostgresDB sets
+	cfg.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+	db, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(db.Close)
+
+	migrator, err := migrate.New(migrate.Config{DB: db, FS: migrations.FS, Logger: zap.NewNop()})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := db.Exec(ctx, "TRUNCATE webhook_deliveries, webhooks, goods, projects RESTART IDENTITY CASCADE"); err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+// newPostgres returns the Postgres repositories over db without a cache, so
+// every read reaches the database
+func newPostgres(db *pgxpool.Pool) *repository.Repository {
+	cache := missCache{}
+	loader := r.NewLoader(r.LoaderConfig{Cache: cache, Logger: zap.NewNop()})
+	policies := repository.NewCachePolicies(repository.CacheConfig{})
+	return repository.New(db, cache, loader, policies, repository.Timeouts{}, zap.NewNop(), noop.NewTracerProvider().Tracer(""))
+}
+
+// missCache stores nothing
+type missCache struct{}
+
+func (missCache) Get(context.Context, string) (string, error)                { return "", redis.Nil }
+func (missCache) Set(context.Context, string, string, time.Duration) error   { return nil }
+func (missCache) Delete(context.Context, string) error                       { return nil }
+func (missCache) GetInt(context.Context, string) (int, error)                { return 0, redis.Nil }
+func (missCache) SetInt(context.Context, string, int, time.Duration) error   { return nil }
+func (missCache) Incr(context.Context, string, time.Duration) (int64, error) { return 0, nil }
+
+var _ r.Cache = missCache{}
+
+func must(tb testing.TB, err error) {
+	tb.Helper()
+	if err != nil {
+		tb.Fatal(err)
+	}
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go-service/internal/models"
+)
+
+type ProjectMemory struct {
+	store *memoryStore
+}
+
+func (r *ProjectMemory) Create(ctx context.Context, project models.Project) (int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.projectSeq++
+	project.ID = s.projectSeq
+	project.CreatedAt = time.Now()
+	s.projects[project.ID] = project
+
+	return project.ID, nil
+}
+
+func (r *ProjectMemory) Update(ctx context.Context, projectID int, input models.UpdateProject) error {
+	return r.Patch(ctx, projectID, func(models.Project) (models.UpdateProject, error) { return input, nil })
+}
+
+// Patch holds the store lock from reading the project for patch until the update is applied
+func (r *ProjectMemory) Patch(ctx context.Context, projectID int, patch func(models.Project) (models.UpdateProject, error)) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[projectID]
+	if !ok {
+		return ErrProjectNotFound
+	}
+	input, err := patch(project)
+	if err != nil {
+		return err
+	}
+	if !input.Name.Set {
+		return ErrEmptyUpdate
+	}
+	if input.Name.Null {
+		return notNullViolation(projectsTable, "name")
+	}
+
+	project.Name = input.Name.Value
+	s.projects[projectID] = project
+	return nil
+}
+
+// Delete removes the project, like the foreign keys it refuses while goods,
+// removed ones included, still reference it and takes its webhooks along
+func (r *ProjectMemory) Delete(ctx context.Context, projectID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.projects[projectID]; !ok {
+		return ErrProjectNotFound
+	}
+	for _, goods := range s.goods {
+		if goods.ProjectID == projectID {
+			return ErrProjectHasGoods
+		}
+	}
+
+	for id, webhook := range s.webhooks {
+		if webhook.ProjectID == projectID {
+			s.deleteWebhook(id)
+		}
+	}
+	delete(s.projects, projectID)
+	return nil
+}
+
+func (r *ProjectMemory) GetAll(ctx context.Context, limit, offset int) (models.GetAllProjects, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	limit, offset = normalizePage(limit, offset)
+
+	projects := make([]models.Project, 0, len(s.projects))
+	for _, project := range s.projects {
+		projects = append(projects, project)
+	}
+
+	return models.GetAllProjects{
+		Meta: models.MetaProjects{
+			Total:  len(projects),
+			Limit:  limit,
+			Offset: offset,
+		},
+		Projects: page(projects, func(p models.Project) int { return p.ID }, limit, offset),
+	}, nil
+}
+
+func (r *ProjectMemory) GetByID(ctx context.Context, projectID int) (models.Project, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	project, ok := s.projects[projectID]
+	if !ok {
+		return models.Project{}, ErrProjectNotFound
+	}
+	return project, nil
+}
@@ -4,9 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
-	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -18,191 +17,211 @@ import (
 )
 
 type ProjectPostgres struct {
-	ctx    context.Context
-	db     *pgxpool.Pool
-	cache  r.Cache
-	logger *zap.Logger
-	tracer trace.Tracer
+	db       *pgxpool.Pool
+	cache    r.Cache
+	loader   *r.Loader
+	policies *CachePolicies
+	timeouts Timeouts
+	logger   *zap.Logger
+	tracer   trace.Tracer
 }
 
-func NewProjectPostgres(ctx context.Context, db *pgxpool.Pool, cache r.Cache, logger *zap.Logger, tracer trace.Tracer) *ProjectPostgres {
+func NewProjectPostgres(db *pgxpool.Pool, cache r.Cache, loader *r.Loader, policies *CachePolicies, timeouts Timeouts, logger *zap.Logger, tracer trace.Tracer) *ProjectPostgres {
 	return &ProjectPostgres{
-		ctx:    ctx,
-		db:     db,
-		cache:  cache,
-		logger: logger,
-		tracer: tracer,
+		db:       db,
+		cache:    cache,
+		loader:   loader,
+		policies: policies,
+		timeouts: timeouts,
+		logger:   logger,
+		tracer:   tracer,
 	}
 }
 
 func (r *ProjectPostgres) Create(ctx context.Context, project models.Project) (int, error) {
 	var id int
 
-	_, span := r.tracer.Start(ctx, "CreateProject")
+	ctx, span := r.tracer.Start(ctx, "CreateProject")
 	defer span.End()
 
-	query := fmt.Sprintf(`INSERT INTO %s (name) VALUES ($1) RETURNING id`, projectsTable)
-
-	conn, err := r.db.Acquire(r.ctx)
-	if err != nil {
-		return 0, err
-	}
-	defer conn.Release()
-
-	pgxConn := conn.Conn()
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
 
-	_, err = pgxConn.Prepare(r.ctx, "createProject", query)
-	if err != nil {
-		return 0, err
-	}
+	query := fmt.Sprintf(`INSERT INTO %s (name) VALUES ($1) RETURNING id`, projectsTable)
 
 	span.AddEvent("createProject", trace.WithAttributes(attribute.String("query", query)))
-	row := pgxConn.QueryRow(r.ctx, "createProject", project.Name)
+	row := r.db.QueryRow(ctx, query, project.Name)
 	if err := row.Scan(&id); err != nil {
-		return 0, err
+		return 0, dbError(err, nil)
 	}
+
+	r.invalidateLists(ctx)
+
 	return id, nil
 }
 
-func (r *ProjectPostgres) Update(ctx context.Context, projectID int, input models.UpdateProjects) error {
-	tx, err := r.db.Begin(r.ctx)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback(r.ctx)
+func (r *ProjectPostgres) Update(ctx context.Context, projectID int, input models.UpdateProject) error {
+	return r.Patch(ctx, projectID, func(models.Project) (models.UpdateProject, error) { return input, nil })
+}
+
+// Patch updates the project with the update patch builds from the project,
+// read and locked in the transaction of the update
+func (r *ProjectPostgres) Patch(ctx context.Context, projectID int, patch func(models.Project) (models.UpdateProject, error)) error {
+	ctx, span := r.tracer.Start(ctx, "UpdateProject")
+	defer span.End()
 
-	var exists bool
-	//_, err = r.db.Prepare(r.ctx, "projectExists", fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1)", projectsTable))
-	//if err != nil {
-	//	return err
-	//}
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
 
-	err = tx.QueryRow(r.ctx, fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1)", projectsTable), projectID).Scan(&exists)
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return err
+		return dbError(err, ErrProjectNotFound)
 	}
-	if !exists {
-		return ErrNotFound
+	defer tx.Rollback(ctx)
+
+	var current models.Project
+	err = tx.QueryRow(ctx, fmt.Sprintf("SELECT p.id, p.name, p.created_at FROM %s p WHERE id = $1 FOR UPDATE", projectsTable), projectID).Scan(&current.ID, &current.Name, &current.CreatedAt)
+	if err != nil {
+		return dbError(err, ErrProjectNotFound)
 	}
 
-	_, err = tx.Exec(r.ctx, fmt.Sprintf("SELECT p.id, p.name, p.created_at FROM %s p WHERE id = $1 FOR UPDATE", projectsTable), projectID)
+	input, err := patch(current)
 	if err != nil {
 		return err
 	}
 
-	setValues := make([]string, 0)
-	args := make([]interface{}, 0)
-	argID := 1
+	update := newUpdateSet(projectsTable, "name")
+	setField(update, "name", input.Name)
+	update.Where("id", projectID)
 
-	if input.Name != nil {
-		setValues = append(setValues, fmt.Sprintf("name = $%d", argID))
-		args = append(args, *input.Name)
-		argID++
+	query, args, err := update.SQL()
+	if err != nil {
+		return err
 	}
 
-	setQuery := strings.Join(setValues, ", ")
-
-	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d`, projectsTable, setQuery, argID)
-	args = append(args, projectID)
-	_, err = tx.Exec(r.ctx, query, args...)
+	_, err = tx.Exec(ctx, query, args...)
 	if err != nil {
-		return err
+		return dbError(err, ErrProjectNotFound)
 	}
 
-	err = tx.Commit(r.ctx)
+	err = tx.Commit(ctx)
 	if err != nil {
-		return err
+		return dbError(err, ErrProjectNotFound)
 	}
 
 	key := fmt.Sprintf("project:%d", projectID)
-	err = r.cache.Delete(r.ctx, key)
+	err = r.loader.Invalidate(context.WithoutCancel(ctx), key)
 	if err != nil {
 		r.logger.Error("Failed to invalidate cache for key %s: %v", zap.String("key", key), zap.Error(err))
 	}
 
+	r.invalidateLists(ctx)
+
 	return nil
 }
 
 func (r *ProjectPostgres) Delete(ctx context.Context, projectID int) error {
-	_, span := r.tracer.Start(ctx, "DeleteProject")
+	ctx, span := r.tracer.Start(ctx, "DeleteProject")
 	defer span.End()
 
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
 	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, projectsTable)
 	span.AddEvent("delete project", trace.WithAttributes(attribute.String("query", query)))
-	res, err := r.db.Exec(r.ctx, query, projectID)
+	res, err := r.db.Exec(ctx, query, projectID)
 	if err != nil {
 		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
 		span.SetStatus(codes.Error, err.Error())
-		return err
+		if isForeignKeyViolation(err) {
+			return ErrProjectHasGoods.Wrap(err)
+		}
+		return dbError(err, ErrProjectNotFound)
 	}
 
 	rowsAffected := res.RowsAffected()
 	if rowsAffected == 0 {
-		return ErrNotFound
+		return ErrProjectNotFound
 	}
 
 	span.AddEvent("invalidate project in cache", trace.WithAttributes(attribute.String("key", fmt.Sprintf("project:%d", projectID))))
 	key := fmt.Sprintf("project:%d", projectID)
-	err = r.cache.Delete(r.ctx, key)
+	err = r.loader.Invalidate(context.WithoutCancel(ctx), key)
 	if err != nil {
 		span.RecordError(err, trace.WithAttributes(attribute.String("Invalidate error", err.Error())))
 		span.SetStatus(codes.Error, err.Error())
 		r.logger.Error("Failed to invalidate cache for key %s: %v", zap.String("key", key), zap.Error(err))
 	}
 
+	r.invalidateLists(ctx)
+
 	return nil
 }
-func (r *ProjectPostgres) GetAll(сtx context.Context, limit, offset int) (models.GetAllProjects, error) {
-	var projects []models.Project
+func (r *ProjectPostgres) GetAll(ctx context.Context, limit, offset int) (models.GetAllProjects, error) {
+	var response models.GetAllProjects
 
-	_, span := r.tracer.Start(сtx, "GetAllProjects")
+	ctx, span := r.tracer.Start(ctx, "GetAllProjects")
 	defer span.End()
 
-	query := fmt.Sprintf(`SELECT p.id, p.name, p.created_at FROM %s p LIMIT $1 OFFSET $2`, projectsTable)
-
-	span.AddEvent("get all projects", trace.WithAttributes(attribute.String("query", query)))
-	conn, err := r.db.Acquire(r.ctx)
-	if err != nil {
-		return models.GetAllProjects{}, err
-	}
-	defer conn.Release()
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
 
-	pgxConn := conn.Conn()
+	limit, offset = normalizePage(limit, offset)
 
-	_, err = pgxConn.Prepare(r.ctx, "getAllProjects", query)
+	key, err := projectsListKey(ctx, r.cache, limit, offset)
 	if err != nil {
-		return models.GetAllProjects{}, err
+		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
+		span.SetStatus(codes.Error, err.Error())
+		return response, dbError(err, nil)
 	}
 
-	rows, err := pgxConn.Query(r.ctx, "getAllProjects", limit, offset)
+	span.AddEvent("redis get", trace.WithAttributes(attribute.String("key", key)))
+	cachedProjects, status, err := r.loader.Get(ctx, key, r.policies.Load().Lists, func(ctx context.Context) (string, error) {
+		return r.loadAll(ctx, limit, offset)
+	})
 	if err != nil {
-		return models.GetAllProjects{}, err
+		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
+		span.SetStatus(codes.Error, err.Error())
+		return response, dbError(err, nil)
 	}
-	defer rows.Close()
+	span.AddEvent("cache lookup", trace.WithAttributes(attribute.Int("status", int(status))))
 
-	for rows.Next() {
-		var project models.Project
-		if err := rows.Scan(&project.ID, &project.Name, &project.CreatedAt); err != nil {
-			return models.GetAllProjects{}, err
-		}
-		projects = append(projects, project)
+	if err := json.Unmarshal([]byte(cachedProjects), &response); err != nil {
+		r.logger.Error("Failed to unmarshal cached projects list: %v", zap.Error(err))
+		return response, dbError(err, nil)
 	}
 
-	if err := rows.Err(); err != nil {
-		return models.GetAllProjects{}, err
-	}
+	return response, nil
+}
 
-	var total int
-	_, err = pgxConn.Prepare(r.ctx, "countAllProjects", fmt.Sprintf(`SELECT COUNT(p.id) FROM %s p`, projectsTable))
-	if err != nil {
-		return models.GetAllProjects{}, err
-	}
+// loadAll reads one page of projects with its meta from the database and encodes it for the cache
+func (r *ProjectPostgres) loadAll(ctx context.Context, limit, offset int) (string, error) {
+	var projects []models.Project
 
-	err = pgxConn.QueryRow(r.ctx, "countAllProjects").Scan(&total)
-	if err != nil {
-		return models.GetAllProjects{}, err
+	query := fmt.Sprintf(`SELECT p.id, p.name, p.created_at FROM %s p LIMIT $1 OFFSET $2`, projectsTable)
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(p.id) FROM %s p`, projectsTable)
+
+	// the page and the count go out in one round trip
+	var total int
+	batch := &pgx.Batch{}
+	batch.Queue(query, limit, offset).Query(func(rows pgx.Rows) error {
+		for rows.Next() {
+			var project models.Project
+			if err := rows.Scan(&project.ID, &project.Name, &project.CreatedAt); err != nil {
+				return err
+			}
+			projects = append(projects, project)
+		}
+		return rows.Err()
+	})
+	batch.Queue(countQuery).QueryRow(func(row pgx.Row) error {
+		return row.Scan(&total)
+	})
+
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		return "", err
 	}
-	span.AddEvent("count all projects", trace.WithAttributes(attribute.Int("total", total)))
 
 	meta := models.MetaProjects{
 		Total:  total,
@@ -215,62 +234,67 @@ func (r *ProjectPostgres) GetAll(сtx context.Context, limit, offset int) (model
 		Projects: projects,
 	}
 
-	return response, nil
+	projectsJson, err := json.Marshal(response)
+	if err != nil {
+		r.logger.Error("Failed to marshal projects list: %v", zap.Error(err))
+		return "", err
+	}
+
+	return string(projectsJson), nil
 }
+
 func (r *ProjectPostgres) GetByID(ctx context.Context, projectID int) (models.Project, error) {
 	var project models.Project
 
-	_, span := r.tracer.Start(ctx, "GetByID")
+	ctx, span := r.tracer.Start(ctx, "GetByID")
 	defer span.End()
 
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
 	span.AddEvent("redis get", trace.WithAttributes(attribute.String("key", fmt.Sprintf("project:%d", projectID))))
 	key := fmt.Sprintf("project:%d", projectID)
-	cachedProject, err := r.cache.Get(r.ctx, key)
-	if err == nil {
-		err := json.Unmarshal([]byte(cachedProject), &project)
-		if err != nil {
-			r.logger.Error("Failed to unmarshal cached project: %v", zap.Error(err))
-		} else {
-			return project, nil
-		}
+	cachedProject, status, err := r.loader.Get(ctx, key, r.policies.Load().Projects, func(ctx context.Context) (string, error) {
+		return r.loadByID(ctx, projectID)
+	})
+	if err != nil {
+		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
+		span.SetStatus(codes.Error, err.Error())
+		return project, dbError(err, ErrProjectNotFound)
 	}
+	span.AddEvent("cache lookup", trace.WithAttributes(attribute.Int("status", int(status))))
 
-	query := fmt.Sprintf(`SELECT p.id, p.name, p.created_at FROM %s p WHERE p.id = $1`, projectsTable)
-
-	conn, err := r.db.Acquire(r.ctx)
-	if err != nil {
-		return project, err
+	if err := json.Unmarshal([]byte(cachedProject), &project); err != nil {
+		r.logger.Error("Failed to unmarshal cached project: %v", zap.Error(err))
+		return project, dbError(err, ErrProjectNotFound)
 	}
-	defer conn.Release()
 
-	pgxConn := conn.Conn()
+	return project, nil
+}
+
+// loadByID reads a project from the database and encodes it for the cache
+func (r *ProjectPostgres) loadByID(ctx context.Context, projectID int) (string, error) {
+	var project models.Project
 
-	_, err = pgxConn.Prepare(r.ctx, "getProjectByID", query)
-	if err != nil {
-		return project, err
-	}
+	query := fmt.Sprintf(`SELECT p.id, p.name, p.created_at FROM %s p WHERE p.id = $1`, projectsTable)
 
-	row := pgxConn.QueryRow(r.ctx, "getProjectByID", projectID)
+	row := r.db.QueryRow(ctx, query, projectID)
 	if err := row.Scan(&project.ID, &project.Name, &project.CreatedAt); err != nil {
-		return project, err
+		return "", err
 	}
 
-	span.AddEvent("Write In JSON")
 	projectJson, err := json.Marshal(project)
 	if err != nil {
-		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
-		span.SetStatus(codes.Error, err.Error())
 		r.logger.Error("Failed to marshal project: %v", zap.Error(err))
-		return project, err
+		return "", err
 	}
 
-	span.AddEvent("redis set", trace.WithAttributes(attribute.String("key", fmt.Sprintf("project:%d", projectID))))
-	err = r.cache.Set(r.ctx, key, string(projectJson), 1*time.Minute)
-	if err != nil {
-		span.RecordError(err, trace.WithAttributes(attribute.String("error", err.Error())))
-		span.SetStatus(codes.Error, err.Error())
-		r.logger.Error("Failed to cache project: %v", zap.Error(err))
-	}
+	return string(projectJson), nil
+}
 
-	return project, nil
+// invalidateLists drops every cached page of projects
+func (r *ProjectPostgres) invalidateLists(ctx context.Context) {
+	if err := invalidateProjectsLists(context.WithoutCancel(ctx), r.cache); err != nil {
+		r.logger.Error("Failed to invalidate projects lists: %v", zap.Error(err))
+	}
 }
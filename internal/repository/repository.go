@@ -2,9 +2,10 @@ package repository
 
 import (
 	"context"
+	"sync/atomic"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/nats-io/nats.go"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 
@@ -14,7 +15,10 @@ import (
 
 type Projects interface {
 	Create(ctx context.Context, input models.Project) (int, error)
-	Update(ctx context.Context, projectID int, input models.UpdateProjects) error
+	Update(ctx context.Context, projectID int, input models.UpdateProject) error
+	// Patch applies the update patch builds from the project, which is locked
+	// until the update is stored
+	Patch(ctx context.Context, projectID int, patch func(models.Project) (models.UpdateProject, error)) error
 	Delete(ctx context.Context, projectID int) error
 	GetAll(ctx context.Context, limit, offset int) (models.GetAllProjects, error)
 	GetByID(ctx context.Context, projectID int) (models.Project, error)
@@ -23,20 +27,74 @@ type Projects interface {
 type Goods interface {
 	Create(ctx context.Context, projectID int, goods models.Goods) (int, error)
 	Update(ctx context.Context, goodsID, projectID int, input models.UpdateGoods) error
+	// Patch applies the update patch builds from the goods, which are locked
+	// until the update is stored
+	Patch(ctx context.Context, goodsID, projectID int, patch func(models.Goods) (models.UpdateGoods, error)) error
 	Delete(ctx context.Context, goodsID, projectID int) error
 	GetAll(ctx context.Context, limit, offset int) (models.GetAllGoods, error)
 	GetOne(ctx context.Context, goodsID, projectID int) (models.Goods, error)
 	Reprioritize(ctx context.Context, goodsID, projectID int, priority int) error
 }
 
+type Webhooks interface {
+	Create(ctx context.Context, projectID int, webhook models.Webhook) (int, error)
+	Update(ctx context.Context, webhookID, projectID int, input models.UpdateWebhook) error
+	// Patch applies the update patch builds from the webhook, which is locked
+	// until the update is stored
+	Patch(ctx context.Context, webhookID, projectID int, patch func(models.Webhook) (models.UpdateWebhook, error)) error
+	Delete(ctx context.Context, webhookID, projectID int) error
+	GetAll(ctx context.Context, projectID int) (models.GetAllWebhooks, error)
+	GetOne(ctx context.Context, webhookID, projectID int) (models.Webhook, error)
+	// Enqueue adds a pending delivery for every active webhook of the project
+	// that accepts eventType and returns how many were added
+	Enqueue(ctx context.Context, projectID int, eventType string, payload []byte) (int, error)
+	GetDeliveries(ctx context.Context, webhookID, projectID, limit, offset int) (models.GetAllDeliveries, error)
+	// Redeliver makes a delivery pending again with a fresh attempt budget
+	Redeliver(ctx context.Context, deliveryID, webhookID, projectID int) error
+	// ClaimDue takes up to limit pending deliveries whose time has come and
+	// hides them from other claimers for lease, so a crashed worker only
+	// delays them
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.DueDelivery, error)
+	RecordAttempt(ctx context.Context, deliveryID int, attempt models.DeliveryAttempt) error
+}
+
 type Repository struct {
 	Projects
 	Goods
+	Webhooks Webhooks
+}
+
+// CacheConfig holds cache policies per entity
+type CacheConfig struct {
+	Goods    r.Policy
+	Projects r.Policy
+	Lists    r.Policy
+}
+
+// CachePolicies holds the current CacheConfig, it is swapped when the
+// configuration is reloaded
+type CachePolicies struct {
+	current atomic.Pointer[CacheConfig]
+}
+
+func NewCachePolicies(config CacheConfig) *CachePolicies {
+	p := &CachePolicies{}
+	p.Store(config)
+	return p
+}
+
+func (p *CachePolicies) Load() CacheConfig {
+	return *p.current.Load()
+}
+
+func (p *CachePolicies) Store(config CacheConfig) {
+	p.current.Store(&config)
 }
 
-func New(ctx context.Context, db *pgxpool.Pool, cache r.Cache, logger *zap.Logger, nats *nats.Conn, tracer trace.Tracer) *Repository {
+func New(db *pgxpool.Pool, cache r.Cache, loader *r.Loader, policies *CachePolicies, timeouts Timeouts, logger *zap.Logger, tracer trace.Tracer) *Repository {
 	return &Repository{
-		Goods:    NewGoodsPostgres(ctx, db, cache, logger, nats, tracer),
-		Projects: NewProjectPostgres(ctx, db, cache, logger, tracer),
+		Goods:    NewGoodsPostgres(db, cache, loader, policies, timeouts, logger, tracer),
+		Projects: NewProjectPostgres(db, cache, loader, policies, timeouts, logger, tracer),
+		Webhooks: NewWebhooksPostgres(db, timeouts, tracer),
 	}
 }
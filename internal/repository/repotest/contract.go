@@ -0,0 +1,466 @@
+// Package repotest holds the behaviour every repository backend must share.
+// A backend's tests call Run with a constructor over empty storage:
+//
+//	func TestMemory(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) *repository.Repository {
+//			return repository.NewMemory()
+//		})
+//	}
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-service/internal/models"
+	"go-service/internal/repository"
+)
+
+// NewRepository returns a repository over empty storage, Postgres backends
+// truncate the tables and flush the cache before returning
+type NewRepository func(t *testing.T) *repository.Repository
+
+// Run checks the Postgres semantics the API relies on: soft delete, priority
+// shifting, not found errors, list meta counts and the webhook delivery queue
+func Run(t *testing.T, newRepository NewRepository) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo *repository.Repository)
+	}{
+		{"projects crud", projectsCRUD},
+		{"projects pagination", projectsPagination},
+		{"project with goods can't be deleted", projectWithGoods},
+		{"goods need a project", goodsNeedProject},
+		{"goods crud", goodsCRUD},
+		{"goods soft delete", goodsSoftDelete},
+		{"goods priority on create", goodsPriorityOnCreate},
+		{"goods reprioritize shifts others", goodsReprioritize},
+		{"goods update priority shifts others", goodsUpdatePriority},
+		{"goods belong to their project", goodsScopedByProject},
+		{"goods pagination", goodsPagination},
+		{"empty updates", emptyUpdates},
+		{"patches see the stored row", patches},
+		{"webhooks crud", webhooksCRUD},
+		{"webhook deliveries", webhookDeliveries},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepository(t))
+		})
+	}
+}
+
+func projectsCRUD(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	id := createProject(t, repo, "first")
+	project, err := repo.Projects.GetByID(ctx, id)
+	must(t, err)
+	if project.ID != id || project.Name != "first" || project.CreatedAt.IsZero() {
+		t.Fatalf("GetByID = %+v, want id %d named first with created_at", project, id)
+	}
+
+	must(t, repo.Projects.Update(ctx, id, models.UpdateProject{Name: models.Some("renamed")}))
+	project, err = repo.Projects.GetByID(ctx, id)
+	must(t, err)
+	if project.Name != "renamed" {
+		t.Fatalf("name after update = %q, want renamed", project.Name)
+	}
+
+	must(t, repo.Projects.Delete(ctx, id))
+	_, err = repo.Projects.GetByID(ctx, id)
+	wantErr(t, err, repository.ErrProjectNotFound)
+
+	wantErr(t, repo.Projects.Delete(ctx, id), repository.ErrProjectNotFound)
+	wantErr(t, repo.Projects.Update(ctx, id, models.UpdateProject{Name: models.Some("x")}), repository.ErrProjectNotFound)
+}
+
+func projectsPagination(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	var ids []int
+	for _, name := range []string{"a", "b", "c"} {
+		ids = append(ids, createProject(t, repo, name))
+	}
+
+	list, err := repo.Projects.GetAll(ctx, 2, 1)
+	must(t, err)
+	if list.Meta != (models.MetaProjects{Total: 3, Limit: 2, Offset: 1}) {
+		t.Fatalf("meta = %+v, want total 3 limit 2 offset 1", list.Meta)
+	}
+	if len(list.Projects) != 2 || list.Projects[0].ID != ids[1] || list.Projects[1].ID != ids[2] {
+		t.Fatalf("page = %+v, want projects %v", list.Projects, ids[1:])
+	}
+
+	list, err = repo.Projects.GetAll(ctx, 0, 0)
+	must(t, err)
+	if list.Meta.Limit != 10 || len(list.Projects) != 3 {
+		t.Fatalf("default page = %+v, want limit 10 with 3 projects", list)
+	}
+
+	list, err = repo.Projects.GetAll(ctx, 10, 5)
+	must(t, err)
+	if len(list.Projects) != 0 || list.Meta.Total != 3 {
+		t.Fatalf("page past the end = %+v, want no projects and total 3", list)
+	}
+}
+
+func projectWithGoods(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	projectID := createProject(t, repo, "project")
+	goodsID := createGoods(t, repo, projectID, "goods")
+	must(t, repo.Goods.Delete(ctx, goodsID, projectID))
+
+	// removed goods still reference the project
+	wantErr(t, repo.Projects.Delete(ctx, projectID), repository.ErrProjectHasGoods)
+}
+
+func goodsNeedProject(t *testing.T, repo *repository.Repository) {
+	_, err := repo.Goods.Create(context.Background(), 404, models.Goods{Name: "orphan"})
+	wantErr(t, err, repository.ErrProjectNotFound)
+}
+
+func goodsCRUD(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	projectID := createProject(t, repo, "project")
+	id := createGoods(t, repo, projectID, "goods")
+
+	goods, err := repo.Goods.GetOne(ctx, id, projectID)
+	must(t, err)
+	if goods.ID != id || goods.ProjectID != projectID || goods.Name != "goods" || goods.Removed || goods.CreatedAt.IsZero() {
+		t.Fatalf("GetOne = %+v, want goods %d of project %d", goods, id, projectID)
+	}
+
+	must(t, repo.Goods.Update(ctx, id, projectID, models.UpdateGoods{
+		Name:        models.Some("renamed"),
+		Description: models.Some("described"),
+	}))
+	goods, err = repo.Goods.GetOne(ctx, id, projectID)
+	must(t, err)
+	if goods.Name != "renamed" || goods.Description != "described" {
+		t.Fatalf("after update = %+v, want renamed and described", goods)
+	}
+
+	must(t, repo.Goods.Update(ctx, id, projectID, models.UpdateGoods{Description: models.Null[string]()}))
+	goods, err = repo.Goods.GetOne(ctx, id, projectID)
+	must(t, err)
+	if goods.Description != "" || goods.Name != "renamed" {
+		t.Fatalf("after clearing description = %+v, want empty description and the name kept", goods)
+	}
+
+	_, err = repo.Goods.GetOne(ctx, id+1000, projectID)
+	wantErr(t, err, repository.ErrGoodsNotFound)
+	wantErr(t, repo.Goods.Delete(ctx, id+1000, projectID), repository.ErrGoodsNotFound)
+	wantErr(t, repo.Goods.Reprioritize(ctx, id+1000, projectID, 1), repository.ErrGoodsNotFound)
+	wantErr(t, repo.Goods.Update(ctx, id+1000, projectID, models.UpdateGoods{Name: models.Some("x")}), repository.ErrGoodsNotFound)
+}
+
+func goodsSoftDelete(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	projectID := createProject(t, repo, "project")
+	kept := createGoods(t, repo, projectID, "kept")
+	removed := createGoods(t, repo, projectID, "removed")
+
+	must(t, repo.Goods.Delete(ctx, removed, projectID))
+	// deleting twice is not an error, the row is still there
+	must(t, repo.Goods.Delete(ctx, removed, projectID))
+
+	goods, err := repo.Goods.GetOne(ctx, removed, projectID)
+	must(t, err)
+	if !goods.Removed {
+		t.Fatalf("deleted goods = %+v, want removed", goods)
+	}
+
+	list, err := repo.Goods.GetAll(ctx, 10, 0)
+	must(t, err)
+	if list.Meta.Total != 2 || list.Meta.Removed != 1 || len(list.Goods) != 2 {
+		t.Fatalf("list = %+v, want both goods with one removed", list)
+	}
+
+	must(t, repo.Goods.Update(ctx, removed, projectID, models.UpdateGoods{Removed: models.Some(false)}))
+	list, err = repo.Goods.GetAll(ctx, 10, 0)
+	must(t, err)
+	if list.Meta.Removed != 0 {
+		t.Fatalf("removed after restore = %d, want 0", list.Meta.Removed)
+	}
+
+	goods, err = repo.Goods.GetOne(ctx, kept, projectID)
+	must(t, err)
+	if goods.Removed {
+		t.Fatalf("untouched goods = %+v, want not removed", goods)
+	}
+}
+
+func goodsPriorityOnCreate(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	first := createProject(t, repo, "first")
+	second := createProject(t, repo, "second")
+
+	a := createGoods(t, repo, first, "a")
+	// the requested priority is ignored, new goods go last across all projects
+	b, err := repo.Goods.Create(ctx, second, models.Goods{Name: "b", Priority: 100})
+	must(t, err)
+
+	wantPriority(t, repo, a, first, 1)
+	wantPriority(t, repo, b, second, 2)
+}
+
+func goodsReprioritize(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	projectID := createProject(t, repo, "project")
+	other := createProject(t, repo, "other")
+	a := createGoods(t, repo, projectID, "a")
+	b := createGoods(t, repo, projectID, "b")
+	c := createGoods(t, repo, projectID, "c")
+	d := createGoods(t, repo, other, "d")
+
+	must(t, repo.Goods.Reprioritize(ctx, c, projectID, 1))
+
+	// the shift runs after the new priority is set and moves the goods itself too
+	wantPriority(t, repo, c, projectID, 2)
+	wantPriority(t, repo, a, projectID, 2)
+	wantPriority(t, repo, b, projectID, 3)
+	// goods of other projects keep their place
+	wantPriority(t, repo, d, other, 4)
+}
+
+func goodsUpdatePriority(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	projectID := createProject(t, repo, "project")
+	a := createGoods(t, repo, projectID, "a")
+	b := createGoods(t, repo, projectID, "b")
+	c := createGoods(t, repo, projectID, "c")
+
+	must(t, repo.Goods.Update(ctx, c, projectID, models.UpdateGoods{Priority: models.Some(2)}))
+
+	// shifted like Reprioritize
+	wantPriority(t, repo, a, projectID, 1)
+	wantPriority(t, repo, c, projectID, 3)
+	wantPriority(t, repo, b, projectID, 3)
+}
+
+func goodsScopedByProject(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	projectID := createProject(t, repo, "project")
+	other := createProject(t, repo, "other")
+	id := createGoods(t, repo, projectID, "goods")
+	createGoods(t, repo, other, "elsewhere")
+
+	_, err := repo.Goods.GetOne(ctx, id, other)
+	wantErr(t, err, repository.ErrGoodsNotFound)
+	wantErr(t, repo.Goods.Delete(ctx, id, other), repository.ErrGoodsNotFound)
+}
+
+func goodsPagination(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	projectID := createProject(t, repo, "project")
+	var ids []int
+	for _, name := range []string{"a", "b", "c", "d"} {
+		ids = append(ids, createGoods(t, repo, projectID, name))
+	}
+	must(t, repo.Goods.Delete(ctx, ids[0], projectID))
+
+	list, err := repo.Goods.GetAll(ctx, 2, 2)
+	must(t, err)
+	if list.Meta != (models.Meta{Total: 4, Removed: 1, Limit: 2, Offset: 2}) {
+		t.Fatalf("meta = %+v, want total 4 removed 1 limit 2 offset 2", list.Meta)
+	}
+	if len(list.Goods) != 2 || list.Goods[0].ID != ids[2] || list.Goods[1].ID != ids[3] {
+		t.Fatalf("page = %+v, want goods %v", list.Goods, ids[2:])
+	}
+
+	list, err = repo.Goods.GetAll(ctx, 5000, -1)
+	must(t, err)
+	if list.Meta.Limit != 1000 || list.Meta.Offset != 0 {
+		t.Fatalf("meta = %+v, want the limit capped at 1000 and offset 0", list.Meta)
+	}
+}
+
+func emptyUpdates(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	projectID := createProject(t, repo, "project")
+	id := createGoods(t, repo, projectID, "goods")
+
+	wantErr(t, repo.Projects.Update(ctx, projectID, models.UpdateProject{}), repository.ErrEmptyUpdate)
+	wantErr(t, repo.Goods.Update(ctx, id, projectID, models.UpdateGoods{}), repository.ErrEmptyUpdate)
+}
+
+func patches(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	projectID := createProject(t, repo, "project")
+	id := createGoods(t, repo, projectID, "goods")
+	must(t, repo.Goods.Update(ctx, id, projectID, models.UpdateGoods{Description: models.Some("stored")}))
+
+	var seen models.Goods
+	must(t, repo.Goods.Patch(ctx, id, projectID, func(current models.Goods) (models.UpdateGoods, error) {
+		seen = current
+		return models.UpdateGoods{Name: models.Some(current.Name + " patched")}, nil
+	}))
+	if seen.ID != id || seen.Description != "stored" {
+		t.Fatalf("patch saw %+v, want the stored goods", seen)
+	}
+	goods, err := repo.Goods.GetOne(ctx, id, projectID)
+	must(t, err)
+	if goods.Name != "goods patched" {
+		t.Fatalf("after patch = %+v, want the name patched", goods)
+	}
+
+	// an error of the patch aborts the update and comes back as is
+	rejected := errors.New("rejected")
+	wantErr(t, repo.Projects.Patch(ctx, projectID, func(models.Project) (models.UpdateProject, error) {
+		return models.UpdateProject{Name: models.Some("ignored")}, rejected
+	}), rejected)
+	project, err := repo.Projects.GetByID(ctx, projectID)
+	must(t, err)
+	if project.Name != "project" {
+		t.Fatalf("after rejected patch = %+v, want the name kept", project)
+	}
+
+	wantErr(t, repo.Goods.Patch(ctx, id+1000, projectID, func(models.Goods) (models.UpdateGoods, error) {
+		t.Fatal("patch called for missing goods")
+		return models.UpdateGoods{}, nil
+	}), repository.ErrGoodsNotFound)
+}
+
+func webhooksCRUD(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	_, err := repo.Webhooks.Create(ctx, 1<<30, models.Webhook{URL: "http://example.com", Secret: "secret"})
+	wantErr(t, err, repository.ErrProjectNotFound)
+
+	projectID := createProject(t, repo, "project")
+	id, err := repo.Webhooks.Create(ctx, projectID, models.Webhook{URL: "http://example.com", Secret: "secret", Active: true})
+	must(t, err)
+
+	webhook, err := repo.Webhooks.GetOne(ctx, id, projectID)
+	must(t, err)
+	if webhook.URL != "http://example.com" || webhook.Secret != "secret" || webhook.EventTypes == nil || !webhook.Active {
+		t.Fatalf("GetOne = %+v, want the stored webhook with an empty filter", webhook)
+	}
+
+	must(t, repo.Webhooks.Update(ctx, id, projectID, models.UpdateWebhook{EventTypes: models.Some([]string{models.GoodsCreated})}))
+	webhook, err = repo.Webhooks.GetOne(ctx, id, projectID)
+	must(t, err)
+	if len(webhook.EventTypes) != 1 || webhook.EventTypes[0] != models.GoodsCreated {
+		t.Fatalf("event types = %v, want [%s]", webhook.EventTypes, models.GoodsCreated)
+	}
+	wantErr(t, repo.Webhooks.Update(ctx, id, projectID, models.UpdateWebhook{}), repository.ErrEmptyUpdate)
+	wantErr(t, repo.Webhooks.Update(ctx, id, projectID+1, models.UpdateWebhook{Active: models.Some(false)}), repository.ErrWebhookNotFound)
+
+	all, err := repo.Webhooks.GetAll(ctx, projectID)
+	must(t, err)
+	if len(all.Webhooks) != 1 {
+		t.Fatalf("GetAll returned %d webhooks, want 1", len(all.Webhooks))
+	}
+
+	must(t, repo.Webhooks.Delete(ctx, id, projectID))
+	_, err = repo.Webhooks.GetOne(ctx, id, projectID)
+	wantErr(t, err, repository.ErrWebhookNotFound)
+	wantErr(t, repo.Webhooks.Delete(ctx, id, projectID), repository.ErrWebhookNotFound)
+}
+
+func webhookDeliveries(t *testing.T, repo *repository.Repository) {
+	ctx := context.Background()
+
+	projectID := createProject(t, repo, "project")
+	id, err := repo.Webhooks.Create(ctx, projectID, models.Webhook{
+		URL:        "http://example.com",
+		EventTypes: []string{models.GoodsCreated},
+		Secret:     "secret",
+		Active:     true,
+	})
+	must(t, err)
+
+	n, err := repo.Webhooks.Enqueue(ctx, projectID, models.GoodsRemoved, []byte(`{}`))
+	must(t, err)
+	if n != 0 {
+		t.Fatalf("Enqueue of a filtered event = %d, want 0", n)
+	}
+	n, err = repo.Webhooks.Enqueue(ctx, projectID, models.GoodsCreated, []byte(`{"goods_id":1}`))
+	must(t, err)
+	if n != 1 {
+		t.Fatalf("Enqueue = %d, want 1", n)
+	}
+
+	due, err := repo.Webhooks.ClaimDue(ctx, 10, time.Minute)
+	must(t, err)
+	if len(due) != 1 || due[0].URL != "http://example.com" || due[0].Secret != "secret" {
+		t.Fatalf("ClaimDue = %+v, want the delivery with its webhook", due)
+	}
+	// claimed deliveries are leased
+	again, err := repo.Webhooks.ClaimDue(ctx, 10, time.Minute)
+	must(t, err)
+	if len(again) != 0 {
+		t.Fatalf("ClaimDue of a leased delivery = %+v, want none", again)
+	}
+
+	must(t, repo.Webhooks.RecordAttempt(ctx, due[0].ID, models.DeliveryAttempt{
+		Status:         models.DeliveryDead,
+		NextAttemptAt:  time.Now(),
+		ResponseStatus: 500,
+		Error:          "boom",
+	}))
+	log, err := repo.Webhooks.GetDeliveries(ctx, id, projectID, 10, 0)
+	must(t, err)
+	if log.Meta.Total != 1 || log.Deliveries[0].Status != models.DeliveryDead || log.Deliveries[0].Attempts != 1 || log.Deliveries[0].LastError != "boom" {
+		t.Fatalf("GetDeliveries = %+v, want one dead delivery", log)
+	}
+
+	must(t, repo.Webhooks.Redeliver(ctx, due[0].ID, id, projectID))
+	due, err = repo.Webhooks.ClaimDue(ctx, 10, time.Minute)
+	must(t, err)
+	if len(due) != 1 || due[0].Attempts != 0 {
+		t.Fatalf("ClaimDue after Redeliver = %+v, want the delivery with no attempts", due)
+	}
+	wantErr(t, repo.Webhooks.Redeliver(ctx, due[0].ID, id, projectID+1), repository.ErrDeliveryNotFound)
+}
+
+func createProject(t *testing.T, repo *repository.Repository, name string) int {
+	t.Helper()
+	id, err := repo.Projects.Create(context.Background(), models.Project{Name: name})
+	must(t, err)
+	return id
+}
+
+func createGoods(t *testing.T, repo *repository.Repository, projectID int, name string) int {
+	t.Helper()
+	id, err := repo.Goods.Create(context.Background(), projectID, models.Goods{Name: name})
+	must(t, err)
+	return id
+}
+
+func wantPriority(t *testing.T, repo *repository.Repository, goodsID, projectID, priority int) {
+	t.Helper()
+	goods, err := repo.Goods.GetOne(context.Background(), goodsID, projectID)
+	must(t, err)
+	if goods.Priority != priority {
+		t.Errorf("priority of goods %d = %d, want %d", goodsID, goods.Priority, priority)
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func wantErr(t *testing.T, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("err = %v, want %v", err, target)
+	}
+}
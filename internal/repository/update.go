@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"go-service/internal/models"
+)
+
+// updateSet builds an UPDATE statement from a whitelist of columns. Column
+// names never come from the request and every value is a bind parameter, so
+// a patch document can't reach the SQL text
+type updateSet struct {
+	table   string
+	allowed map[string]struct{}
+	sets    []string
+	where   []string
+	args    []any
+	err     error
+}
+
+func newUpdateSet(table string, columns ...string) *updateSet {
+	allowed := make(map[string]struct{}, len(columns))
+	for _, column := range columns {
+		allowed[column] = struct{}{}
+	}
+	return &updateSet{table: table, allowed: allowed}
+}
+
+// Set assigns value to column, a nil value stores NULL
+func (u *updateSet) Set(column string, value any) {
+	if _, ok := u.allowed[column]; !ok {
+		u.err = fmt.Errorf("update %s: column %q is not updatable", u.table, column)
+		return
+	}
+	u.args = append(u.args, value)
+	u.sets = append(u.sets, fmt.Sprintf("%s = $%d", pgx.Identifier{column}.Sanitize(), len(u.args)))
+}
+
+// Where adds an equality condition, conditions are joined with AND
+func (u *updateSet) Where(column string, value any) {
+	u.args = append(u.args, value)
+	u.where = append(u.where, fmt.Sprintf("%s = $%d", pgx.Identifier{column}.Sanitize(), len(u.args)))
+}
+
+func (u *updateSet) Empty() bool {
+	return len(u.sets) == 0
+}
+
+// SQL returns the statement and its arguments
+func (u *updateSet) SQL() (string, []any, error) {
+	if u.err != nil {
+		return "", nil, u.err
+	}
+	if u.Empty() {
+		return "", nil, ErrEmptyUpdate
+	}
+	if len(u.where) == 0 {
+		return "", nil, fmt.Errorf("update %s: refusing to update without a condition", u.table)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+		pgx.Identifier{u.table}.Sanitize(),
+		strings.Join(u.sets, ", "),
+		strings.Join(u.where, " AND "),
+	)
+	return query, u.args, nil
+}
+
+// setField adds a merge patch member to u, absent members are skipped
+func setField[T any](u *updateSet, column string, f models.Field[T]) {
+	switch {
+	case !f.Set:
+	case f.Null:
+		u.Set(column, nil)
+	default:
+		u.Set(column, f.Value)
+	}
+}
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go-service/internal/models"
+)
+
+type WebhooksMemory struct {
+	store *memoryStore
+}
+
+func (r *WebhooksMemory) Create(ctx context.Context, projectID int, webhook models.Webhook) (int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.projects[projectID]; !ok {
+		return 0, ErrProjectNotFound
+	}
+
+	s.webhookSeq++
+	webhook.ID = s.webhookSeq
+	webhook.ProjectID = projectID
+	webhook.EventTypes = eventTypes(webhook.EventTypes)
+	webhook.CreatedAt = time.Now()
+	s.webhooks[webhook.ID] = webhook
+
+	return webhook.ID, nil
+}
+
+func (r *WebhooksMemory) Update(ctx context.Context, webhookID, projectID int, input models.UpdateWebhook) error {
+	return r.Patch(ctx, webhookID, projectID, func(models.Webhook) (models.UpdateWebhook, error) { return input, nil })
+}
+
+// Patch holds the store lock from reading the webhook for patch until the update is applied
+func (r *WebhooksMemory) Patch(ctx context.Context, webhookID, projectID int, patch func(models.Webhook) (models.UpdateWebhook, error)) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook, ok := s.findWebhook(webhookID, projectID)
+	if !ok {
+		return ErrWebhookNotFound
+	}
+	input, err := patch(webhook)
+	if err != nil {
+		return err
+	}
+	if !input.URL.Set && !input.EventTypes.Set && !input.Active.Set {
+		return ErrEmptyUpdate
+	}
+	if input.URL.Null {
+		return notNullViolation(webhooksTable, "url")
+	}
+	if input.Active.Null {
+		return notNullViolation(webhooksTable, "active")
+	}
+
+	if input.URL.Set {
+		webhook.URL = input.URL.Value
+	}
+	if input.EventTypes.Set {
+		webhook.EventTypes = eventTypes(input.EventTypes.Value)
+	}
+	if input.Active.Set {
+		webhook.Active = input.Active.Value
+	}
+	s.webhooks[webhookID] = webhook
+	return nil
+}
+
+func (r *WebhooksMemory) Delete(ctx context.Context, webhookID, projectID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.findWebhook(webhookID, projectID); !ok {
+		return ErrWebhookNotFound
+	}
+	s.deleteWebhook(webhookID)
+	return nil
+}
+
+func (r *WebhooksMemory) GetAll(ctx context.Context, projectID int) (models.GetAllWebhooks, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhooks := []models.Webhook{}
+	for _, webhook := range s.webhooks {
+		if webhook.ProjectID == projectID {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+
+	return models.GetAllWebhooks{Webhooks: webhooks}, nil
+}
+
+func (r *WebhooksMemory) GetOne(ctx context.Context, webhookID, projectID int) (models.Webhook, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhook, ok := s.findWebhook(webhookID, projectID)
+	if !ok {
+		return models.Webhook{}, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+func (r *WebhooksMemory) Enqueue(ctx context.Context, projectID int, eventType string, payload []byte) (int, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var enqueued int
+	for _, webhook := range s.webhooks {
+		if webhook.ProjectID != projectID || !webhook.Accepts(eventType) {
+			continue
+		}
+		s.deliverySeq++
+		s.deliveries[s.deliverySeq] = models.WebhookDelivery{
+			ID:            s.deliverySeq,
+			WebhookID:     webhook.ID,
+			EventType:     eventType,
+			Payload:       append([]byte(nil), payload...),
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+		enqueued++
+	}
+	return enqueued, nil
+}
+
+// GetDeliveries lists the newest deliveries first, like the delivery log in Postgres
+func (r *WebhooksMemory) GetDeliveries(ctx context.Context, webhookID, projectID, limit, offset int) (models.GetAllDeliveries, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.findWebhook(webhookID, projectID); !ok {
+		return models.GetAllDeliveries{}, ErrWebhookNotFound
+	}
+
+	limit, offset = normalizePage(limit, offset)
+
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.WebhookID == webhookID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	total := len(deliveries)
+
+	// page sorts by id ascending, negating it lists the newest first
+	deliveries = page(deliveries, func(d models.WebhookDelivery) int { return -d.ID }, limit, offset)
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+
+	return models.GetAllDeliveries{
+		Meta: models.MetaDeliveries{
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		},
+		Deliveries: deliveries,
+	}, nil
+}
+
+func (r *WebhooksMemory) Redeliver(ctx context.Context, deliveryID, webhookID, projectID int) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery, ok := s.deliveries[deliveryID]
+	if !ok || delivery.WebhookID != webhookID {
+		return ErrDeliveryNotFound
+	}
+	if _, ok := s.findWebhook(webhookID, projectID); !ok {
+		return ErrDeliveryNotFound
+	}
+
+	delivery.Status = models.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.DeliveredAt = nil
+	s.deliveries[deliveryID] = delivery
+	return nil
+}
+
+func (r *WebhooksMemory) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.DueDelivery, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var due []models.WebhookDelivery
+	for _, delivery := range s.deliveries {
+		if delivery.Status != models.DeliveryPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		if webhook, ok := s.webhooks[delivery.WebhookID]; ok && webhook.Active {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]models.DueDelivery, 0, len(due))
+	for _, delivery := range due {
+		delivery.NextAttemptAt = now.Add(lease)
+		s.deliveries[delivery.ID] = delivery
+
+		webhook := s.webhooks[delivery.WebhookID]
+		claimed = append(claimed, models.DueDelivery{
+			WebhookDelivery: delivery,
+			URL:             webhook.URL,
+			Secret:          webhook.Secret,
+		})
+	}
+	return claimed, nil
+}
+
+func (r *WebhooksMemory) RecordAttempt(ctx context.Context, deliveryID int, attempt models.DeliveryAttempt) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery, ok := s.deliveries[deliveryID]
+	if !ok {
+		return ErrDeliveryNotFound
+	}
+
+	delivery.Status = attempt.Status
+	delivery.Attempts++
+	delivery.NextAttemptAt = attempt.NextAttemptAt
+	delivery.ResponseStatus = attempt.ResponseStatus
+	delivery.LastError = attempt.Error
+	delivery.DeliveredAt = nil
+	if attempt.Status == models.DeliverySucceeded {
+		now := time.Now()
+		delivery.DeliveredAt = &now
+	}
+	s.deliveries[deliveryID] = delivery
+	return nil
+}
+
+func (s *memoryStore) findWebhook(webhookID, projectID int) (models.Webhook, bool) {
+	webhook, ok := s.webhooks[webhookID]
+	if !ok || webhook.ProjectID != projectID {
+		return models.Webhook{}, false
+	}
+	return webhook, true
+}
+
+// deleteWebhook removes the webhook and its deliveries, as ON DELETE CASCADE does
+func (s *memoryStore) deleteWebhook(webhookID int) {
+	for id, delivery := range s.deliveries {
+		if delivery.WebhookID == webhookID {
+			delete(s.deliveries, id)
+		}
+	}
+	delete(s.webhooks, webhookID)
+}
@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"go-service/internal/models"
+)
+
+// WebhooksPostgres isn't cached, webhooks are read by the delivery worker
+// far more often than they change and it needs the current secret
+type WebhooksPostgres struct {
+	db       *pgxpool.Pool
+	timeouts Timeouts
+	tracer   trace.Tracer
+}
+
+func NewWebhooksPostgres(db *pgxpool.Pool, timeouts Timeouts, tracer trace.Tracer) *WebhooksPostgres {
+	return &WebhooksPostgres{
+		db:       db,
+		timeouts: timeouts,
+		tracer:   tracer,
+	}
+}
+
+const (
+	webhookColumns  = `w.id, w.project_id, w.url, w.event_types, w.secret, w.active, w.created_at`
+	deliveryColumns = `d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at, d.response_status, d.last_error, d.created_at, d.delivered_at`
+)
+
+func (r *WebhooksPostgres) Create(ctx context.Context, projectID int, webhook models.Webhook) (int, error) {
+	var id int
+
+	ctx, span := r.tracer.Start(ctx, "CreateWebhook")
+	defer span.End()
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	query := fmt.Sprintf(`INSERT INTO %s (project_id, url, event_types, secret, active) VALUES ($1, $2, $3, $4, $5) RETURNING id`, webhooksTable)
+
+	span.AddEvent("create webhook", trace.WithAttributes(attribute.String("query", query)))
+	err := r.db.QueryRow(ctx, query, projectID, webhook.URL, eventTypes(webhook.EventTypes), webhook.Secret, webhook.Active).Scan(&id)
+	if isForeignKeyViolation(err) {
+		return 0, ErrProjectNotFound.Wrap(err)
+	}
+	if err != nil {
+		return 0, dbError(err, nil)
+	}
+	return id, nil
+}
+
+func (r *WebhooksPostgres) Update(ctx context.Context, webhookID, projectID int, input models.UpdateWebhook) error {
+	ctx, span := r.tracer.Start(ctx, "UpdateWebhook")
+	defer span.End()
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	query, args, err := webhookUpdate(webhookID, projectID, input)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("update webhook", trace.WithAttributes(attribute.String("query", query)))
+	res, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return dbError(err, ErrWebhookNotFound)
+	}
+	if res.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// Patch updates the webhook with the update patch builds from the webhook,
+// read and locked in the transaction of the update
+func (r *WebhooksPostgres) Patch(ctx context.Context, webhookID, projectID int, patch func(models.Webhook) (models.UpdateWebhook, error)) error {
+	ctx, span := r.tracer.Start(ctx, "UpdateWebhook")
+	defer span.End()
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return dbError(err, ErrWebhookNotFound)
+	}
+	defer tx.Rollback(ctx)
+
+	query := fmt.Sprintf(`SELECT %s FROM %s w WHERE w.id = $1 AND w.project_id = $2 FOR UPDATE`, webhookColumns, webhooksTable)
+	current, err := scanWebhook(tx.QueryRow(ctx, query, webhookID, projectID))
+	if err != nil {
+		return dbError(err, ErrWebhookNotFound)
+	}
+
+	input, err := patch(current)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := webhookUpdate(webhookID, projectID, input)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("update webhook", trace.WithAttributes(attribute.String("query", query)))
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return dbError(err, ErrWebhookNotFound)
+	}
+	return dbError(tx.Commit(ctx), ErrWebhookNotFound)
+}
+
+func webhookUpdate(webhookID, projectID int, input models.UpdateWebhook) (string, []any, error) {
+	update := newUpdateSet(webhooksTable, "url", "event_types", "active")
+	setField(update, "url", input.URL)
+	if input.EventTypes.Set {
+		// an empty filter is stored rather than NULL, it means every event
+		update.Set("event_types", eventTypes(input.EventTypes.Value))
+	}
+	setField(update, "active", input.Active)
+	update.Where("id", webhookID)
+	update.Where("project_id", projectID)
+	return update.SQL()
+}
+
+// Delete removes the webhook together with its delivery log
+func (r *WebhooksPostgres) Delete(ctx context.Context, webhookID, projectID int) error {
+	ctx, span := r.tracer.Start(ctx, "DeleteWebhook")
+	defer span.End()
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1 AND project_id = $2`, webhooksTable)
+	span.AddEvent("delete webhook", trace.WithAttributes(attribute.String("query", query)))
+	res, err := r.db.Exec(ctx, query, webhookID, projectID)
+	if err != nil {
+		return dbError(err, ErrWebhookNotFound)
+	}
+	if res.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *WebhooksPostgres) GetAll(ctx context.Context, projectID int) (models.GetAllWebhooks, error) {
+	response := models.GetAllWebhooks{Webhooks: []models.Webhook{}}
+
+	ctx, span := r.tracer.Start(ctx, "GetAllWebhooks")
+	defer span.End()
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT %s FROM %s w WHERE w.project_id = $1 ORDER BY w.id`, webhookColumns, webhooksTable)
+	rows, err := r.db.Query(ctx, query, projectID)
+	if err != nil {
+		return response, dbError(err, nil)
+	}
+	webhooks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Webhook, error) {
+		return scanWebhook(row)
+	})
+	if err != nil {
+		return response, dbError(err, nil)
+	}
+	response.Webhooks = append(response.Webhooks, webhooks...)
+	return response, nil
+}
+
+func (r *WebhooksPostgres) GetOne(ctx context.Context, webhookID, projectID int) (models.Webhook, error) {
+	ctx, span := r.tracer.Start(ctx, "GetWebhook")
+	defer span.End()
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	query := fmt.Sprintf(`SELECT %s FROM %s w WHERE w.id = $1 AND w.project_id = $2`, webhookColumns, webhooksTable)
+	webhook, err := scanWebhook(r.db.QueryRow(ctx, query, webhookID, projectID))
+	if err != nil {
+		return webhook, dbError(err, ErrWebhookNotFound)
+	}
+	return webhook, nil
+}
+
+func (r *WebhooksPostgres) Enqueue(ctx context.Context, projectID int, eventType string, payload []byte) (int, error) {
+	ctx, span := r.tracer.Start(ctx, "EnqueueDeliveries")
+	defer span.End()
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	query := fmt.Sprintf(`INSERT INTO %s (webhook_id, event_type, payload)
+		SELECT w.id, $2::text, $3::jsonb FROM %s w
+		WHERE w.project_id = $1 AND w.active AND (cardinality(w.event_types) = 0 OR $2::text = ANY(w.event_types))`,
+		deliveriesTable, webhooksTable)
+
+	span.AddEvent("enqueue deliveries", trace.WithAttributes(attribute.String("type", eventType)))
+	res, err := r.db.Exec(ctx, query, projectID, eventType, payload)
+	if err != nil {
+		return 0, dbError(err, nil)
+	}
+	return int(res.RowsAffected()), nil
+}
+
+func (r *WebhooksPostgres) GetDeliveries(ctx context.Context, webhookID, projectID, limit, offset int) (models.GetAllDeliveries, error) {
+	var response models.GetAllDeliveries
+
+	ctx, span := r.tracer.Start(ctx, "GetDeliveries")
+	defer span.End()
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Read)
+	defer cancel()
+
+	if _, err := r.GetOne(ctx, webhookID, projectID); err != nil {
+		return response, err
+	}
+
+	limit, offset = normalizePage(limit, offset)
+
+	query := fmt.Sprintf(`SELECT %s FROM %s d WHERE d.webhook_id = $1 ORDER BY d.id DESC LIMIT $2 OFFSET $3`, deliveryColumns, deliveriesTable)
+	countQuery := fmt.Sprintf(`SELECT COUNT(d.id) FROM %s d WHERE d.webhook_id = $1`, deliveriesTable)
+
+	deliveries := []models.WebhookDelivery{}
+	var total int
+	batch := &pgx.Batch{}
+	batch.Queue(query, webhookID, limit, offset).Query(func(rows pgx.Rows) error {
+		for rows.Next() {
+			delivery, err := scanDelivery(rows)
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		return rows.Err()
+	})
+	batch.Queue(countQuery, webhookID).QueryRow(func(row pgx.Row) error {
+		return row.Scan(&total)
+	})
+
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		return response, dbError(err, nil)
+	}
+
+	response.Meta = models.MetaDeliveries{
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	response.Deliveries = deliveries
+	return response, nil
+}
+
+func (r *WebhooksPostgres) Redeliver(ctx context.Context, deliveryID, webhookID, projectID int) error {
+	ctx, span := r.tracer.Start(ctx, "Redeliver")
+	defer span.End()
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	query := fmt.Sprintf(`UPDATE %s d SET status = $4, attempts = 0, next_attempt_at = now(), delivered_at = NULL
+		FROM %s w
+		WHERE d.id = $1 AND d.webhook_id = $2 AND w.id = d.webhook_id AND w.project_id = $3`,
+		deliveriesTable, webhooksTable)
+
+	span.AddEvent("redeliver", trace.WithAttributes(attribute.Int("id", deliveryID)))
+	res, err := r.db.Exec(ctx, query, deliveryID, webhookID, projectID, models.DeliveryPending)
+	if err != nil {
+		return dbError(err, ErrDeliveryNotFound)
+	}
+	if res.RowsAffected() == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+func (r *WebhooksPostgres) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.DueDelivery, error) {
+	ctx, span := r.tracer.Start(ctx, "ClaimDueDeliveries")
+	defer span.End()
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	// SKIP LOCKED lets several workers claim side by side, moving
+	// next_attempt_at forward is the lease
+	query := fmt.Sprintf(`UPDATE %[1]s d SET next_attempt_at = now() + make_interval(secs => $3)
+		FROM %[2]s w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT q.id FROM %[1]s q JOIN %[2]s qw ON qw.id = q.webhook_id
+			WHERE q.status = $2 AND q.next_attempt_at <= now() AND qw.active
+			ORDER BY q.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF q SKIP LOCKED
+		)
+		RETURNING %[3]s, w.url, w.secret`,
+		deliveriesTable, webhooksTable, deliveryColumns)
+
+	rows, err := r.db.Query(ctx, query, limit, models.DeliveryPending, lease.Seconds())
+	if err != nil {
+		return nil, dbError(err, nil)
+	}
+	due, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DueDelivery, error) {
+		var d models.DueDelivery
+		var err error
+		d.WebhookDelivery, err = scanDelivery(row, &d.URL, &d.Secret)
+		return d, err
+	})
+	if err != nil {
+		return nil, dbError(err, nil)
+	}
+	span.SetAttributes(attribute.Int("claimed", len(due)))
+	return due, nil
+}
+
+func (r *WebhooksPostgres) RecordAttempt(ctx context.Context, deliveryID int, attempt models.DeliveryAttempt) error {
+	ctx, span := r.tracer.Start(ctx, "RecordDeliveryAttempt")
+	defer span.End()
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Write)
+	defer cancel()
+
+	query := fmt.Sprintf(`UPDATE %s SET status = $2, attempts = attempts + 1, next_attempt_at = $3,
+		response_status = NULLIF($4, 0), last_error = NULLIF($5, ''),
+		delivered_at = CASE WHEN $2 = '%s' THEN now() END
+		WHERE id = $1`, deliveriesTable, models.DeliverySucceeded)
+
+	res, err := r.db.Exec(ctx, query, deliveryID, attempt.Status, attempt.NextAttemptAt, attempt.ResponseStatus, attempt.Error)
+	if err != nil {
+		return dbError(err, ErrDeliveryNotFound)
+	}
+	if res.RowsAffected() == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+func scanWebhook(row pgx.Row) (models.Webhook, error) {
+	var w models.Webhook
+	err := row.Scan(&w.ID, &w.ProjectID, &w.URL, &w.EventTypes, &w.Secret, &w.Active, &w.CreatedAt)
+	return w, err
+}
+
+// scanDelivery reads deliveryColumns followed by extra
+func scanDelivery(row pgx.Row, extra ...any) (models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var responseStatus *int
+	var lastError *string
+	dest := append([]any{
+		&d.ID, &d.WebhookID, &d.EventType, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&responseStatus, &lastError, &d.CreatedAt, &d.DeliveredAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return d, err
+	}
+	if responseStatus != nil {
+		d.ResponseStatus = *responseStatus
+	}
+	if lastError != nil {
+		d.LastError = *lastError
+	}
+	return d, nil
+}
+
+// eventTypes keeps a missing filter from becoming NULL
+func eventTypes(types []string) []string {
+	if types == nil {
+		return []string{}
+	}
+	return types
+}
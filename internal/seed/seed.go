@@ -0,0 +1,85 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"go-service/internal/models"
+	"go-service/internal/service"
+)
+
+type Options struct {
+	Projects int
+	// Goods is the number of goods created in each project
+	Goods int
+	// Seed makes the generated data reproducible, 0 picks a random one
+	Seed int64
+}
+
+type Result struct {
+	Projects int
+	Goods    int
+}
+
+var (
+	projectPrefixes = []string{"North", "Blue", "Rapid", "Urban", "Green", "Prime", "Silver", "Bright", "Central", "Coastal"}
+	projectSuffixes = []string{"Logistics", "Retail", "Supply", "Market", "Outlet", "Warehouse", "Traders", "Store", "Depot", "Hub"}
+
+	adjectives = []string{"Compact", "Durable", "Wireless", "Organic", "Premium", "Portable", "Classic", "Ergonomic", "Smart", "Recycled"}
+	materials  = []string{"Steel", "Bamboo", "Cotton", "Ceramic", "Leather", "Glass", "Oak", "Aluminium", "Wool", "Carbon"}
+	products   = []string{"Kettle", "Backpack", "Desk Lamp", "Headphones", "Water Bottle", "Notebook", "Chair", "Keyboard", "Blanket", "Mug"}
+	features   = []string{"a two year warranty", "free returns", "a gift box", "spare parts included", "a travel case", "a recycled package"}
+)
+
+// Run creates projects and goods through the services, so caches get
+// invalidated exactly as for API calls
+func Run(ctx context.Context, services *service.Service, opts Options) (Result, error) {
+	var result Result
+
+	seed := opts.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rnd := rand.New(rand.NewSource(seed))
+
+	for i := 0; i < opts.Projects; i++ {
+		projectID, err := services.Projects.Create(ctx, models.Project{Name: projectName(rnd)})
+		if err != nil {
+			return result, fmt.Errorf("create project: %w", err)
+		}
+		result.Projects++
+
+		for j := 0; j < opts.Goods; j++ {
+			name, description := goodsText(rnd)
+			_, err := services.Goods.Create(ctx, projectID, models.Goods{
+				Name:        name,
+				Description: description,
+				Removed:     rnd.Intn(10) == 0,
+			})
+			if err != nil {
+				return result, fmt.Errorf("create goods in project %d: %w", projectID, err)
+			}
+			result.Goods++
+		}
+	}
+
+	return result, nil
+}
+
+func projectName(rnd *rand.Rand) string {
+	return fmt.Sprintf("%s %s %d", pick(rnd, projectPrefixes), pick(rnd, projectSuffixes), rnd.Intn(900)+100)
+}
+
+func goodsText(rnd *rand.Rand) (string, string) {
+	adjective, material, product := pick(rnd, adjectives), pick(rnd, materials), pick(rnd, products)
+	name := fmt.Sprintf("%s %s %s", adjective, material, product)
+	description := fmt.Sprintf("%s %s made of %s, comes with %s.",
+		adjective, strings.ToLower(product), strings.ToLower(material), pick(rnd, features))
+	return name, description
+}
+
+func pick(rnd *rand.Rand, words []string) string {
+	return words[rnd.Intn(len(words))]
+}
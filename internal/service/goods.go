@@ -2,17 +2,40 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
 
+	"go.uber.org/zap"
+
+	"go-service/internal/apperror"
 	"go-service/internal/models"
 	"go-service/internal/repository"
+	"go-service/pkg/logger"
 )
 
+// publishTimeout bounds reading the changed goods and publishing the event
+const publishTimeout = 5 * time.Second
+
+// GoodsEventsSubjects matches the subjects of every project
+const GoodsEventsSubjects = "goods.events.*"
+
+// GoodsEventsSubject receives a models.GoodsEvent after every change of
+// goods in the project
+func GoodsEventsSubject(projectID int) string {
+	return fmt.Sprintf("goods.events.%d", projectID)
+}
+
 type GoodsService struct {
-	repo repository.Goods
+	repo   repository.Goods
+	events Publisher
 }
 
-func NewGoodsService(repo repository.Goods) *GoodsService {
-	return &GoodsService{repo: repo}
+// NewGoodsService publishes changes to events, nil turns events off
+func NewGoodsService(repo repository.Goods, events Publisher) *GoodsService {
+	return &GoodsService{repo: repo, events: events}
 }
 
 func (s *GoodsService) GetAll(ctx context.Context, limit, offset int) (models.GetAllGoods, error) {
@@ -22,14 +45,84 @@ func (s *GoodsService) GetOne(ctx context.Context, goodsID, projectID int) (mode
 	return s.repo.GetOne(ctx, goodsID, projectID)
 }
 func (s *GoodsService) Create(ctx context.Context, projectID int, goods models.Goods) (int, error) {
-	return s.repo.Create(ctx, projectID, goods)
+	id, err := s.repo.Create(ctx, projectID, goods)
+	if err != nil {
+		return 0, err
+	}
+	s.publish(ctx, models.GoodsCreated, id, projectID)
+	return id, nil
 }
 func (s *GoodsService) Update(ctx context.Context, goodsID, projectID int, input models.UpdateGoods) error {
-	return s.repo.Update(ctx, goodsID, projectID, input)
+	if err := s.repo.Update(ctx, goodsID, projectID, input); err != nil {
+		return err
+	}
+	s.publish(ctx, models.GoodsUpdated, goodsID, projectID)
+	return nil
+}
+func (s *GoodsService) Patch(ctx context.Context, goodsID, projectID int, patch func(models.Goods) (models.UpdateGoods, error)) error {
+	if err := s.repo.Patch(ctx, goodsID, projectID, patch); err != nil {
+		return err
+	}
+	s.publish(ctx, models.GoodsUpdated, goodsID, projectID)
+	return nil
 }
 func (s *GoodsService) Delete(ctx context.Context, goodsID, projectID int) error {
-	return s.repo.Delete(ctx, goodsID, projectID)
+	if err := s.repo.Delete(ctx, goodsID, projectID); err != nil {
+		return err
+	}
+	s.publish(ctx, models.GoodsRemoved, goodsID, projectID)
+	return nil
 }
 func (s *GoodsService) Reprioritize(ctx context.Context, goodsID, projectID int, priority int) error {
-	return s.repo.Reprioritize(ctx, goodsID, projectID, priority)
+	if priority < 1 {
+		return apperror.Validation("goods.invalid_priority", "priority is invalid", apperror.FieldError{
+			Field:   "priority",
+			Message: "must be at least 1",
+		})
+	}
+	if err := s.repo.Reprioritize(ctx, goodsID, projectID, priority); err != nil {
+		return err
+	}
+	s.publish(ctx, models.GoodsReprioritized, goodsID, projectID)
+	return nil
+}
+
+// publish sends the change with the current state of the goods. The change
+// is already stored, so failures are only logged. The event is sent even
+// when the request is canceled meanwhile, the trace of ctx is kept
+func (s *GoodsService) publish(ctx context.Context, eventType string, goodsID, projectID int) {
+	if s.events == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), publishTimeout)
+	defer cancel()
+
+	goods, err := s.repo.GetOne(ctx, goodsID, projectID)
+	if err == nil {
+		err = s.events.Publish(ctx, GoodsEventsSubject(projectID), models.GoodsEvent{
+			ID:        newEventID(),
+			Type:      eventType,
+			ProjectID: projectID,
+			GoodsID:   goodsID,
+			Goods:     goods,
+			At:        time.Now().UTC(),
+		})
+	}
+	if err != nil {
+		logger.GetLogger().Error("failed to publish goods event",
+			zap.String("type", eventType),
+			zap.Int("goods_id", goodsID),
+			zap.Error(err),
+		)
+	}
+}
+
+// newEventID returns a random ID, 128 bits keep collisions out of the deduplication window
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand doesn't fail on supported platforms, a time based ID still dedupes retries
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
 }
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"go-service/internal/models"
+	"go-service/internal/repository"
+)
+
+// canceledGoods cancels the request once the change is stored, like a
+// client that goes away before the response
+type canceledGoods struct {
+	repository.Goods
+	cancel context.CancelFunc
+}
+
+func (g canceledGoods) Create(ctx context.Context, projectID int, goods models.Goods) (int, error) {
+	defer g.cancel()
+	return g.Goods.Create(ctx, projectID, goods)
+}
+
+type recordingPublisher struct {
+	events []models.GoodsEvent
+	err    error
+	span   trace.SpanContext
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, _ string, data interface{}) error {
+	p.events = append(p.events, data.(models.GoodsEvent))
+	p.err = ctx.Err()
+	p.span = trace.SpanContextFromContext(ctx)
+	return nil
+}
+
+func TestPublishAfterCancel(t *testing.T) {
+	repos := repository.NewMemory()
+	projectID, err := repos.Projects.Create(context.Background(), models.Project{Name: "events"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	span := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx, cancel := context.WithCancel(trace.ContextWithSpanContext(context.Background(), span))
+	defer cancel()
+
+	events := &recordingPublisher{}
+	goods := NewGoodsService(canceledGoods{Goods: repos.Goods, cancel: cancel}, events)
+	id, err := goods.Create(ctx, projectID, models.Goods{Name: "stored"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events.events) != 1 {
+		t.Fatalf("%d events published, want 1", len(events.events))
+	}
+	if event := events.events[0]; event.GoodsID != id || event.Goods.Name != "stored" {
+		t.Errorf("event = %+v, want the created goods", event)
+	}
+	if events.err != nil {
+		t.Errorf("published with a done context: %v", events.err)
+	}
+	if !events.span.Equal(span) {
+		t.Errorf("published in span %v, want the request's", events.span)
+	}
+}
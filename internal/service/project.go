@@ -18,9 +18,12 @@ func NewProjectService(repo repository.Projects) *ProjectService {
 func (s *ProjectService) Create(ctx context.Context, project models.Project) (int, error) {
 	return s.repo.Create(ctx, project)
 }
-func (s *ProjectService) Update(ctx context.Context, projectID int, input models.UpdateProjects) error {
+func (s *ProjectService) Update(ctx context.Context, projectID int, input models.UpdateProject) error {
 	return s.repo.Update(ctx, projectID, input)
 }
+func (s *ProjectService) Patch(ctx context.Context, projectID int, patch func(models.Project) (models.UpdateProject, error)) error {
+	return s.repo.Patch(ctx, projectID, patch)
+}
 func (s *ProjectService) Delete(ctx context.Context, projectID int) error {
 	return s.repo.Delete(ctx, projectID)
 }
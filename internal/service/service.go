@@ -11,7 +11,8 @@ import (
 
 type Projects interface {
 	Create(ctx context.Context, input models.Project) (int, error)
-	Update(ctx context.Context, projectID int, project models.UpdateProjects) error
+	Update(ctx context.Context, projectID int, project models.UpdateProject) error
+	Patch(ctx context.Context, projectID int, patch func(models.Project) (models.UpdateProject, error)) error
 	Delete(ctx context.Context, projectID int) error
 	GetAll(ctx context.Context, limit, offset int) (models.GetAllProjects, error)
 	GetByID(ctx context.Context, projectID int) (models.Project, error)
@@ -20,20 +21,47 @@ type Projects interface {
 type Goods interface {
 	Create(ctx context.Context, projectID int, goods models.Goods) (int, error)
 	Update(ctx context.Context, goodsID, projectID int, input models.UpdateGoods) error
+	Patch(ctx context.Context, goodsID, projectID int, patch func(models.Goods) (models.UpdateGoods, error)) error
 	Delete(ctx context.Context, goodsID, projectID int) error
 	GetAll(ctx context.Context, limit, offset int) (models.GetAllGoods, error)
 	GetOne(ctx context.Context, goodsID, projectID int) (models.Goods, error)
 	Reprioritize(ctx context.Context, goodsID, projectID int, priority int) error
 }
 
+type Webhooks interface {
+	Create(ctx context.Context, projectID int, input models.CreateWebhook) (models.Webhook, error)
+	Update(ctx context.Context, webhookID, projectID int, input models.UpdateWebhook) error
+	Patch(ctx context.Context, webhookID, projectID int, patch func(models.Webhook) (models.UpdateWebhook, error)) error
+	Delete(ctx context.Context, webhookID, projectID int) error
+	GetAll(ctx context.Context, projectID int) (models.GetAllWebhooks, error)
+	GetOne(ctx context.Context, webhookID, projectID int) (models.Webhook, error)
+	GetDeliveries(ctx context.Context, webhookID, projectID, limit, offset int) (models.GetAllDeliveries, error)
+	Redeliver(ctx context.Context, deliveryID, webhookID, projectID int) error
+	Notify(ctx context.Context, event models.GoodsEvent) (int, error)
+}
+
+// Publisher sends events to the message bus, every pkg/eventbus.Bus implements it
+type Publisher interface {
+	Publish(ctx context.Context, subject string, data interface{}) error
+}
+
 type Service struct {
 	Projects
 	Goods
+	Webhooks Webhooks
+}
+
+type Config struct {
+	// AllowPrivateWebhooks accepts webhook receivers on private, loopback
+	// and link-local addresses
+	AllowPrivateWebhooks bool
 }
 
-func New(repo *repository.Repository) *Service {
+// New wires the services, changes of goods are published to events unless it is nil
+func New(repo *repository.Repository, events Publisher, config Config) *Service {
 	return &Service{
 		Projects: NewProjectService(repo.Projects),
-		Goods:    NewGoodsService(repo.Goods),
+		Goods:    NewGoodsService(repo.Goods, events),
+		Webhooks: NewWebhookService(repo.Webhooks, config.AllowPrivateWebhooks),
 	}
 }
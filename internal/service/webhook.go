@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go-service/internal/apperror"
+	"go-service/internal/models"
+	"go-service/internal/repository"
+	"go-service/internal/webhook"
+)
+
+// secretPrefix marks generated signing secrets, so they are recognisable in configs and logs
+const secretPrefix = "whsec_"
+
+type WebhookService struct {
+	repo repository.Webhooks
+	// allowPrivate accepts receivers that aren't on the public internet
+	allowPrivate bool
+}
+
+func NewWebhookService(repo repository.Webhooks, allowPrivate bool) *WebhookService {
+	return &WebhookService{repo: repo, allowPrivate: allowPrivate}
+}
+
+// Create stores the webhook and returns it with its secret, the only time
+// the secret is shown
+func (s *WebhookService) Create(ctx context.Context, projectID int, input models.CreateWebhook) (models.Webhook, error) {
+	if err := s.checkURL(ctx, input.URL); err != nil {
+		return models.Webhook{}, err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newSecret(); err != nil {
+			return models.Webhook{}, err
+		}
+	}
+
+	id, err := s.repo.Create(ctx, projectID, models.Webhook{
+		URL:        input.URL,
+		EventTypes: input.EventTypes,
+		Secret:     secret,
+		Active:     true,
+	})
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	webhook, err := s.repo.GetOne(ctx, id, projectID)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	return webhook, nil
+}
+func (s *WebhookService) Update(ctx context.Context, webhookID, projectID int, input models.UpdateWebhook) error {
+	if input.URL.Set {
+		if err := s.checkURL(ctx, input.URL.Value); err != nil {
+			return err
+		}
+	}
+	return s.repo.Update(ctx, webhookID, projectID, input)
+}
+
+// Patch hides the secret from patch like GetOne does
+func (s *WebhookService) Patch(ctx context.Context, webhookID, projectID int, patch func(models.Webhook) (models.UpdateWebhook, error)) error {
+	return s.repo.Patch(ctx, webhookID, projectID, func(webhook models.Webhook) (models.UpdateWebhook, error) {
+		webhook.Secret = ""
+		input, err := patch(webhook)
+		if err == nil && input.URL.Set {
+			err = s.checkURL(ctx, input.URL.Value)
+		}
+		return input, err
+	})
+}
+func (s *WebhookService) Delete(ctx context.Context, webhookID, projectID int) error {
+	return s.repo.Delete(ctx, webhookID, projectID)
+}
+func (s *WebhookService) GetAll(ctx context.Context, projectID int) (models.GetAllWebhooks, error) {
+	webhooks, err := s.repo.GetAll(ctx, projectID)
+	for i := range webhooks.Webhooks {
+		webhooks.Webhooks[i].Secret = ""
+	}
+	return webhooks, err
+}
+func (s *WebhookService) GetOne(ctx context.Context, webhookID, projectID int) (models.Webhook, error) {
+	webhook, err := s.repo.GetOne(ctx, webhookID, projectID)
+	webhook.Secret = ""
+	return webhook, err
+}
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookID, projectID, limit, offset int) (models.GetAllDeliveries, error) {
+	return s.repo.GetDeliveries(ctx, webhookID, projectID, limit, offset)
+}
+func (s *WebhookService) Redeliver(ctx context.Context, deliveryID, webhookID, projectID int) error {
+	return s.repo.Redeliver(ctx, deliveryID, webhookID, projectID)
+}
+
+// Notify queues the event for every webhook of its project that wants it
+// and returns how many deliveries were queued
+func (s *WebhookService) Notify(ctx context.Context, event models.GoodsEvent) (int, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("encode %s event: %w", event.Type, err)
+	}
+	return s.repo.Enqueue(ctx, event.ProjectID, event.Type, payload)
+}
+
+// checkURL refuses receivers the dispatcher wouldn't connect to
+func (s *WebhookService) checkURL(ctx context.Context, rawURL string) error {
+	if s.allowPrivate {
+		return nil
+	}
+	err := webhook.CheckURL(ctx, rawURL)
+	switch {
+	case errors.Is(err, webhook.ErrPrivateAddress):
+		return apperror.Validation("webhook.private_url", "url must point to a public address", apperror.FieldError{
+			Field:   "url",
+			Message: "must not be a private, loopback or link-local address",
+		})
+	case err != nil:
+		return apperror.Validation("webhook.invalid_url", "url can't be resolved", apperror.FieldError{
+			Field:   "url",
+			Message: err.Error(),
+		})
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
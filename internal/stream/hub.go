@@ -0,0 +1,325 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go-service/internal/models"
+	"go-service/internal/service"
+	"go-service/pkg/eventbus"
+	p "go-service/pkg/prometheus"
+)
+
+var (
+	// ErrTooManyConnections is returned by Subscribe at the connection limit
+	ErrTooManyConnections = errors.New("too many event stream connections")
+	// ErrSlowClient ends subscriptions that didn't keep up with the events
+	ErrSlowClient = errors.New("client is too slow to receive events")
+	// ErrClosed ends the subscriptions of a hub that is shutting down
+	ErrClosed = errors.New("event hub is shut down")
+)
+
+// EventReset starts a stream that can't be resumed, the client should fetch
+// the goods again. Its ID is the current position of the hub
+const EventReset = "reset"
+
+// Event is a goods change numbered by the hub
+type Event struct {
+	// ID resumes the stream after this event, see Subscribe
+	ID   string
+	Type string
+	Data json.RawMessage
+}
+
+type Config struct {
+	Bus eventbus.Bus
+	// Buffer is how many recent events per project are kept to resume streams
+	Buffer int
+	// ClientBuffer is how many events wait for one connection before it is dropped
+	ClientBuffer int
+	// MaxConnections per instance, 0 means no limit
+	MaxConnections int
+	// Retention is how long the events of a project without streams are
+	// kept for resumes, 5 minutes by default
+	Retention time.Duration
+	Logger    *zap.Logger
+}
+
+// Hub fans goods events from the bus out to the streams of this instance.
+//
+// Events are numbered as they arrive, so IDs are only meaningful to the
+// instance that sent them. A stream resumed from an unknown or too old ID
+// starts with a reset and the client should fetch the goods again. Projects
+// without streams are dropped after Config.Retention, so are their events
+type Hub struct {
+	config Config
+	// epoch tells IDs of this process from IDs of other instances and restarts
+	epoch string
+
+	mu       sync.Mutex
+	seq      uint64
+	projects map[int]*project
+	conns    int
+	// pruned is the last event number dropped with an idle project
+	pruned uint64
+	closed bool
+}
+
+type project struct {
+	// recent events, oldest first
+	recent []Event
+	subs   map[*Subscription]struct{}
+	// from is the event number the project was tracked after, resumes
+	// from before it may have missed events
+	from uint64
+	// active is when the last event came or the last stream ended
+	active time.Time
+}
+
+func NewHub(config Config) *Hub {
+	if config.Buffer < 1 {
+		config.Buffer = 1
+	}
+	if config.ClientBuffer < 1 {
+		config.ClientBuffer = 1
+	}
+	if config.Retention <= 0 {
+		config.Retention = 5 * time.Minute
+	}
+	return &Hub{
+		config:   config,
+		epoch:    strconv.FormatInt(time.Now().UnixNano(), 36),
+		projects: make(map[int]*project),
+	}
+}
+
+// Listen subscribes to the goods events of every project and drops idle
+// projects until ctx is done
+func (h *Hub) Listen(ctx context.Context) error {
+	err := h.config.Bus.Subscribe(ctx, service.GoodsEventsSubjects, func(_ context.Context, msg eventbus.Message) error {
+		var event models.GoodsEvent
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			return fmt.Errorf("invalid goods event: %w", err)
+		}
+		h.Dispatch(event.ProjectID, event.Type, msg.Data)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(h.config.Retention)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				h.Prune(now)
+			}
+		}
+	}()
+	return nil
+}
+
+// Prune drops the projects without streams that were idle for longer than
+// Config.Retention at now, Listen calls it periodically
+func (h *Hub) Prune(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for projectID, pr := range h.projects {
+		if len(pr.subs) > 0 || now.Sub(pr.active) < h.config.Retention {
+			continue
+		}
+		if n := len(pr.recent); n > 0 {
+			seq, _ := h.parseID(pr.recent[n-1].ID)
+			h.pruned = max(h.pruned, seq)
+		}
+		delete(h.projects, projectID)
+	}
+}
+
+// Dispatch numbers the event, keeps it for resumes and sends it to the
+// subscriptions of the project. Subscriptions with a full buffer are dropped
+// rather than slowing the others down
+func (h *Hub) Dispatch(projectID int, eventType string, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	event := Event{
+		ID:   h.id(h.seq),
+		Type: eventType,
+		Data: data,
+	}
+
+	pr := h.project(projectID)
+	pr.active = time.Now()
+	if len(pr.recent) == h.config.Buffer {
+		pr.recent = append(pr.recent[:0], pr.recent[1:]...)
+	}
+	pr.recent = append(pr.recent, event)
+
+	for sub := range pr.subs {
+		select {
+		case sub.events <- event:
+		default:
+			sub.err = ErrSlowClient
+			h.remove(pr, sub)
+			p.EventsDroppedTotal.WithLabelValues("slow_client").Inc()
+		}
+	}
+}
+
+// Subscribe streams the events of the project. With lastEventID the events
+// after it are returned as backlog, or a single EventReset when they can't
+// be replayed
+func (h *Hub) Subscribe(projectID int, lastEventID string) (*Subscription, []Event, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, ErrClosed
+	}
+	if h.config.MaxConnections > 0 && h.conns >= h.config.MaxConnections {
+		p.EventsRejectedTotal.Inc()
+		return nil, nil, ErrTooManyConnections
+	}
+
+	pr := h.project(projectID)
+	var backlog []Event
+	if lastEventID != "" {
+		var ok bool
+		if backlog, ok = h.since(pr, lastEventID); !ok {
+			backlog = []Event{{ID: h.id(h.seq), Type: EventReset, Data: json.RawMessage("{}")}}
+		}
+	}
+
+	sub := &Subscription{
+		hub:       h,
+		projectID: projectID,
+		events:    make(chan Event, h.config.ClientBuffer),
+	}
+	pr.subs[sub] = struct{}{}
+	h.conns++
+	return sub, backlog, nil
+}
+
+// Close ends every subscription with ErrClosed and refuses new ones, so
+// streams return before the server waits for its connections
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, pr := range h.projects {
+		for sub := range pr.subs {
+			sub.err = ErrClosed
+			h.remove(pr, sub)
+		}
+	}
+}
+
+// since returns the kept events after id, false when some may be missing
+func (h *Hub) since(pr *project, id string) ([]Event, bool) {
+	seq, ok := h.parseID(id)
+	if !ok || seq > h.seq || seq < pr.from {
+		return nil, false
+	}
+
+	for i, event := range pr.recent {
+		eventSeq, _ := h.parseID(event.ID)
+		if eventSeq <= seq {
+			continue
+		}
+		// events of other projects share the numbering, so a gap only
+		// shows when the oldest kept event of a full buffer is newer
+		if i == 0 && len(pr.recent) == h.config.Buffer {
+			return nil, false
+		}
+		return append([]Event(nil), pr.recent[i:]...), true
+	}
+	return nil, true
+}
+
+func (h *Hub) id(seq uint64) string {
+	return h.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+func (h *Hub) parseID(id string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != h.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
+
+func (h *Hub) project(projectID int) *project {
+	pr, ok := h.projects[projectID]
+	if !ok {
+		// events of the project up to the last pruned one may be gone
+		pr = &project{
+			subs:   make(map[*Subscription]struct{}),
+			from:   h.pruned,
+			active: time.Now(),
+		}
+		h.projects[projectID] = pr
+	}
+	return pr
+}
+
+// remove ends the subscription, the caller holds h.mu
+func (h *Hub) remove(pr *project, sub *Subscription) {
+	if pr == nil {
+		return
+	}
+	if _, ok := pr.subs[sub]; !ok {
+		return
+	}
+	delete(pr.subs, sub)
+	close(sub.events)
+	h.conns--
+	pr.active = time.Now()
+
+	if len(pr.subs) == 0 && len(pr.recent) == 0 {
+		delete(h.projects, sub.projectID)
+	}
+}
+
+// Subscription is one stream of events, Events is closed when the hub drops it
+type Subscription struct {
+	hub       *Hub
+	projectID int
+	events    chan Event
+	// err is set before events is closed
+	err error
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err tells why Events was closed, nil after Close
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription, it is safe to call more than once
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s.hub.projects[s.projectID], s)
+}
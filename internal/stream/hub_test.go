@@ -0,0 +1,78 @@
+package stream
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	const retention = time.Minute
+	hub := NewHub(Config{Buffer: 8, ClientBuffer: 8, Retention: retention})
+
+	watched, _, err := hub.Subscribe(1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watched.Close()
+
+	hub.Dispatch(1, "goods.created", []byte(`{}`))
+	hub.Dispatch(2, "goods.created", []byte(`{}`))
+	last := (<-watched.Events()).ID
+
+	hub.Prune(time.Now())
+	if len(hub.projects) != 2 {
+		t.Fatalf("pruned before the retention, %d projects left", len(hub.projects))
+	}
+
+	hub.Prune(time.Now().Add(retention))
+	if _, ok := hub.projects[2]; ok {
+		t.Fatal("idle project was kept")
+	}
+	if _, ok := hub.projects[1]; !ok {
+		t.Fatal("project with a stream was pruned")
+	}
+
+	// a stream of project 2 that ended before its pruned event can't resume
+	hub.Dispatch(2, "goods.updated", []byte(`{}`))
+	sub, backlog, err := hub.Subscribe(2, hub.id(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub.Close()
+	if len(backlog) != 1 || backlog[0].Type != EventReset {
+		t.Errorf("resume after pruning: backlog %v, want a reset", backlog)
+	}
+
+	// a stream of a kept project resumes as before
+	hub.Dispatch(1, "goods.updated", []byte(`{}`))
+	sub, backlog, err = hub.Subscribe(1, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub.Close()
+	if len(backlog) != 1 || backlog[0].Type != "goods.updated" {
+		t.Errorf("resume of a kept project: backlog %v, want goods.updated", backlog)
+	}
+}
+
+func TestClose(t *testing.T) {
+	hub := NewHub(Config{Buffer: 8, ClientBuffer: 8})
+
+	sub, _, err := hub.Subscribe(1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub.Close()
+
+	if _, open := <-sub.Events(); open {
+		t.Fatal("events still open after Close")
+	}
+	if !errors.Is(sub.Err(), ErrClosed) {
+		t.Errorf("subscription error = %v, want ErrClosed", sub.Err())
+	}
+	if _, _, err := hub.Subscribe(1, ""); !errors.Is(err, ErrClosed) {
+		t.Errorf("subscribe after Close: %v, want ErrClosed", err)
+	}
+	sub.Close()
+}
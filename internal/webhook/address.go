@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned for receivers that aren't on the public
+// internet, deliveries must not reach into the network the service runs in
+var ErrPrivateAddress = errors.New("webhook receiver address is not public")
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, private in
+// all but name
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Public reports whether deliveries may be sent to addr. Loopback, private,
+// link-local, multicast and unspecified addresses are not public
+func Public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		!addr.IsLoopback() &&
+		!addr.IsPrivate() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() &&
+		!addr.IsMulticast() &&
+		!addr.IsUnspecified() &&
+		!sharedAddressSpace.Contains(addr)
+}
+
+// CheckURL fails with ErrPrivateAddress when the host of rawURL is or
+// resolves to an address that isn't public. The host may resolve elsewhere
+// later, the dispatcher checks every connection again
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return checkAddr(addr)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if err := checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkAddr(addr netip.Addr) error {
+	if !Public(addr) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, addr)
+	}
+	return nil
+}
+
+// publicTransport only connects to public addresses. The address is checked
+// after resolution, right before the connection is made, so a receiver host
+// that resolves elsewhere since it was registered can't get around it.
+// Proxies are not used, they would connect on the dispatcher's behalf
+func publicTransport(base *http.Transport) *http.Transport {
+	transport := base.Clone()
+	transport.Proxy = nil
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			return checkAddr(addrPort.Addr())
+		},
+	}
+	transport.DialContext = dialer.DialContext
+	return transport
+}
@@ -0,0 +1,40 @@
+package webhook_test
+
+import (
+	"context"
+	"testing"
+
+	"go-service/internal/webhook"
+)
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		private bool
+	}{
+		{"https://93.184.216.34/hook", false},
+		{"https://[2606:2800:220:1:248:1893:25c8:1946]/hook", false},
+		{"http://127.0.0.1:8080/hook", true},
+		{"http://[::1]/hook", true},
+		{"http://10.0.0.8/hook", true},
+		{"http://172.16.3.4/hook", true},
+		{"http://192.168.1.1/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://[fe80::1]/hook", true},
+		{"http://[fd00::1]/hook", true},
+		{"http://100.64.0.1/hook", true},
+		{"http://0.0.0.0/hook", true},
+		{"http://[::ffff:127.0.0.1]/hook", true},
+		{"http://localhost:8080/hook", true},
+	}
+
+	for _, tt := range tests {
+		err := webhook.CheckURL(context.Background(), tt.url)
+		if tt.private && err == nil {
+			t.Errorf("CheckURL(%s) = nil, want an error", tt.url)
+		}
+		if !tt.private && err != nil {
+			t.Errorf("CheckURL(%s) = %v, want nil", tt.url, err)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	"go-service/internal/models"
+	"go-service/internal/repository"
+	p "go-service/pkg/prometheus"
+)
+
+// errorBodyLimit is how much of a failed response ends up in the delivery log
+const errorBodyLimit = 256
+
+type Config struct {
+	Repo repository.Webhooks
+	// Client sends the deliveries, redirects are not followed either way.
+	// Its transport is replaced by one that only connects to public
+	// addresses, unless it is a custom http.RoundTripper
+	Client *http.Client
+	// AllowPrivate lets deliveries reach private, loopback and link-local
+	// addresses, for receivers inside the network
+	AllowPrivate bool
+	// Timeout bounds one attempt
+	Timeout time.Duration
+	// MaxAttempts is how many failed attempts make a delivery dead
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	// Batch is how many deliveries one claim takes
+	Batch       int
+	Concurrency int
+	Logger      *zap.Logger
+	Tracer      trace.Tracer
+}
+
+// Dispatcher sends due deliveries. Several dispatchers may share a
+// repository, every delivery is claimed by one of them at a time
+type Dispatcher struct {
+	repo        repository.Webhooks
+	client      *http.Client
+	timeout     time.Duration
+	maxAttempts int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	batch       int
+	concurrency int
+	logger      *zap.Logger
+	tracer      trace.Tracer
+}
+
+func NewDispatcher(config Config) *Dispatcher {
+	d := &Dispatcher{
+		repo:        config.Repo,
+		timeout:     config.Timeout,
+		maxAttempts: config.MaxAttempts,
+		minBackoff:  config.MinBackoff,
+		maxBackoff:  config.MaxBackoff,
+		batch:       config.Batch,
+		concurrency: config.Concurrency,
+		logger:      config.Logger,
+		tracer:      config.Tracer,
+	}
+	if d.timeout <= 0 {
+		d.timeout = 10 * time.Second
+	}
+	if d.maxAttempts <= 0 {
+		d.maxAttempts = 8
+	}
+	if d.minBackoff <= 0 {
+		d.minBackoff = 10 * time.Second
+	}
+	if d.maxBackoff < d.minBackoff {
+		d.maxBackoff = d.minBackoff
+	}
+	if d.batch <= 0 {
+		d.batch = 100
+	}
+	if d.concurrency <= 0 {
+		d.concurrency = 8
+	}
+	if d.logger == nil {
+		d.logger = zap.NewNop()
+	}
+	if d.tracer == nil {
+		d.tracer = otel.Tracer("go-service/internal/webhook")
+	}
+
+	client := http.Client{}
+	if config.Client != nil {
+		client = *config.Client
+	}
+	// a redirect is a failed delivery, following it would send the payload
+	// somewhere the subscriber didn't register
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	if !config.AllowPrivate {
+		switch transport := client.Transport.(type) {
+		case nil:
+			client.Transport = publicTransport(http.DefaultTransport.(*http.Transport))
+		case *http.Transport:
+			client.Transport = publicTransport(transport)
+		}
+	}
+	d.client = &client
+	return d
+}
+
+// Deliver sends every due delivery and returns how many were attempted.
+// The outcome of each attempt is recorded, failures of the receivers are
+// not errors of Deliver
+func (d *Dispatcher) Deliver(ctx context.Context) (int, error) {
+	var attempted int
+	for {
+		// the lease outlives every attempt of the batch, so no delivery is
+		// claimed twice while it is still being sent
+		lease := d.timeout*time.Duration((d.batch+d.concurrency-1)/d.concurrency) + d.timeout
+		due, err := d.repo.ClaimDue(ctx, d.batch, lease)
+		if err != nil {
+			return attempted, fmt.Errorf("claim deliveries: %w", err)
+		}
+
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(d.concurrency)
+		for _, delivery := range due {
+			delivery := delivery
+			g.Go(func() error {
+				return d.attempt(gctx, delivery)
+			})
+		}
+		err = g.Wait()
+		attempted += len(due)
+		if err != nil || len(due) < d.batch || ctx.Err() != nil {
+			return attempted, err
+		}
+	}
+}
+
+// attempt sends one delivery and records the outcome
+func (d *Dispatcher) attempt(ctx context.Context, delivery models.DueDelivery) error {
+	ctx, span := d.tracer.Start(ctx, "DeliverWebhook", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.Int("webhook.id", delivery.WebhookID),
+		attribute.Int("webhook.delivery_id", delivery.ID),
+		attribute.String("webhook.event", delivery.EventType),
+		attribute.Int("webhook.attempt", delivery.Attempts+1),
+	))
+	defer span.End()
+
+	status, retryAfter, err := d.send(ctx, delivery)
+	if ctx.Err() != nil {
+		// shutting down, the lease hands the delivery to the next run
+		return nil
+	}
+
+	result := models.DeliveryAttempt{ResponseStatus: status, NextAttemptAt: time.Now()}
+	switch {
+	case err == nil:
+		result.Status = models.DeliverySucceeded
+	case delivery.Attempts+1 >= d.maxAttempts:
+		result.Status = models.DeliveryDead
+		result.Error = err.Error()
+	default:
+		result.Status = models.DeliveryPending
+		result.NextAttemptAt = result.NextAttemptAt.Add(d.backoff(delivery.Attempts+1, retryAfter))
+		result.Error = err.Error()
+	}
+
+	p.WebhookDeliveriesTotal.WithLabelValues(resultLabel(result.Status)).Inc()
+	if err != nil {
+		span.RecordError(err)
+		d.logger.Warn("webhook delivery failed",
+			zap.Int("delivery_id", delivery.ID),
+			zap.Int("webhook_id", delivery.WebhookID),
+			zap.Int("attempt", delivery.Attempts+1),
+			zap.String("status", result.Status),
+			zap.Error(err),
+		)
+	}
+
+	if err := d.repo.RecordAttempt(context.WithoutCancel(ctx), delivery.ID, result); err != nil {
+		// the lease runs out and the delivery is sent again, receivers
+		// dedupe on HeaderID
+		d.logger.Error("failed to record webhook delivery",
+			zap.Int("delivery_id", delivery.ID),
+			zap.Error(err),
+		)
+	}
+	return nil
+}
+
+// send posts the payload and returns the response status and the
+// Retry-After the receiver asked for, an error for anything but 2xx
+func (d *Dispatcher) send(ctx context.Context, delivery models.DueDelivery) (int, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, 0, err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-service-webhooks")
+	req.Header.Set(HeaderID, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, now, delivery.Payload))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, errorBodyLimit))
+	// drain the rest so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, 0, nil
+	}
+	err = fmt.Errorf("receiver responded %s", resp.Status)
+	if len(bytes.TrimSpace(body)) > 0 {
+		err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(body))
+	}
+	return resp.StatusCode, retryAfter(resp), err
+}
+
+// backoff doubles from minBackoff up to maxBackoff with up to half of it
+// random, so deliveries that failed together don't retry together. A
+// longer Retry-After of the receiver wins, up to maxBackoff
+func (d *Dispatcher) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	wait := d.maxBackoff
+	if attempt < 32 {
+		if exp := d.minBackoff << (attempt - 1); exp > 0 && exp < d.maxBackoff {
+			wait = exp
+		}
+	}
+	wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+	if retryAfter > wait {
+		wait = min(retryAfter, d.maxBackoff)
+	}
+	return wait
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	value := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+func resultLabel(status string) string {
+	if status == models.DeliveryPending {
+		return "retry"
+	}
+	return status
+}
@@ -0,0 +1,217 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-service/internal/models"
+	"go-service/internal/repository"
+	"go-service/internal/webhook"
+)
+
+const secret = "whsec_test-secret-0123456789"
+
+// receiver is a webhook registered for a test server
+type receiver struct {
+	repo      repository.Webhooks
+	projectID int
+	webhookID int
+}
+
+func newReceiver(t *testing.T, url string) *receiver {
+	t.Helper()
+	ctx := context.Background()
+
+	repos := repository.NewMemory()
+	projectID, err := repos.Projects.Create(ctx, models.Project{Name: "webhooks"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhookID, err := repos.Webhooks.Create(ctx, projectID, models.Webhook{URL: url, Secret: secret, Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Webhooks.Enqueue(ctx, projectID, models.GoodsCreated, []byte(`{"goods_id":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	return &receiver{repo: repos.Webhooks, projectID: projectID, webhookID: webhookID}
+}
+
+// dispatcher delivers to the receiver, loopback test servers need AllowPrivate
+func (r *receiver) dispatcher(config webhook.Config) *webhook.Dispatcher {
+	config.Repo = r.repo
+	config.AllowPrivate = true
+	return webhook.NewDispatcher(config)
+}
+
+func (r *receiver) deliver(t *testing.T, d *webhook.Dispatcher) int {
+	t.Helper()
+	attempted, err := d.Deliver(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return attempted
+}
+
+func (r *receiver) delivery(t *testing.T) models.WebhookDelivery {
+	t.Helper()
+	deliveries, err := r.repo.GetDeliveries(context.Background(), r.webhookID, r.projectID, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries.Deliveries) != 1 {
+		t.Fatalf("%d deliveries, want 1", len(deliveries.Deliveries))
+	}
+	return deliveries.Deliveries[0]
+}
+
+func TestDeliverSigned(t *testing.T) {
+	var verified atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		if err := webhook.Verify(secret, req.Header, body, time.Minute); err != nil {
+			t.Errorf("verify: %v", err)
+		}
+		if req.Header.Get(webhook.HeaderEvent) != models.GoodsCreated {
+			t.Errorf("event header = %q", req.Header.Get(webhook.HeaderEvent))
+		}
+		if webhook.Verify("whsec_other-secret-0123456789", req.Header, body, time.Minute) == nil {
+			t.Error("signature verified with another secret")
+		}
+		verified.Store(true)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	r := newReceiver(t, server.URL)
+	if attempted := r.deliver(t, r.dispatcher(webhook.Config{})); attempted != 1 {
+		t.Fatalf("attempted %d deliveries, want 1", attempted)
+	}
+
+	delivery := r.delivery(t)
+	if !verified.Load() || delivery.Status != models.DeliverySucceeded || delivery.ResponseStatus != http.StatusNoContent {
+		t.Errorf("delivery = %+v, want succeeded with 204", delivery)
+	}
+}
+
+func TestDeliverBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		// the next attempt is due between min and max from now
+		min, max time.Duration
+	}{
+		{"server error", http.StatusInternalServerError, "", 30 * time.Second, time.Minute},
+		{"retry after", http.StatusServiceUnavailable, "600", 10 * time.Minute, 10 * time.Minute},
+		{"retry after date", http.StatusTooManyRequests, time.Now().Add(20 * time.Minute).UTC().Format(http.TimeFormat), 19 * time.Minute, 20 * time.Minute},
+		{"retry after over the limit", http.StatusServiceUnavailable, "7200", time.Hour, time.Hour},
+		{"retry after of a server error", http.StatusInternalServerError, "600", 30 * time.Second, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				http.Error(w, "try later", tt.status)
+			}))
+			defer server.Close()
+
+			r := newReceiver(t, server.URL)
+			start := time.Now()
+			r.deliver(t, r.dispatcher(webhook.Config{MinBackoff: time.Minute, MaxBackoff: time.Hour}))
+
+			delivery := r.delivery(t)
+			if delivery.Status != models.DeliveryPending || delivery.ResponseStatus != tt.status || delivery.Attempts != 1 {
+				t.Fatalf("delivery = %+v, want pending after 1 attempt", delivery)
+			}
+			if !strings.Contains(delivery.LastError, "try later") {
+				t.Errorf("last error = %q, want the response body", delivery.LastError)
+			}
+			wait := delivery.NextAttemptAt.Sub(start)
+			if wait < tt.min-time.Second || wait > tt.max+time.Second {
+				t.Errorf("next attempt in %v, want %v to %v", wait, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestDeliverDead(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	r := newReceiver(t, server.URL)
+	d := r.dispatcher(webhook.Config{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	for attempt := 1; attempt <= 3; attempt++ {
+		time.Sleep(5 * time.Millisecond)
+		if attempted := r.deliver(t, d); attempted != 1 {
+			t.Fatalf("attempt %d: attempted %d deliveries, want 1", attempt, attempted)
+		}
+	}
+
+	delivery := r.delivery(t)
+	if delivery.Status != models.DeliveryDead || delivery.Attempts != 3 {
+		t.Fatalf("delivery = %+v, want dead after 3 attempts", delivery)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if attempted := r.deliver(t, d); attempted != 0 || requests.Load() != 3 {
+		t.Errorf("dead delivery was sent again, %d requests", requests.Load())
+	}
+}
+
+func TestDeliverRedirect(t *testing.T) {
+	var redirected atomic.Bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		redirected.Store(true)
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	r := newReceiver(t, server.URL)
+	r.deliver(t, r.dispatcher(webhook.Config{}))
+
+	delivery := r.delivery(t)
+	if redirected.Load() {
+		t.Error("redirect was followed")
+	}
+	if delivery.Status != models.DeliveryPending || delivery.ResponseStatus != http.StatusTemporaryRedirect {
+		t.Errorf("delivery = %+v, want a failed attempt with 307", delivery)
+	}
+}
+
+func TestDeliverPrivate(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	// the host resolves to loopback only when connecting, like after DNS rebinding
+	_, port, _ := strings.Cut(strings.TrimPrefix(server.URL, "http://"), ":")
+	r := newReceiver(t, "http://localhost:"+port)
+	d := webhook.NewDispatcher(webhook.Config{Repo: r.repo})
+	r.deliver(t, d)
+
+	delivery := r.delivery(t)
+	if requests.Load() != 0 {
+		t.Error("delivery reached a loopback address")
+	}
+	if delivery.Status != models.DeliveryPending || !strings.Contains(delivery.LastError, webhook.ErrPrivateAddress.Error()) {
+		t.Errorf("delivery = %+v, want refused as private", delivery)
+	}
+}
@@ -0,0 +1,64 @@
+// Package webhook sends goods events to the webhooks of a project and signs
+// them, so receivers can tell the deliveries came from this service
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderID carries the delivery id, it stays the same across retries so
+	// receivers can drop duplicates
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrInvalidSignature = errors.New("webhook signature is invalid")
+	ErrExpired          = errors.New("webhook timestamp is outside the tolerance")
+)
+
+// Sign returns the signature header of body sent at timestamp: the hex
+// HMAC-SHA256 of "<unix seconds>.<body>" keyed with secret. Covering the
+// timestamp keeps captured deliveries from being replayed later
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature headers of a delivery the way receivers
+// should, tolerance bounds the age of the timestamp and 0 skips the check
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	timestamp := time.Unix(unix, 0)
+
+	signature := header.Get(HeaderSignature)
+	if !strings.HasPrefix(signature, signaturePrefix) ||
+		!hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		if age := time.Since(timestamp); age > tolerance || age < -tolerance {
+			return ErrExpired
+		}
+	}
+	return nil
+}
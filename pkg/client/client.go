@@ -0,0 +1,226 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "go-service/pkg/client"
+
+type Config struct {
+	// BaseURL of the service, e.g. http://localhost:8000
+	BaseURL string
+	// HTTPClient defaults to a client with a 10s timeout
+	HTTPClient *http.Client
+	Retry      RetryConfig
+}
+
+// RetryConfig applies to idempotent calls and to calls rejected by the rate
+// limit, zero values pick the defaults
+type RetryConfig struct {
+	// MaxAttempts counts the first attempt too, 1 turns retries off
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Client calls the HTTP API of go-service. Trace context of the caller is
+// propagated to the service
+type Client struct {
+	baseURL *url.URL
+	http    *http.Client
+	retry   RetryConfig
+	tracer  trace.Tracer
+}
+
+func New(config Config) (*Client, error) {
+	baseURL, err := url.Parse(config.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+	if baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("base url %q must be absolute", config.BaseURL)
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	retry := config.Retry
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = 3
+	}
+	if retry.MinBackoff <= 0 {
+		retry.MinBackoff = 100 * time.Millisecond
+	}
+	if retry.MaxBackoff <= 0 {
+		retry.MaxBackoff = 2 * time.Second
+	}
+
+	return &Client{
+		baseURL: baseURL,
+		http:    httpClient,
+		retry:   retry,
+		tracer:  otel.Tracer(tracerName),
+	}, nil
+}
+
+// call is one API request, route is the path template used as span name
+type call struct {
+	method      string
+	route       string
+	path        string
+	query       url.Values
+	body        any
+	contentType string
+}
+
+func (c *Client) do(ctx context.Context, req call, out any) error {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	u := c.baseURL.JoinPath(req.path)
+	u.RawQuery = req.query.Encode()
+
+	ctx, span := c.tracer.Start(ctx, req.method+" "+req.route,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.method),
+			semconv.HTTPRoute(req.route),
+			semconv.URLFull(u.String()),
+		),
+	)
+	defer span.End()
+
+	err := c.retryLoop(ctx, span, req, u.String(), body, out)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func (c *Client) retryLoop(ctx context.Context, span trace.Span, req call, u string, body []byte, out any) error {
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			span.SetAttributes(semconv.HTTPRequestResendCount(attempt - 1))
+		}
+
+		retryAfter, err := c.attempt(ctx, span, req, u, body, out)
+		if err == nil {
+			return nil
+		}
+		if attempt >= c.retry.MaxAttempts || !retryable(req.method, err) {
+			return err
+		}
+
+		wait := max(c.backoff(attempt), retryAfter)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// attempt sends the request once, the duration is the Retry-After of the response
+func (c *Client) attempt(ctx context.Context, span trace.Span, req call, u string, body []byte, out any) (time.Duration, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return 0, ctxErr
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return retryAfter(resp), decodeError(resp)
+	}
+
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return 0, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, fmt.Errorf("decode response: %w", err)
+	}
+	return 0, nil
+}
+
+// retryable reports whether the failed request may be sent again. Requests
+// rejected by the rate limit never reached the handler, anything else is
+// only retried for idempotent methods
+func retryable(method string, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if method != http.MethodGet && method != http.MethodDelete {
+		return false
+	}
+	if apiErr == nil {
+		// transport errors
+		return true
+	}
+	switch apiErr.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff is exponential with full jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.retry.MinBackoff << (attempt - 1)
+	if d <= 0 || d > c.retry.MaxBackoff {
+		d = c.retry.MaxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,95 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"go-service/internal/app"
+	"go-service/internal/config"
+	"go-service/pkg/client"
+)
+
+func TestUpdateGoodsJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input client.UpdateGoods
+		want  string
+	}{
+		{"nothing set", client.UpdateGoods{}, `{}`},
+		{"values", client.UpdateGoods{Name: client.Set("apples"), Priority: client.Set(2), Removed: client.Set(false)}, `{"name":"apples","priority":2,"removed":false}`},
+		{"null", client.UpdateGoods{Description: client.Null[string]()}, `{"description":null}`},
+		{"empty string", client.UpdateGoods{Description: client.Set("")}, `{"description":""}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("json = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestClient runs the client against the demo profile, everything in memory
+func TestClient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	cfg, err := config.Load(config.Options{Dir: "../../configs", Profile: "demo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := app.NewApp(ctx, app.Options{Config: cfg, Logger: zap.NewNop(), Registry: prometheus.NewRegistry()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close(ctx)
+	server := httptest.NewServer(a.Server.Handler)
+	defer server.Close()
+
+	c, err := client.New(client.Config{BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := c.CreateProject(ctx, client.CreateProject{Name: "sdk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err = c.UpdateProject(ctx, project.ID, client.UpdateProject{Name: client.Set("renamed")})
+	if err != nil || project.Name != "renamed" {
+		t.Fatalf("UpdateProject = %+v, %v, want renamed", project, err)
+	}
+
+	goods, err := c.CreateGoods(ctx, project.ID, client.CreateGoods{Name: "apples", Description: "green"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	goods, err = c.UpdateGoods(ctx, project.ID, goods.ID, client.UpdateGoods{Description: client.Null[string]()})
+	if err != nil || goods.Description != "" || goods.Name != "apples" {
+		t.Fatalf("UpdateGoods with a null description = %+v, %v, want it cleared", goods, err)
+	}
+
+	list, err := c.ListGoods(ctx, client.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Meta.Total != 1 || len(list.Goods) != 1 || list.Goods[0].ID != goods.ID {
+		t.Errorf("ListGoods = %+v, want the one goods", list)
+	}
+
+	_, err = c.UpdateGoods(ctx, project.ID, goods.ID, client.UpdateGoods{Name: client.Null[string]()})
+	if err == nil {
+		t.Error("UpdateGoods with a null name succeeded")
+	}
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Error is a problem details document (RFC 7807) returned by the API
+type Error struct {
+	StatusCode int    `json:"status"`
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Detail     string `json:"detail"`
+	Instance   string `json:"instance"`
+	// Code is stable and meant for programs, e.g. "goods.not_found"
+	Code    string       `json:"code"`
+	Errors  []FieldError `json:"errors"`
+	TraceID string       `json:"trace_id"`
+}
+
+// FieldError describes one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Detail
+	if msg == "" {
+		msg = e.Title
+	}
+	if e.Code == "" {
+		return fmt.Sprintf("go-service: %d: %s", e.StatusCode, msg)
+	}
+	return fmt.Sprintf("go-service: %d %s: %s", e.StatusCode, e.Code, msg)
+}
+
+// decodeError reads a problem document, bodies of proxies and the like are
+// reported by status only
+func decodeError(resp *http.Response) error {
+	e := &Error{}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err == nil {
+		_ = json.Unmarshal(data, e)
+	}
+
+	e.StatusCode = resp.StatusCode
+	if e.Title == "" {
+		e.Title = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
+
+// StatusCode returns the HTTP status of an API error, 0 for other errors
+func StatusCode(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+func IsValidation(err error) bool {
+	return StatusCode(err) == http.StatusBadRequest
+}
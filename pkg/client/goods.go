@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func (c *Client) CreateGoods(ctx context.Context, projectID int, input CreateGoods) (Goods, error) {
+	var goods Goods
+	err := c.do(ctx, call{
+		method:      http.MethodPost,
+		route:       "/api/goods/{project_id}",
+		path:        fmt.Sprintf("/api/goods/%d", projectID),
+		body:        input,
+		contentType: "application/json",
+	}, &goods)
+	return goods, err
+}
+
+func (c *Client) ListGoods(ctx context.Context, opts ListOptions) (GoodsList, error) {
+	var goods GoodsList
+	err := c.do(ctx, call{
+		method: http.MethodGet,
+		route:  "/api/goods/list",
+		path:   "/api/goods/list",
+		query:  opts.values(),
+	}, &goods)
+	return goods, err
+}
+
+func (c *Client) GetGoods(ctx context.Context, projectID, goodsID int) (Goods, error) {
+	var goods Goods
+	err := c.do(ctx, call{
+		method: http.MethodGet,
+		route:  "/api/goods/{project_id}/{id}",
+		path:   fmt.Sprintf("/api/goods/%d/%d", projectID, goodsID),
+	}, &goods)
+	return goods, err
+}
+
+// UpdateGoods returns the goods after the update
+func (c *Client) UpdateGoods(ctx context.Context, projectID, goodsID int, input UpdateGoods) (Goods, error) {
+	var goods Goods
+	err := c.do(ctx, call{
+		method:      http.MethodPatch,
+		route:       "/api/goods/{project_id}/{id}",
+		path:        fmt.Sprintf("/api/goods/%d/%d", projectID, goodsID),
+		body:        input,
+		contentType: mergePatchContentType,
+	}, &goods)
+	return goods, err
+}
+
+// DeleteGoods marks goods as removed and returns them
+func (c *Client) DeleteGoods(ctx context.Context, projectID, goodsID int) (Goods, error) {
+	var goods Goods
+	err := c.do(ctx, call{
+		method: http.MethodDelete,
+		route:  "/api/goods/{project_id}/{id}",
+		path:   fmt.Sprintf("/api/goods/%d/%d", projectID, goodsID),
+	}, &goods)
+	return goods, err
+}
+
+// ReprioritizeGoods moves goods to priority and the other goods of the project down
+func (c *Client) ReprioritizeGoods(ctx context.Context, projectID, goodsID, priority int) (Goods, error) {
+	var goods Goods
+	err := c.do(ctx, call{
+		method: http.MethodPatch,
+		route:  "/api/goods/prioritize/{project_id}/{id}",
+		path:   fmt.Sprintf("/api/goods/prioritize/%d/%d", projectID, goodsID),
+		query:  url.Values{"priority": {strconv.Itoa(priority)}},
+	}, &goods)
+	return goods, err
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// The types below are the JSON the API sends and accepts. They are declared
+// here rather than shared with the service, so its internals can change
+// without breaking callers
+
+type Project struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ProjectList struct {
+	Meta     ProjectListMeta `json:"meta"`
+	Projects []Project       `json:"projects"`
+}
+
+type ProjectListMeta struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+type Goods struct {
+	ID          int       `json:"id"`
+	ProjectID   int       `json:"project_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Priority    int       `json:"priority"`
+	Removed     bool      `json:"removed"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type GoodsList struct {
+	Meta  GoodsListMeta `json:"meta"`
+	Goods []Goods       `json:"goods"`
+}
+
+type GoodsListMeta struct {
+	Total int `json:"total"`
+	// Removed counts the removed goods among Total
+	Removed int `json:"removed"`
+	Limit   int `json:"limit"`
+	Offset  int `json:"offset"`
+}
+
+// ListOptions pages list calls, zero values pick the server defaults
+type ListOptions struct {
+	Limit  int
+	Offset int
+}
+
+func (o ListOptions) values() url.Values {
+	query := url.Values{}
+	if o.Limit != 0 {
+		query.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset != 0 {
+		query.Set("offset", strconv.Itoa(o.Offset))
+	}
+	return query
+}
+
+type CreateProject struct {
+	Name string `json:"name"`
+}
+
+// UpdateProject is a merge patch, unset fields are left unchanged
+type UpdateProject struct {
+	Name Value[string]
+}
+
+func (u UpdateProject) MarshalJSON() ([]byte, error) {
+	doc := map[string]any{}
+	u.Name.put(doc, "name")
+	return json.Marshal(doc)
+}
+
+type CreateGoods struct {
+	Name string `json:"name"`
+	// Description defaults to the name
+	Description string `json:"description,omitempty"`
+}
+
+// UpdateGoods is a merge patch, unset fields are left unchanged. A null
+// description clears it, the other fields can't be null. A new priority
+// moves the other goods of the project down
+type UpdateGoods struct {
+	Name        Value[string]
+	Description Value[string]
+	Priority    Value[int]
+	Removed     Value[bool]
+}
+
+func (u UpdateGoods) MarshalJSON() ([]byte, error) {
+	doc := map[string]any{}
+	u.Name.put(doc, "name")
+	u.Description.put(doc, "description")
+	u.Priority.put(doc, "priority")
+	u.Removed.put(doc, "removed")
+	return json.Marshal(doc)
+}
+
+// Value is a member of a merge patch. The zero Value leaves the member out,
+// Set sends a value and Null sends null
+type Value[T any] struct {
+	set   bool
+	null  bool
+	value T
+}
+
+// Set returns a Value that sends v
+func Set[T any](v T) Value[T] {
+	return Value[T]{set: true, value: v}
+}
+
+// Null returns a Value that sends null
+func Null[T any]() Value[T] {
+	return Value[T]{set: true, null: true}
+}
+
+func (v Value[T]) put(doc map[string]any, name string) {
+	switch {
+	case v.null:
+		doc[name] = nil
+	case v.set:
+		doc[name] = v.value
+	}
+}
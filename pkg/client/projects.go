@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+func (c *Client) CreateProject(ctx context.Context, input CreateProject) (Project, error) {
+	var project Project
+	err := c.do(ctx, call{
+		method:      http.MethodPost,
+		route:       "/api/projects/",
+		path:        "/api/projects/",
+		body:        input,
+		contentType: "application/json",
+	}, &project)
+	return project, err
+}
+
+func (c *Client) ListProjects(ctx context.Context, opts ListOptions) (ProjectList, error) {
+	var projects ProjectList
+	err := c.do(ctx, call{
+		method: http.MethodGet,
+		route:  "/api/projects/",
+		path:   "/api/projects/",
+		query:  opts.values(),
+	}, &projects)
+	return projects, err
+}
+
+func (c *Client) GetProject(ctx context.Context, projectID int) (Project, error) {
+	var project Project
+	err := c.do(ctx, call{
+		method: http.MethodGet,
+		route:  "/api/projects/{project_id}",
+		path:   fmt.Sprintf("/api/projects/%d", projectID),
+	}, &project)
+	return project, err
+}
+
+// UpdateProject returns the project after the update
+func (c *Client) UpdateProject(ctx context.Context, projectID int, input UpdateProject) (Project, error) {
+	var project Project
+	err := c.do(ctx, call{
+		method:      http.MethodPatch,
+		route:       "/api/projects/{project_id}",
+		path:        fmt.Sprintf("/api/projects/%d", projectID),
+		body:        input,
+		contentType: mergePatchContentType,
+	}, &project)
+	return project, err
+}
+
+// DeleteProject fails with a conflict while the project still has goods
+func (c *Client) DeleteProject(ctx context.Context, projectID int) error {
+	return c.do(ctx, call{
+		method: http.MethodDelete,
+		route:  "/api/projects/{project_id}",
+		path:   fmt.Sprintf("/api/projects/%d", projectID),
+	}, nil)
+}
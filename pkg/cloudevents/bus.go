@@ -0,0 +1,133 @@
+package cloudevents
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"time"
+
+	"go-service/pkg/eventbus"
+)
+
+// jsonContentType is the datacontenttype of the payloads
+const jsonContentType = "application/json"
+
+// Payload is the data of an event that knows its attributes
+type Payload interface {
+	// EventID is unique per event, empty gets a random ID
+	EventID() string
+	EventType() string
+	EventTime() time.Time
+}
+
+type BusConfig struct {
+	Bus eventbus.Bus
+	// Source is the source attribute of the published events
+	Source string
+	// Mode defaults to ModeBinary
+	Mode Mode
+	// Schema returns the dataschema of an event type, payloads of types
+	// without one are published without an envelope
+	Schema func(eventType string) (string, bool)
+}
+
+// Bus wraps the payloads with a schema in CloudEvents and hands
+// subscribers the payload of events in either mode, with the attributes as
+// ce- headers. Messages without an envelope, from older producers or
+// internal traffic, pass as they are
+type Bus struct {
+	next   eventbus.Bus
+	config BusConfig
+}
+
+func NewBus(config BusConfig) *Bus {
+	if config.Mode == "" {
+		config.Mode = ModeBinary
+	}
+	if config.Schema == nil {
+		config.Schema = func(string) (string, bool) { return "", false }
+	}
+	return &Bus{next: config.Bus, config: config}
+}
+
+func (b *Bus) Publish(ctx context.Context, subject string, data interface{}) error {
+	payload, ok := data.(Payload)
+	if !ok {
+		return b.next.Publish(ctx, subject, data)
+	}
+	schema, ok := b.config.Schema(payload.EventType())
+	if !ok {
+		return b.next.Publish(ctx, subject, data)
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	e := Event{
+		SpecVersion:     SpecVersion,
+		ID:              payload.EventID(),
+		Source:          b.config.Source,
+		Type:            payload.EventType(),
+		Time:            payload.EventTime().UTC(),
+		DataContentType: jsonContentType,
+		DataSchema:      schema,
+		Data:            body,
+	}
+	if e.ID == "" {
+		e.ID = newID()
+	}
+	header, body, err := e.Encode(b.config.Mode)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", e.Type, err)
+	}
+	return b.next.Publish(ctx, subject, eventbus.Raw{Header: header, Data: body, ID: e.ID})
+}
+
+func (b *Bus) Subscribe(ctx context.Context, subject string, handler eventbus.Handler) error {
+	return b.next.Subscribe(ctx, subject, unwrap(handler))
+}
+
+func (b *Bus) SubscribeGroup(ctx context.Context, subject string, group eventbus.Group, handler eventbus.Handler) error {
+	return b.next.SubscribeGroup(ctx, subject, group, unwrap(handler))
+}
+
+// unwrap hands handler the payload of the event, a broken envelope won't
+// get better by delivering it again
+func unwrap(handler eventbus.Handler) eventbus.Handler {
+	return func(ctx context.Context, msg eventbus.Message) error {
+		e, err := Decode(msg.Header, msg.Data)
+		if errors.Is(err, ErrNotEvent) {
+			return handler(ctx, msg)
+		}
+		if err != nil {
+			return eventbus.Permanent(fmt.Errorf("invalid event on %s: %w", msg.Subject, err))
+		}
+
+		attributes, data, err := e.Encode(ModeBinary)
+		if err != nil {
+			return eventbus.Permanent(fmt.Errorf("invalid event on %s: %w", msg.Subject, err))
+		}
+		msg.Header = maps.Clone(msg.Header)
+		if msg.Header == nil {
+			msg.Header = make(map[string]string, len(attributes))
+		}
+		maps.Copy(msg.Header, attributes)
+		msg.Data = data
+		return handler(ctx, msg)
+	}
+}
+
+// newID returns a random ID, 128 bits keep collisions out of deduplication windows
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
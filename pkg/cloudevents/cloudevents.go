@@ -0,0 +1,152 @@
+// Package cloudevents wraps events in CloudEvents 1.0 envelopes, in the
+// structured and binary content modes of the NATS protocol binding
+package cloudevents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const SpecVersion = "1.0"
+
+// Mode is the content mode of the envelope
+type Mode string
+
+const (
+	// ModeBinary keeps the payload as the body and the attributes in ce-
+	// headers, consumers unaware of CloudEvents read the payload as before
+	ModeBinary Mode = "binary"
+	// ModeStructured puts the attributes and the payload in one JSON body
+	ModeStructured Mode = "structured"
+)
+
+const (
+	// ContentType is the content type of structured events
+	ContentType = "application/cloudevents+json"
+	// HeaderContentType carries the content type in both modes
+	HeaderContentType = "content-type"
+	// HeaderPrefix comes before the attribute names in binary mode
+	HeaderPrefix = "ce-"
+)
+
+var ErrNotEvent = errors.New("not a cloudevent")
+
+// Event is a CloudEvent with a JSON payload
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// Validate checks the attributes the specification requires
+func (e Event) Validate() error {
+	var errs []error
+	if e.SpecVersion != SpecVersion {
+		errs = append(errs, fmt.Errorf("unsupported specversion %q", e.SpecVersion))
+	}
+	if e.ID == "" {
+		errs = append(errs, errors.New("id is required"))
+	}
+	if e.Source == "" {
+		errs = append(errs, errors.New("source is required"))
+	}
+	if e.Type == "" {
+		errs = append(errs, errors.New("type is required"))
+	}
+	return errors.Join(errs...)
+}
+
+// Encode returns the headers and the body of the event in mode
+func (e Event) Encode(mode Mode) (map[string]string, []byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	switch mode {
+	case ModeStructured:
+		body, err := json.Marshal(e)
+		if err != nil {
+			return nil, nil, err
+		}
+		return map[string]string{HeaderContentType: ContentType + "; charset=utf-8"}, body, nil
+	case ModeBinary:
+		header := map[string]string{
+			HeaderPrefix + "specversion": e.SpecVersion,
+			HeaderPrefix + "id":          e.ID,
+			HeaderPrefix + "source":      e.Source,
+			HeaderPrefix + "type":        e.Type,
+		}
+		optional := map[string]string{
+			HeaderPrefix + "subject":    e.Subject,
+			HeaderPrefix + "dataschema": e.DataSchema,
+			HeaderContentType:           e.DataContentType,
+		}
+		if !e.Time.IsZero() {
+			optional[HeaderPrefix+"time"] = e.Time.Format(time.RFC3339Nano)
+		}
+		for key, value := range optional {
+			if value != "" {
+				header[key] = value
+			}
+		}
+		return header, e.Data, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown content mode %q", mode)
+	}
+}
+
+// Decode reads an event in either mode, ErrNotEvent means the message
+// carries no envelope
+func Decode(header map[string]string, body []byte) (Event, error) {
+	if contentType := lookup(header, HeaderContentType); strings.HasPrefix(contentType, ContentType) {
+		var e Event
+		if err := json.Unmarshal(body, &e); err != nil {
+			return Event{}, fmt.Errorf("decode structured event: %w", err)
+		}
+		return e, e.Validate()
+	}
+
+	specVersion := lookup(header, HeaderPrefix+"specversion")
+	if specVersion == "" {
+		return Event{}, ErrNotEvent
+	}
+	e := Event{
+		SpecVersion:     specVersion,
+		ID:              lookup(header, HeaderPrefix+"id"),
+		Source:          lookup(header, HeaderPrefix+"source"),
+		Type:            lookup(header, HeaderPrefix+"type"),
+		Subject:         lookup(header, HeaderPrefix+"subject"),
+		DataContentType: lookup(header, HeaderContentType),
+		DataSchema:      lookup(header, HeaderPrefix+"dataschema"),
+		Data:            body,
+	}
+	if t := lookup(header, HeaderPrefix+"time"); t != "" {
+		var err error
+		if e.Time, err = time.Parse(time.RFC3339Nano, t); err != nil {
+			return Event{}, fmt.Errorf("decode binary event time: %w", err)
+		}
+	}
+	return e, e.Validate()
+}
+
+// lookup ignores the case of key, brokers and proxies may change it
+func lookup(header map[string]string, key string) string {
+	if value, ok := header[key]; ok {
+		return value
+	}
+	for k, value := range header {
+		if strings.EqualFold(k, key) {
+			return value
+		}
+	}
+	return ""
+}
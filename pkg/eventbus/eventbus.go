@@ -0,0 +1,141 @@
+// Package eventbus publishes events and hands them to subscribers and
+// consumer groups independently of the broker behind it
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Message is one event as a subscriber sees it
+type Message struct {
+	Subject string
+	Data    []byte
+	// Header carries the trace context next to the payload
+	Header map[string]string
+	// Delivery counts the deliveries of the message to a group, the first is 1
+	Delivery int
+}
+
+// Raw is published as it is instead of being encoded as JSON, Header goes
+// next to the trace context
+type Raw struct {
+	Header map[string]string
+	Data   []byte
+	// ID deduplicates the message on backends that support it
+	ID string
+}
+
+// encode returns the payload and the headers of data
+func encode(data interface{}) (Raw, error) {
+	if raw, ok := data.(Raw); ok {
+		return raw, nil
+	}
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return Raw{}, err
+	}
+	return Raw{Data: dataBytes}, nil
+}
+
+// Handler handles one message. For groups a nil error acknowledges the
+// message and any other error delivers it again, see Retry and Permanent
+type Handler func(ctx context.Context, msg Message) error
+
+// Group is a consumer group, every message goes to one of its subscribers.
+// Backends that can keep messages do so while no subscriber runs
+type Group struct {
+	Name string
+	// AckWait is how long a message may go unanswered before it is
+	// delivered again
+	AckWait time.Duration
+	// MaxDeliver is how many deliveries a message gets, 0 is no limit
+	MaxDeliver int
+	// Backoff delays the next delivery after a failure, the last step repeats
+	Backoff []time.Duration
+	// DeadLetter is the subject of messages that ran out of deliveries,
+	// empty drops them
+	DeadLetter string
+}
+
+// Bus is the message bus every producer and consumer of the service uses.
+// Subjects are dot separated, subscriptions may use the * and > wildcards
+// of NATS. Subscriptions end when their ctx is done
+type Bus interface {
+	// Publish sends data encoded as JSON, Raw is sent as it is
+	Publish(ctx context.Context, subject string, data interface{}) error
+	// Subscribe hands every message to handler, errors are only logged
+	Subscribe(ctx context.Context, subject string, handler Handler) error
+	// SubscribeGroup hands every message to one subscriber of the group
+	SubscribeGroup(ctx context.Context, subject string, group Group, handler Handler) error
+}
+
+type retryError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryError) Error() string { return e.err.Error() }
+func (e *retryError) Unwrap() error { return e.err }
+
+// Retry delivers the message again after delay instead of the backoff of
+// the group
+func Retry(err error, delay time.Duration) error {
+	return &retryError{err: err, delay: delay}
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent dead-letters the message right away, delivering it again won't help
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// nextDelay is the delay before the next delivery after a failed one
+func nextDelay(group Group, delivery int, err error) time.Duration {
+	var retry *retryError
+	if errors.As(err, &retry) {
+		return retry.delay
+	}
+	if len(group.Backoff) == 0 || delivery < 1 {
+		return 0
+	}
+	return group.Backoff[min(delivery, len(group.Backoff))-1]
+}
+
+// exhausted reports whether a failed message should be dead-lettered
+func exhausted(group Group, delivery int, err error) bool {
+	return isPermanent(err) || group.MaxDeliver > 0 && delivery >= group.MaxDeliver
+}
+
+// Match reports whether pattern matches subject the way NATS does. A
+// subject with wildcards matches when pattern matches every subject it does
+func Match(pattern, subject string) bool {
+	patterns, subjects := strings.Split(pattern, "."), strings.Split(subject, ".")
+	for i, token := range patterns {
+		switch {
+		case token == ">":
+			return i < len(subjects)
+		case i >= len(subjects):
+			return false
+		case token == "*":
+			if subjects[i] == ">" {
+				return false
+			}
+		case token != subjects[i]:
+			return false
+		}
+	}
+	return len(patterns) == len(subjects)
+}
@@ -0,0 +1,212 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	n "go-service/pkg/nats"
+	p "go-service/pkg/prometheus"
+)
+
+const memorySystem = "memory"
+
+// MemoryBus delivers within the process, for tests and single instance
+// setups. Groups keep their messages while no subscriber runs, until the
+// process exits
+type MemoryBus struct {
+	logger *zap.Logger
+
+	mu     sync.Mutex
+	subs   map[*mailbox]string
+	groups map[string]*memoryGroup
+}
+
+type memoryGroup struct {
+	pattern string
+	config  Group
+	queue   *mailbox
+}
+
+func NewMemoryBus(logger *zap.Logger) *MemoryBus {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &MemoryBus{
+		logger: logger,
+		subs:   make(map[*mailbox]string),
+		groups: make(map[string]*memoryGroup),
+	}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, subject string, data interface{}) error {
+	raw, err := encode(data)
+	if err != nil {
+		return err
+	}
+
+	header := maps.Clone(raw.Header)
+	if header == nil {
+		header = make(map[string]string)
+	}
+	_, span := startPublish(ctx, memorySystem, subject, header, len(raw.Data))
+	defer span.End()
+
+	b.publish(Message{Subject: subject, Data: raw.Data, Header: header, Delivery: 1})
+	return nil
+}
+
+func (b *MemoryBus) publish(msg Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for queue, pattern := range b.subs {
+		if Match(pattern, msg.Subject) {
+			queue.push(clone(msg))
+		}
+	}
+	for _, group := range b.groups {
+		if Match(group.pattern, msg.Subject) {
+			group.queue.push(clone(msg))
+		}
+	}
+}
+
+func (b *MemoryBus) Subscribe(ctx context.Context, subject string, handler Handler) error {
+	queue := newMailbox()
+	b.mu.Lock()
+	b.subs[queue] = subject
+	b.mu.Unlock()
+
+	go func() {
+		for {
+			msg, ok := queue.pop(ctx)
+			if !ok {
+				b.mu.Lock()
+				delete(b.subs, queue)
+				b.mu.Unlock()
+				return
+			}
+			if err := handle(ctx, memorySystem, subject, msg, handler); err != nil {
+				b.logger.Error("failed to handle message", zap.String("subject", msg.Subject), zap.Error(err))
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *MemoryBus) SubscribeGroup(ctx context.Context, subject string, group Group, handler Handler) error {
+	b.mu.Lock()
+	g, ok := b.groups[group.Name]
+	if !ok {
+		g = &memoryGroup{pattern: subject, config: group, queue: newMailbox()}
+		b.groups[group.Name] = g
+	}
+	b.mu.Unlock()
+	if g.pattern != subject {
+		return fmt.Errorf("group %s already subscribes to %s", group.Name, g.pattern)
+	}
+
+	go func() {
+		for {
+			msg, ok := g.queue.pop(ctx)
+			if !ok {
+				return
+			}
+			b.deliver(ctx, g, msg, handler)
+		}
+	}()
+	return nil
+}
+
+func (b *MemoryBus) deliver(ctx context.Context, g *memoryGroup, msg Message, handler Handler) {
+	err := handle(ctx, memorySystem, g.pattern, msg, handler)
+	switch {
+	case err == nil:
+		p.BusMessagesTotal.WithLabelValues(g.config.Name, "ack").Inc()
+	case exhausted(g.config, msg.Delivery, err):
+		b.logger.Warn("dead-lettering message",
+			zap.String("group", g.config.Name),
+			zap.Int("deliveries", msg.Delivery),
+			zap.Error(err),
+		)
+		if g.config.DeadLetter != "" {
+			b.publish(deadLetter(g.config, msg, err))
+		}
+		p.BusMessagesTotal.WithLabelValues(g.config.Name, "dead_letter").Inc()
+	default:
+		msg.Delivery++
+		time.AfterFunc(nextDelay(g.config, msg.Delivery-1, err), func() {
+			g.queue.push(msg)
+		})
+		p.BusMessagesTotal.WithLabelValues(g.config.Name, "retry").Inc()
+	}
+}
+
+// deadLetter is msg on the dead-letter subject of group, the headers tell
+// where it came from and why it failed
+func deadLetter(group Group, msg Message, cause error) Message {
+	dl := clone(msg)
+	dl.Subject = group.DeadLetter
+	dl.Delivery = 1
+	dl.Header[n.HeaderDeadLetterSubject] = msg.Subject
+	dl.Header[n.HeaderDeadLetterConsumer] = group.Name
+	dl.Header[n.HeaderDeadLetterDeliveries] = strconv.Itoa(msg.Delivery)
+	dl.Header[n.HeaderDeadLetterError] = cause.Error()
+	return dl
+}
+
+func clone(msg Message) Message {
+	msg.Header = maps.Clone(msg.Header)
+	if msg.Header == nil {
+		msg.Header = make(map[string]string)
+	}
+	return msg
+}
+
+// mailbox is an unbounded queue, publishers never wait for subscribers
+type mailbox struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	items []Message
+}
+
+func newMailbox() *mailbox {
+	m := &mailbox{}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+func (m *mailbox) push(msg Message) {
+	m.mu.Lock()
+	m.items = append(m.items, msg)
+	m.mu.Unlock()
+	m.cond.Signal()
+}
+
+// pop waits for the next message, false once ctx is done
+func (m *mailbox) pop(ctx context.Context) (Message, bool) {
+	stop := context.AfterFunc(ctx, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.cond.Broadcast()
+	})
+	defer stop()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for len(m.items) == 0 && ctx.Err() == nil {
+		m.cond.Wait()
+	}
+	if ctx.Err() != nil {
+		return Message{}, false
+	}
+	msg := m.items[0]
+	m.items = m.items[1:]
+	return msg, true
+}
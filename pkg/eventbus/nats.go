@@ -0,0 +1,140 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+
+	n "go-service/pkg/nats"
+)
+
+type NatsConfig struct {
+	Conn *nats.Conn
+	// JetStream keeps the subjects of Durable, nil leaves everything to core NATS
+	JetStream *n.JetStream
+	// Durable are the subjects of the JetStream streams
+	Durable []string
+	Logger  *zap.Logger
+}
+
+// NatsBus uses JetStream for the subjects a stream keeps and core NATS for
+// the rest. Core NATS groups are queue groups, a failed message is logged
+// and not delivered again
+type NatsBus struct {
+	client  *n.NatsClient
+	js      *n.JetStream
+	durable []string
+	logger  *zap.Logger
+}
+
+func NewNatsBus(config NatsConfig) *NatsBus {
+	b := &NatsBus{
+		client:  n.NewNatsClient(config.Conn),
+		js:      config.JetStream,
+		durable: config.Durable,
+		logger:  config.Logger,
+	}
+	if b.js == nil {
+		b.durable = nil
+	}
+	if b.logger == nil {
+		b.logger = zap.NewNop()
+	}
+	return b
+}
+
+// Publish waits for a stream to store messages on durable subjects
+func (b *NatsBus) Publish(ctx context.Context, subject string, data interface{}) error {
+	raw, err := encode(data)
+	if err != nil {
+		return err
+	}
+
+	msg := &nats.Msg{Subject: subject, Data: raw.Data, Header: make(nats.Header, len(raw.Header))}
+	for key, value := range raw.Header {
+		// set as is, CloudEvents headers are lower case
+		msg.Header[key] = []string{value}
+	}
+	if !b.isDurable(subject) {
+		return b.client.PublishMsg(ctx, msg)
+	}
+
+	id := raw.ID
+	if m, ok := data.(n.MessageIDer); ok {
+		id = m.MessageID()
+	}
+	return b.js.PublishMsg(ctx, msg, id)
+}
+
+func (b *NatsBus) Subscribe(ctx context.Context, subject string, handler Handler) error {
+	return b.client.Subscribe(ctx, subject, func(ctx context.Context, msg *nats.Msg) {
+		if err := handler(ctx, natsMessage(msg.Subject, msg.Data, msg.Header, 1)); err != nil {
+			b.logger.Error("failed to handle message", zap.String("subject", msg.Subject), zap.Error(err))
+		}
+	})
+}
+
+// SubscribeGroup creates a durable consumer named after the group when a
+// stream keeps subject, otherwise the group is a queue group
+func (b *NatsBus) SubscribeGroup(ctx context.Context, subject string, group Group, handler Handler) error {
+	if !b.isDurable(subject) {
+		return b.client.QueueSubscribe(ctx, subject, group.Name, func(ctx context.Context, msg *nats.Msg) {
+			if err := handler(ctx, natsMessage(msg.Subject, msg.Data, msg.Header, 1)); err != nil {
+				b.logger.Error("failed to handle message",
+					zap.String("subject", msg.Subject),
+					zap.String("group", group.Name),
+					zap.Error(err),
+				)
+			}
+		})
+	}
+
+	return b.js.Consume(ctx, n.ConsumerConfig{
+		Durable:        group.Name,
+		FilterSubjects: []string{subject},
+		AckWait:        group.AckWait,
+		MaxDeliver:     group.MaxDeliver,
+		Backoff:        group.Backoff,
+		DeadLetter:     group.DeadLetter,
+	}, func(ctx context.Context, msg jetstream.Msg) error {
+		delivery := 1
+		if meta, err := msg.Metadata(); err == nil {
+			delivery = int(meta.NumDelivered)
+		}
+		return natsError(handler(ctx, natsMessage(msg.Subject(), msg.Data(), msg.Headers(), delivery)))
+	})
+}
+
+func (b *NatsBus) isDurable(subject string) bool {
+	for _, pattern := range b.durable {
+		if Match(pattern, subject) {
+			return true
+		}
+	}
+	return false
+}
+
+func natsMessage(subject string, data []byte, header nats.Header, delivery int) Message {
+	msg := Message{Subject: subject, Data: data, Header: make(map[string]string, len(header)), Delivery: delivery}
+	for key := range header {
+		msg.Header[key] = header.Get(key)
+	}
+	return msg
+}
+
+// natsError passes the retry decisions of the handler on to pkg/nats
+func natsError(err error) error {
+	var retry *retryError
+	switch {
+	case err == nil:
+		return nil
+	case isPermanent(err):
+		return n.Permanent(err)
+	case errors.As(err, &retry):
+		return n.Retry(err, retry.delay)
+	}
+	return err
+}
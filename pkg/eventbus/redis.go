@@ -0,0 +1,394 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.uber.org/zap"
+
+	p "go-service/pkg/prometheus"
+)
+
+const (
+	redisSystem = "redis"
+
+	defaultStreamPrefix = "bus:"
+	defaultMaxLen       = 100000
+	defaultBlock        = 2 * time.Second
+	defaultAckWait      = 30 * time.Second
+	// readCount is how many messages one read takes
+	readCount = 64
+)
+
+type RedisConfig struct {
+	Client *redis.Client
+	// Prefix comes before the stream keys. Subjects share a stream by their
+	// first token, goods.events.1 is stored in bus:goods
+	Prefix string
+	// MaxLen trims the streams to about this many messages
+	MaxLen int64
+	// Block is how long a read waits for messages, it bounds how late
+	// subscriptions notice their ctx is done and retries are due
+	Block  time.Duration
+	Logger *zap.Logger
+}
+
+// RedisBus keeps messages in Redis Streams. Subscriptions only see messages
+// published after they start, groups keep theirs until they are acknowledged
+type RedisBus struct {
+	client *redis.Client
+	prefix string
+	maxLen int64
+	block  time.Duration
+	logger *zap.Logger
+}
+
+func NewRedisBus(config RedisConfig) *RedisBus {
+	b := &RedisBus{
+		client: config.Client,
+		prefix: config.Prefix,
+		maxLen: config.MaxLen,
+		block:  config.Block,
+		logger: config.Logger,
+	}
+	if b.prefix == "" {
+		b.prefix = defaultStreamPrefix
+	}
+	if b.maxLen <= 0 {
+		b.maxLen = defaultMaxLen
+	}
+	if b.block <= 0 {
+		b.block = defaultBlock
+	}
+	if b.logger == nil {
+		b.logger = zap.NewNop()
+	}
+	return b
+}
+
+func (b *RedisBus) Publish(ctx context.Context, subject string, data interface{}) error {
+	raw, err := encode(data)
+	if err != nil {
+		return err
+	}
+	stream, err := b.stream(subject)
+	if err != nil {
+		return err
+	}
+
+	header := maps.Clone(raw.Header)
+	if header == nil {
+		header = make(map[string]string)
+	}
+	ctx, span := startPublish(ctx, redisSystem, subject, header, len(raw.Data))
+	defer span.End()
+
+	return b.add(ctx, stream, Message{Subject: subject, Data: raw.Data, Header: header})
+}
+
+func (b *RedisBus) add(ctx context.Context, stream string, msg Message) error {
+	header, err := json.Marshal(msg.Header)
+	if err != nil {
+		return err
+	}
+	return b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: b.maxLen,
+		Approx: true,
+		Values: map[string]any{
+			"subject": msg.Subject,
+			"data":    msg.Data,
+			"header":  header,
+		},
+	}).Err()
+}
+
+func (b *RedisBus) Subscribe(ctx context.Context, subject string, handler Handler) error {
+	stream, err := b.stream(subject)
+	if err != nil {
+		return err
+	}
+
+	// start after the last message, "$" would skip the ones published
+	// between two reads
+	last := "0-0"
+	latest, err := b.client.XRevRangeN(ctx, stream, "+", "-", 1).Result()
+	if err != nil {
+		return fmt.Errorf("read %s: %w", stream, err)
+	}
+	if len(latest) > 0 {
+		last = latest[0].ID
+	}
+
+	go func() {
+		for ctx.Err() == nil {
+			streams, err := b.client.XRead(ctx, &redis.XReadArgs{
+				Streams: []string{stream, last},
+				Count:   readCount,
+				Block:   b.block,
+			}).Result()
+			if err != nil {
+				b.readFailed(ctx, stream, err)
+				continue
+			}
+			for _, xmsg := range streams[0].Messages {
+				last = xmsg.ID
+				msg, ok := b.decode(xmsg)
+				if !ok || !Match(subject, msg.Subject) {
+					continue
+				}
+				if err := handle(ctx, redisSystem, subject, msg, handler); err != nil {
+					b.logger.Error("failed to handle message", zap.String("subject", msg.Subject), zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// SubscribeGroup reads the stream as a Redis consumer group. Failed
+// messages stay pending and are claimed again once their delay passed,
+// messages of a crashed consumer once they are AckWait old
+func (b *RedisBus) SubscribeGroup(ctx context.Context, subject string, group Group, handler Handler) error {
+	stream, err := b.stream(subject)
+	if err != nil {
+		return err
+	}
+	if group.AckWait <= 0 {
+		group.AckWait = defaultAckWait
+	}
+	// a new group starts with the messages published from now on
+	err = b.client.XGroupCreateMkStream(ctx, stream, group.Name, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("create group %s on %s: %w", group.Name, stream, err)
+	}
+
+	c := &redisGroup{
+		bus:       b,
+		consumer:  consumerName(),
+		stream:    stream,
+		pattern:   subject,
+		config:    group,
+		handler:   handler,
+		notBefore: make(map[string]time.Time),
+	}
+	go c.run(ctx)
+	return nil
+}
+
+type redisGroup struct {
+	bus *RedisBus
+	// consumer names the subscription within the group
+	consumer string
+	stream   string
+	pattern  string
+	config   Group
+	handler  Handler
+	// notBefore holds when the messages this consumer failed are due again
+	notBefore map[string]time.Time
+}
+
+func (g *redisGroup) run(ctx context.Context) {
+	b := g.bus
+	defer g.leave()
+	for ctx.Err() == nil {
+		if err := g.retry(ctx); err != nil && ctx.Err() == nil {
+			b.logger.Warn("failed to claim pending messages", zap.String("group", g.config.Name), zap.Error(err))
+		}
+
+		streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    g.config.Name,
+			Consumer: g.consumer,
+			Streams:  []string{g.stream, ">"},
+			Count:    readCount,
+			Block:    b.block,
+		}).Result()
+		if err != nil {
+			b.readFailed(ctx, g.stream, err)
+			continue
+		}
+		for _, xmsg := range streams[0].Messages {
+			g.deliver(ctx, xmsg, 1)
+		}
+	}
+}
+
+// leave removes the consumer from the group unless messages are pending on
+// it, those are claimed by the others after AckWait
+func (g *redisGroup) leave() {
+	ctx, cancel := context.WithTimeout(context.Background(), g.bus.block)
+	defer cancel()
+
+	pending, err := g.bus.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   g.stream,
+		Group:    g.config.Name,
+		Start:    "-",
+		End:      "+",
+		Count:    1,
+		Consumer: g.consumer,
+	}).Result()
+	if err == nil && len(pending) == 0 {
+		err = g.bus.client.XGroupDelConsumer(ctx, g.stream, g.config.Name, g.consumer).Err()
+	}
+	if err != nil {
+		g.bus.logger.Warn("failed to leave group", zap.String("group", g.config.Name), zap.Error(err))
+	}
+}
+
+// retry claims the pending messages that are due, the ones this consumer
+// failed after their delay and the ones of others after AckWait
+func (g *redisGroup) retry(ctx context.Context) error {
+	b := g.bus
+	pending, err := b.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: g.stream,
+		Group:  g.config.Name,
+		Start:  "-",
+		End:    "+",
+		Count:  readCount,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, entry := range pending {
+		minIdle := g.config.AckWait
+		if entry.Consumer == g.consumer {
+			at, ok := g.notBefore[entry.ID]
+			if ok && now.Before(at) {
+				continue
+			}
+			minIdle = 0
+		} else if entry.Idle < g.config.AckWait {
+			continue
+		}
+
+		claimed, err := b.client.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   g.stream,
+			Group:    g.config.Name,
+			Consumer: g.consumer,
+			MinIdle:  minIdle,
+			Messages: []string{entry.ID},
+		}).Result()
+		if err != nil {
+			return err
+		}
+		delete(g.notBefore, entry.ID)
+		if len(claimed) == 0 {
+			// trimmed from the stream, nothing left to deliver
+			b.client.XAck(ctx, g.stream, g.config.Name, entry.ID)
+			continue
+		}
+		g.deliver(ctx, claimed[0], int(entry.RetryCount)+1)
+	}
+	return nil
+}
+
+func (g *redisGroup) deliver(ctx context.Context, xmsg redis.XMessage, delivery int) {
+	b := g.bus
+	msg, ok := b.decode(xmsg)
+	if !ok || !Match(g.pattern, msg.Subject) {
+		// the stream holds every subject with the same first token
+		b.ack(ctx, g, xmsg.ID, "")
+		return
+	}
+	msg.Delivery = delivery
+
+	err := handle(ctx, redisSystem, g.pattern, msg, g.handler,
+		semconv.MessagingMessageID(xmsg.ID),
+		attribute.String("messaging.redis.group", g.config.Name),
+	)
+	switch {
+	case err == nil:
+		b.ack(ctx, g, xmsg.ID, "ack")
+	case exhausted(g.config, delivery, err):
+		b.logger.Warn("dead-lettering message",
+			zap.String("group", g.config.Name),
+			zap.String("id", xmsg.ID),
+			zap.Int("deliveries", delivery),
+			zap.Error(err),
+		)
+		if g.config.DeadLetter != "" {
+			dl := deadLetter(g.config, msg, err)
+			stream, serr := b.stream(dl.Subject)
+			if serr == nil {
+				serr = b.add(ctx, stream, dl)
+			}
+			if serr != nil {
+				// stays pending, the next claim tries again
+				b.logger.Error("failed to dead-letter message", zap.String("group", g.config.Name), zap.Error(serr))
+				return
+			}
+		}
+		b.ack(ctx, g, xmsg.ID, "dead_letter")
+	default:
+		g.notBefore[xmsg.ID] = time.Now().Add(nextDelay(g.config, delivery, err))
+		p.BusMessagesTotal.WithLabelValues(g.config.Name, "retry").Inc()
+	}
+}
+
+// ack counts the outcome, a failed acknowledgement leaves the message
+// pending and it is delivered again after AckWait
+func (b *RedisBus) ack(ctx context.Context, g *redisGroup, id, result string) {
+	if err := b.client.XAck(ctx, g.stream, g.config.Name, id).Err(); err != nil {
+		b.logger.Warn("failed to acknowledge message", zap.String("group", g.config.Name), zap.Error(err))
+		return
+	}
+	if result != "" {
+		p.BusMessagesTotal.WithLabelValues(g.config.Name, result).Inc()
+	}
+}
+
+func (b *RedisBus) decode(xmsg redis.XMessage) (Message, bool) {
+	subject, _ := xmsg.Values["subject"].(string)
+	data, _ := xmsg.Values["data"].(string)
+	msg := Message{Subject: subject, Data: []byte(data), Delivery: 1}
+	if header, ok := xmsg.Values["header"].(string); ok {
+		_ = json.Unmarshal([]byte(header), &msg.Header)
+	}
+	if subject == "" {
+		b.logger.Warn("dropping malformed stream entry", zap.String("id", xmsg.ID))
+		return Message{}, false
+	}
+	return msg, true
+}
+
+// readFailed waits a little before the next read unless the read just
+// timed out or the subscription ended
+func (b *RedisBus) readFailed(ctx context.Context, stream string, err error) {
+	if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+		return
+	}
+	b.logger.Warn("failed to read stream", zap.String("stream", stream), zap.Error(err))
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+	}
+}
+
+// stream is the key of the stream subject is stored in
+func (b *RedisBus) stream(subject string) (string, error) {
+	first, _, _ := strings.Cut(subject, ".")
+	if first == "" || first == "*" || first == ">" {
+		return "", fmt.Errorf("subject %q needs a literal first token", subject)
+	}
+	return b.prefix + first, nil
+}
+
+func consumerName() string {
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
@@ -0,0 +1,60 @@
+package eventbus
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "go-service/pkg/eventbus"
+
+// startPublish starts the producer span of a message and writes its trace
+// context and baggage into header, the NATS bus leaves this to pkg/nats
+func startPublish(ctx context.Context, system, subject string, header map[string]string, size int) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, subject+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(system),
+			semconv.MessagingOperationPublish,
+			semconv.MessagingDestinationName(subject),
+			semconv.MessagingMessageBodySize(size),
+		),
+	)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(header))
+	return ctx, span
+}
+
+// startDeliver continues the trace of the producer in a consumer span
+func startDeliver(ctx context.Context, system, pattern string, msg Message, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if msg.Header != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Header))
+	}
+	return otel.Tracer(tracerName).Start(ctx, pattern+" deliver",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(append([]attribute.KeyValue{
+			semconv.MessagingSystemKey.String(system),
+			semconv.MessagingOperationDeliver,
+			semconv.MessagingDestinationName(msg.Subject),
+			semconv.MessagingDestinationTemplate(pattern),
+			semconv.MessagingMessageBodySize(len(msg.Data)),
+		}, attrs...)...),
+	)
+}
+
+// handle runs handler in a consumer span, recording its error
+func handle(ctx context.Context, system, pattern string, msg Message, handler Handler, attrs ...attribute.KeyValue) error {
+	ctx, span := startDeliver(ctx, system, pattern, msg, attrs...)
+	defer span.End()
+
+	err := handler(ctx, msg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
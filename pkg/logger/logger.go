@@ -2,11 +2,18 @@ package logger
 
 import "go.uber.org/zap"
 
-var logger *zap.Logger
+var (
+	logger *zap.Logger
+	// level is shared by every logger built here, so it can change at runtime
+	level = zap.NewAtomicLevelAt(zap.InfoLevel)
+)
 
 func init() {
+	config := zap.NewProductionConfig()
+	config.Level = level
+
 	var err error
-	logger, err = zap.NewProduction()
+	logger, err = config.Build()
 	if err != nil {
 		panic("failed to initialize logger: " + err.Error())
 	}
@@ -15,3 +22,9 @@ func init() {
 func GetLogger() *zap.Logger {
 	return logger
 }
+
+// SetLevel changes the level of the process logger, text is one of debug,
+// info, warn or error
+func SetLevel(text string) error {
+	return level.UnmarshalText([]byte(text))
+}
@@ -0,0 +1,360 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// NilVersion is the version of a database without applied migrations
+const NilVersion = -1
+
+const defaultTable = "schema_migrations"
+
+var (
+	ErrDirty          = errors.New("database is dirty, fix it by hand and run force")
+	ErrUnknownVersion = errors.New("no migration with this version")
+)
+
+// files look like 000001_init.up.sql, the layout golang-migrate uses
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Config struct {
+	DB *pgxpool.Pool
+	FS fs.FS
+	// Table keeps the current version, golang-migrate's schema_migrations by default
+	Table  string
+	Logger *zap.Logger
+}
+
+// Migrator applies SQL migrations in order. The version table holds one row,
+// compatible with golang-migrate, so databases migrated with it carry over
+type Migrator struct {
+	db         *pgxpool.Pool
+	table      string
+	lockID     int64
+	migrations []Migration
+	logger     *zap.Logger
+}
+
+type Status struct {
+	Version int64
+	Dirty   bool
+	Applied []Migration
+	Pending []Migration
+}
+
+func New(config Config) (*Migrator, error) {
+	migrations, err := load(config.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	table := config.Table
+	if table == "" {
+		table = defaultTable
+	}
+
+	return &Migrator{
+		db:         config.DB,
+		table:      table,
+		lockID:     int64(crc32.ChecksumIEEE([]byte("go-service:" + table))),
+		migrations: migrations,
+		logger:     config.Logger,
+	}, nil
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	if len(m.migrations) == 0 {
+		return 0, nil
+	}
+	return m.To(ctx, m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down rolls back the last n applied migrations
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	var done int
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		version, err := m.clean(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		applied := m.appliedUpTo(version)
+		for i := len(applied) - 1; i >= 0 && done < n; i-- {
+			target := int64(NilVersion)
+			if i > 0 {
+				target = applied[i-1].Version
+			}
+			if err := m.apply(ctx, conn, applied[i], false, target); err != nil {
+				return err
+			}
+			done++
+		}
+		return nil
+	})
+	return done, err
+}
+
+// To migrates up or down until version is the current one
+func (m *Migrator) To(ctx context.Context, version int64) (int, error) {
+	if version != NilVersion && m.index(version) < 0 {
+		return 0, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	var done int
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		current, err := m.clean(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= current || migration.Version > version {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, true, migration.Version); err != nil {
+				return err
+			}
+			done++
+		}
+
+		applied := m.appliedUpTo(current)
+		for i := len(applied) - 1; i >= 0 && applied[i].Version > version; i-- {
+			target := int64(NilVersion)
+			if i > 0 {
+				target = applied[i-1].Version
+			}
+			if err := m.apply(ctx, conn, applied[i], false, target); err != nil {
+				return err
+			}
+			done++
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Force sets the version and clears the dirty flag without running any SQL
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != NilVersion && m.index(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return m.locked(ctx, func(conn *pgxpool.Conn) error {
+		tx, err := conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		if err := m.setVersion(ctx, tx, version); err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	})
+}
+
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	var status Status
+	err := m.locked(ctx, func(conn *pgxpool.Conn) error {
+		version, dirty, err := m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		status.Version, status.Dirty = version, dirty
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				status.Applied = append(status.Applied, migration)
+			} else {
+				status.Pending = append(status.Pending, migration)
+			}
+		}
+		return nil
+	})
+	return status, err
+}
+
+// locked runs fn on one connection holding the advisory lock, so replicas
+// starting together apply every migration once
+func (m *Migrator) locked(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", m.lockID); err != nil {
+		return fmt.Errorf("take migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", m.lockID); err != nil {
+			m.logger.Error("failed to release migration lock", zap.Error(err))
+		}
+	}()
+
+	create := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`, m.tableName())
+	if _, err := conn.Exec(ctx, create); err != nil {
+		return fmt.Errorf("create version table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) version(ctx context.Context, conn *pgxpool.Conn) (int64, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := conn.QueryRow(ctx, fmt.Sprintf(`SELECT version, dirty FROM %s LIMIT 1`, m.tableName())).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return NilVersion, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("read version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// clean returns the current version, refusing to go on from a dirty one
+func (m *Migrator) clean(ctx context.Context, conn *pgxpool.Conn) (int64, error) {
+	version, dirty, err := m.version(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w: version %d", ErrDirty, version)
+	}
+	return version, nil
+}
+
+// apply runs one direction of migration and records target as the new
+// version in the same transaction
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, migration Migration, up bool, target int64) error {
+	direction, body := "down", migration.Down
+	if up {
+		direction, body = "up", migration.Up
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, body); err != nil {
+		return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+	if err := m.setVersion(ctx, tx, target); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	m.logger.Info("applied migration",
+		zap.Int64("version", migration.Version),
+		zap.String("name", migration.Name),
+		zap.String("direction", direction),
+	)
+	return nil
+}
+
+func (m *Migrator) setVersion(ctx context.Context, tx pgx.Tx, version int64) error {
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s`, m.tableName())); err != nil {
+		return fmt.Errorf("set version: %w", err)
+	}
+	if version == NilVersion {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (version, dirty) VALUES ($1, false)`, m.tableName()), version); err != nil {
+		return fmt.Errorf("set version: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) appliedUpTo(version int64) []Migration {
+	var applied []Migration
+	for _, migration := range m.migrations {
+		if migration.Version <= version {
+			applied = append(applied, migration)
+		}
+	}
+	return applied
+}
+
+func (m *Migrator) index(version int64) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Migrator) tableName() string {
+	return pgx.Identifier{m.table}.Sanitize()
+}
@@ -0,0 +1,394 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.uber.org/zap"
+
+	p "go-service/pkg/prometheus"
+)
+
+// Headers of dead-lettered messages, the original headers are kept as well
+const (
+	HeaderDeadLetterStream     = "Dead-Letter-Stream"
+	HeaderDeadLetterSequence   = "Dead-Letter-Sequence"
+	HeaderDeadLetterSubject    = "Dead-Letter-Subject"
+	HeaderDeadLetterConsumer   = "Dead-Letter-Consumer"
+	HeaderDeadLetterDeliveries = "Dead-Letter-Deliveries"
+	HeaderDeadLetterError      = "Dead-Letter-Error"
+)
+
+// maxDeliveriesAdvisory is published by the server when a message ran out of
+// deliveries without an answer from the consumer
+const maxDeliveriesAdvisory = "$JS.EVENT.ADVISORY.CONSUMER.MAX_DELIVERIES.%s.%s"
+
+// StreamConfig describes a stream Provision creates or updates
+type StreamConfig struct {
+	Name     string
+	Subjects []string
+	// MaxAge drops older messages, 0 keeps them until the limits are hit
+	MaxAge time.Duration
+	// Duplicates is the window message IDs are deduplicated in, 0 is the server default of 2m
+	Duplicates time.Duration
+	Replicas   int
+}
+
+// ConsumerConfig describes a durable pull consumer
+type ConsumerConfig struct {
+	// Stream defaults to the stream capturing the first filter subject
+	Stream  string
+	Durable string
+	// FilterSubjects narrows the stream, empty takes every subject
+	FilterSubjects []string
+	// AckWait is how long a message may be handled before it is delivered again
+	AckWait time.Duration
+	// MaxDeliver caps the deliveries of one message, then it goes to DeadLetter
+	MaxDeliver int
+	// Backoff delays the redeliveries, the last delay repeats. Failed
+	// messages are delayed the same way
+	Backoff       []time.Duration
+	MaxAckPending int
+	// DeadLetter receives the messages that ran out of deliveries or failed
+	// permanently, a stream has to capture it. Empty drops them
+	DeadLetter string
+}
+
+// Handler handles one message. nil acks it, errors made by Retry or
+// Permanent choose what happens next and any other error retries after
+// the consumer backoff
+type Handler func(ctx context.Context, msg jetstream.Msg) error
+
+type retryError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryError) Error() string { return e.err.Error() }
+func (e *retryError) Unwrap() error { return e.err }
+
+// Retry delivers the message again after delay instead of the consumer backoff
+func Retry(err error, delay time.Duration) error {
+	return &retryError{err: err, delay: delay}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent dead-letters the message right away, for messages no retry can fix
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// MessageIDer is implemented by payloads that carry their own ID, Publish
+// uses it to deduplicate retried publishes
+type MessageIDer interface {
+	MessageID() string
+}
+
+// JetStream publishes to and consumes from JetStream streams
+type JetStream struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	logger *zap.Logger
+}
+
+func NewJetStream(conn *nats.Conn, logger *zap.Logger) (*JetStream, error) {
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &JetStream{conn: conn, js: js, logger: logger}, nil
+}
+
+// Provision creates the streams or brings existing ones in line with their config
+func (j *JetStream) Provision(ctx context.Context, streams ...StreamConfig) error {
+	for _, s := range streams {
+		_, err := j.js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+			Name:       s.Name,
+			Subjects:   s.Subjects,
+			MaxAge:     s.MaxAge,
+			Duplicates: s.Duplicates,
+			Replicas:   s.Replicas,
+			Storage:    jetstream.FileStorage,
+		})
+		if err != nil {
+			return fmt.Errorf("provision stream %s: %w", s.Name, err)
+		}
+		j.logger.Info("stream provisioned", zap.String("stream", s.Name), zap.Strings("subjects", s.Subjects))
+	}
+	return nil
+}
+
+// Publish encodes data as JSON and waits for a stream to store it. Payloads
+// implementing MessageIDer are stored once however often they are published
+// within the duplicates window
+func (j *JetStream) Publish(ctx context.Context, subject string, data interface{}) error {
+	var id string
+	if m, ok := data.(MessageIDer); ok {
+		id = m.MessageID()
+	}
+	return j.PublishWithID(ctx, subject, id, data)
+}
+
+// PublishWithID is Publish with an explicit message ID, empty turns deduplication off
+func (j *JetStream) PublishWithID(ctx context.Context, subject, id string, data interface{}) error {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return j.PublishMsg(ctx, &nats.Msg{Subject: subject, Data: dataBytes}, id)
+}
+
+// PublishMsg sends msg as it is and waits for a stream to store it, empty
+// id turns deduplication off
+func (j *JetStream) PublishMsg(ctx context.Context, msg *nats.Msg, id string) error {
+	var opts []jetstream.PublishOpt
+	var attrs []attribute.KeyValue
+	if id != "" {
+		opts = append(opts, jetstream.WithMsgID(id))
+		attrs = append(attrs, semconv.MessagingMessageID(id))
+	}
+	ctx, span := startPublish(ctx, msg, attrs...)
+	defer span.End()
+
+	if _, err := j.js.PublishMsg(ctx, msg, opts...); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// Consume creates or updates the durable consumer and hands its messages to
+// handler until ctx is done. Replicas consuming the same durable share the
+// messages
+func (j *JetStream) Consume(ctx context.Context, config ConsumerConfig, handler Handler) error {
+	if config.MaxDeliver > 0 && len(config.Backoff) >= config.MaxDeliver {
+		return fmt.Errorf("consumer %s: backoff needs fewer steps than max deliver", config.Durable)
+	}
+	if config.Stream == "" && len(config.FilterSubjects) > 0 {
+		stream, err := j.js.StreamNameBySubject(ctx, config.FilterSubjects[0])
+		if err != nil {
+			return fmt.Errorf("find the stream of %s: %w", config.FilterSubjects[0], err)
+		}
+		config.Stream = stream
+	}
+
+	consumer, err := j.js.CreateOrUpdateConsumer(ctx, config.Stream, jetstream.ConsumerConfig{
+		Durable:        config.Durable,
+		FilterSubjects: config.FilterSubjects,
+		AckPolicy:      jetstream.AckExplicitPolicy,
+		AckWait:        config.AckWait,
+		MaxDeliver:     config.MaxDeliver,
+		BackOff:        config.Backoff,
+		MaxAckPending:  config.MaxAckPending,
+	})
+	if err != nil {
+		return fmt.Errorf("create consumer %s: %w", config.Durable, err)
+	}
+
+	// a consumer that crashes on the last delivery never answers, the server
+	// reports the message and one replica dead-letters it from the stream
+	var advisories *nats.Subscription
+	if config.DeadLetter != "" {
+		advisories, err = j.conn.QueueSubscribe(fmt.Sprintf(maxDeliveriesAdvisory, config.Stream, config.Durable), config.Durable, func(msg *nats.Msg) {
+			j.onMaxDeliveries(ctx, config, msg)
+		})
+		if err != nil {
+			return fmt.Errorf("subscribe to advisories of %s: %w", config.Durable, err)
+		}
+	}
+
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		j.handle(ctx, config, handler, msg)
+	}, jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+		j.logger.Warn("jetstream consumer error", zap.String("consumer", config.Durable), zap.Error(err))
+	}))
+	if err != nil {
+		if advisories != nil {
+			_ = advisories.Unsubscribe()
+		}
+		return fmt.Errorf("consume %s: %w", config.Durable, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		consumeCtx.Stop()
+		if advisories != nil {
+			_ = advisories.Unsubscribe()
+		}
+	}()
+	return nil
+}
+
+func (j *JetStream) handle(ctx context.Context, config ConsumerConfig, handler Handler, msg jetstream.Msg) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		j.logger.Error("dropping message without metadata", zap.String("subject", msg.Subject()), zap.Error(err))
+		_ = msg.Term()
+		return
+	}
+
+	ctx, span := startDeliver(ctx, subscription(config), msg.Subject(), msg.Headers(), len(msg.Data()),
+		attribute.String("messaging.nats.stream", config.Stream),
+		attribute.String("messaging.nats.consumer", config.Durable),
+		attribute.Int64("messaging.nats.deliveries", int64(meta.NumDelivered)),
+		semconv.MessagingMessageID(msg.Headers().Get(nats.MsgIdHdr)),
+	)
+	defer span.End()
+
+	err = handler(ctx, msg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if err == nil {
+		j.ack(config, msg.Ack(), "ack")
+		return
+	}
+
+	var permanent *permanentError
+	last := config.MaxDeliver > 0 && meta.NumDelivered >= uint64(config.MaxDeliver)
+	if errors.As(err, &permanent) || last {
+		j.logger.Warn("dead-lettering message",
+			zap.String("consumer", config.Durable),
+			zap.Uint64("stream_seq", meta.Sequence.Stream),
+			zap.Uint64("deliveries", meta.NumDelivered),
+			zap.Error(err),
+		)
+		if dlErr := j.deadLetter(ctx, config, msg.Subject(), msg.Headers(), msg.Data(), meta.Sequence.Stream, meta.NumDelivered, err); dlErr != nil {
+			// leave the message to the server, it is delivered again or,
+			// when out of deliveries, reported through the advisory
+			j.logger.Error("failed to dead-letter message", zap.String("consumer", config.Durable), zap.Error(dlErr))
+			j.ack(config, msg.NakWithDelay(backoff(config.Backoff, meta.NumDelivered)), "retry")
+			return
+		}
+		j.ack(config, msg.Term(), "dead_letter")
+		return
+	}
+
+	delay := backoff(config.Backoff, meta.NumDelivered)
+	var retry *retryError
+	if errors.As(err, &retry) {
+		delay = retry.delay
+	}
+	j.logger.Debug("retrying message",
+		zap.String("consumer", config.Durable),
+		zap.Uint64("stream_seq", meta.Sequence.Stream),
+		zap.Duration("delay", delay),
+		zap.Error(err),
+	)
+	j.ack(config, msg.NakWithDelay(delay), "retry")
+}
+
+// ack counts the outcome, a failed acknowledgement means the server
+// delivers the message again after AckWait
+func (j *JetStream) ack(config ConsumerConfig, err error, result string) {
+	if err != nil {
+		j.logger.Warn("failed to acknowledge message", zap.String("consumer", config.Durable), zap.Error(err))
+		return
+	}
+	p.JetStreamMessagesTotal.WithLabelValues(config.Durable, result).Inc()
+}
+
+type maxDeliveriesEvent struct {
+	Stream     string `json:"stream"`
+	Consumer   string `json:"consumer"`
+	StreamSeq  uint64 `json:"stream_seq"`
+	Deliveries uint64 `json:"deliveries"`
+}
+
+func (j *JetStream) onMaxDeliveries(ctx context.Context, config ConsumerConfig, advisory *nats.Msg) {
+	var event maxDeliveriesEvent
+	if err := json.Unmarshal(advisory.Data, &event); err != nil {
+		j.logger.Error("failed to unmarshal max deliveries advisory", zap.Error(err))
+		return
+	}
+
+	stream, err := j.js.Stream(ctx, config.Stream)
+	if err == nil {
+		var msg *jetstream.RawStreamMsg
+		msg, err = stream.GetMsg(ctx, event.StreamSeq)
+		if err == nil {
+			err = j.deadLetter(ctx, config, msg.Subject, msg.Header, msg.Data, event.StreamSeq, event.Deliveries, errors.New("ran out of deliveries"))
+		}
+	}
+	if err != nil {
+		j.logger.Error("failed to dead-letter message",
+			zap.String("consumer", config.Durable),
+			zap.Uint64("stream_seq", event.StreamSeq),
+			zap.Error(err),
+		)
+		return
+	}
+	p.JetStreamMessagesTotal.WithLabelValues(config.Durable, "dead_letter").Inc()
+}
+
+// deadLetter stores the message on the dead-letter subject. Its ID is the
+// stream sequence, so the handler and the advisory can't store it twice
+func (j *JetStream) deadLetter(ctx context.Context, config ConsumerConfig, subject string, header nats.Header, data []byte, seq, deliveries uint64, cause error) error {
+	if config.DeadLetter == "" {
+		return nil
+	}
+
+	dl := nats.NewMsg(config.DeadLetter)
+	for key, values := range header {
+		if key == nats.MsgIdHdr {
+			continue
+		}
+		dl.Header[key] = values
+	}
+	dl.Header.Set(HeaderDeadLetterStream, config.Stream)
+	dl.Header.Set(HeaderDeadLetterSequence, strconv.FormatUint(seq, 10))
+	dl.Header.Set(HeaderDeadLetterSubject, subject)
+	dl.Header.Set(HeaderDeadLetterConsumer, config.Durable)
+	dl.Header.Set(HeaderDeadLetterDeliveries, strconv.FormatUint(deliveries, 10))
+	dl.Header.Set(HeaderDeadLetterError, cause.Error())
+	dl.Data = data
+
+	_, err := j.js.PublishMsg(ctx, dl, jetstream.WithMsgID(fmt.Sprintf("%s.%s.%d", config.Stream, config.Durable, seq)))
+	return err
+}
+
+// subscription names the consumer spans after the filters of the consumer,
+// or the stream when it takes every subject
+func subscription(config ConsumerConfig) string {
+	if len(config.FilterSubjects) == 0 {
+		return config.Stream
+	}
+	return strings.Join(config.FilterSubjects, ",")
+}
+
+// backoff returns the delay before delivery number delivered+1
+func backoff(steps []time.Duration, delivered uint64) time.Duration {
+	if len(steps) == 0 {
+		return 0
+	}
+	i := int(delivered) - 1
+	if i >= len(steps) {
+		i = len(steps) - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return steps[i]
+}
@@ -5,11 +5,17 @@ import (
 	"encoding/json"
 
 	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/codes"
 )
 
+// MsgHandler gets the context of the subscription carrying the trace of the
+// publisher, so the work a message causes joins the trace that sent it
+type MsgHandler func(ctx context.Context, msg *nats.Msg)
+
 type NatsService interface {
 	Publish(ctx context.Context, subject string, data interface{}) error
-	Subscribe(ctx context.Context, subject string, handler func(msg *nats.Msg)) error
+	Subscribe(ctx context.Context, subject string, handler MsgHandler) error
+	QueueSubscribe(ctx context.Context, subject, queue string, handler MsgHandler) error
 }
 
 type NatsClient struct {
@@ -20,18 +26,56 @@ func NewNatsClient(conn *nats.Conn) *NatsClient {
 	return &NatsClient{conn: conn}
 }
 
+// Publish sends data as JSON with the trace context and baggage of ctx in
+// the headers
 func (n *NatsClient) Publish(ctx context.Context, subject string, data interface{}) error {
 	dataBytes, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
 
-	return n.conn.Publish(subject, dataBytes)
+	return n.PublishMsg(ctx, &nats.Msg{Subject: subject, Data: dataBytes})
 }
 
-func (n *NatsClient) Subscribe(ctx context.Context, subject string, handler func(msg *nats.Msg)) error {
-	_, err := n.conn.Subscribe(subject, func(msg *nats.Msg) {
-		handler(msg)
-	})
-	return err
+// PublishMsg sends msg as it is, adding the trace context to its headers
+func (n *NatsClient) PublishMsg(ctx context.Context, msg *nats.Msg) error {
+	_, span := startPublish(ctx, msg)
+	defer span.End()
+
+	if err := n.conn.PublishMsg(msg); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// Subscribe hands every message on subject to handler until ctx is done
+func (n *NatsClient) Subscribe(ctx context.Context, subject string, handler MsgHandler) error {
+	sub, err := n.conn.Subscribe(subject, n.deliver(ctx, subject, handler))
+	if err != nil {
+		return err
+	}
+	context.AfterFunc(ctx, func() { _ = sub.Unsubscribe() })
+	return nil
+}
+
+// QueueSubscribe hands every message to one subscriber of the queue group,
+// so replicas share the work instead of repeating it
+func (n *NatsClient) QueueSubscribe(ctx context.Context, subject, queue string, handler MsgHandler) error {
+	sub, err := n.conn.QueueSubscribe(subject, queue, n.deliver(ctx, subject, handler))
+	if err != nil {
+		return err
+	}
+	context.AfterFunc(ctx, func() { _ = sub.Unsubscribe() })
+	return nil
+}
+
+// deliver runs handler in a consumer span continuing the trace of the message
+func (n *NatsClient) deliver(ctx context.Context, subject string, handler MsgHandler) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		ctx, span := startDeliver(ctx, subject, msg.Subject, msg.Header, len(msg.Data))
+		defer span.End()
+		handler(ctx, msg)
+	}
 }
@@ -0,0 +1,88 @@
+package nats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// RequestHandler answers one request, the returned bytes are the reply
+type RequestHandler func(ctx context.Context, msg *nats.Msg) []byte
+
+// Responder answers requests until it is drained
+type Responder struct {
+	sub *nats.Subscription
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+// Respond answers requests on subject, every request goes to one member of
+// the queue group. Up to concurrency requests are handled at once, more
+// wait in the subscription. Each request runs in a consumer span continuing
+// the trace of the requester, the reply carries it back
+func (n *NatsClient) Respond(ctx context.Context, subject, queue string, concurrency int, handler RequestHandler) (*Responder, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	r := &Responder{sem: make(chan struct{}, concurrency)}
+
+	sub, err := n.conn.QueueSubscribe(subject, queue, func(msg *nats.Msg) {
+		if msg.Reply == "" {
+			// published rather than requested, nobody waits for an answer
+			return
+		}
+		r.sem <- struct{}{}
+		r.wg.Add(1)
+		go func() {
+			defer func() {
+				<-r.sem
+				r.wg.Done()
+			}()
+			r.respond(ctx, subject, msg, handler)
+		}()
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.sub = sub
+	return r, nil
+}
+
+func (r *Responder) respond(ctx context.Context, subject string, msg *nats.Msg, handler RequestHandler) {
+	ctx, span := startDeliver(ctx, subject, msg.Subject, msg.Header, len(msg.Data))
+	defer span.End()
+
+	reply := nats.NewMsg(msg.Reply)
+	reply.Data = handler(ctx, msg)
+	Inject(ctx, reply)
+	if err := msg.RespondMsg(reply); err != nil {
+		span.RecordError(err)
+	}
+}
+
+// Drain stops taking requests and waits for the pending ones to be
+// answered, the ones still running when ctx is done are left behind
+func (r *Responder) Drain(ctx context.Context) error {
+	if err := r.sub.Drain(); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		// the subscription turns invalid once its pending messages are handed out
+		for r.sub.IsValid() && ctx.Err() == nil {
+			time.Sleep(10 * time.Millisecond)
+		}
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,77 @@
+package nats
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "go-service/pkg/nats"
+
+var messagingSystem = semconv.MessagingSystemKey.String("nats")
+
+// headerCarrier lets the propagators read and write NATS headers. Keys are
+// used as the propagators give them, nats.Header doesn't canonicalize
+type headerCarrier nats.Header
+
+func (c headerCarrier) Get(key string) string {
+	return nats.Header(c).Get(key)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	nats.Header(c).Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// startPublish starts the producer span of msg and writes its trace context
+// and baggage into the headers
+func startPublish(ctx context.Context, msg *nats.Msg, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, msg.Subject+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(append([]attribute.KeyValue{
+			messagingSystem,
+			semconv.MessagingOperationPublish,
+			semconv.MessagingDestinationName(msg.Subject),
+			semconv.MessagingMessageBodySize(len(msg.Data)),
+		}, attrs...)...),
+	)
+	Inject(ctx, msg)
+	return ctx, span
+}
+
+// startDeliver continues the trace of the producer in a consumer span,
+// subscription is the subject subscribed to, wildcards included
+func startDeliver(ctx context.Context, subscription, subject string, header nats.Header, size int, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if header != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier(header))
+	}
+	return otel.Tracer(tracerName).Start(ctx, subscription+" deliver",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(append([]attribute.KeyValue{
+			messagingSystem,
+			semconv.MessagingOperationDeliver,
+			semconv.MessagingDestinationName(subject),
+			semconv.MessagingDestinationTemplate(subscription),
+			semconv.MessagingMessageBodySize(size),
+		}, attrs...)...),
+	)
+}
+
+// Inject writes the trace context and baggage of ctx into the headers of msg
+func Inject(ctx context.Context, msg *nats.Msg) {
+	if msg.Header == nil {
+		msg.Header = nats.Header{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(msg.Header))
+}
@@ -28,3 +28,128 @@ var GoodsCounter = prometheus.NewCounterVec(
 	},
 	[]string{"project_id"},
 )
+
+var CacheStaleTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "cacheStale",
+		Name:      "cache_stale_total",
+		Help:      "Total number of stale cache values served while refreshing",
+	},
+	[]string{"goods_id", "project_id"},
+)
+
+var CacheTierHitsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "cacheTier",
+		Name:      "cache_tier_hits_total",
+		Help:      "Total number of cache hits per tier",
+	},
+	[]string{"tier"},
+)
+
+var CacheTierMissesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "cacheTier",
+		Name:      "cache_tier_misses_total",
+		Help:      "Total number of cache misses per tier",
+	},
+	[]string{"tier"},
+)
+
+var CacheInvalidationsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "cacheTier",
+		Name:      "cache_invalidations_total",
+		Help:      "Total number of local cache invalidations by result",
+	},
+	[]string{"result"},
+)
+
+var ConfigReloadsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "config",
+		Name:      "config_reloads_total",
+		Help:      "Total number of configuration reloads by result",
+	},
+	[]string{"result"},
+)
+
+var EventsConnections = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "events",
+		Name:      "events_connections",
+		Help:      "Number of open event stream connections by transport",
+	},
+	[]string{"transport"},
+)
+
+var EventsSentTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "events",
+		Name:      "events_sent_total",
+		Help:      "Total number of events sent to stream clients by transport",
+	},
+	[]string{"transport"},
+)
+
+var EventsDroppedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "events",
+		Name:      "events_dropped_total",
+		Help:      "Total number of event stream connections dropped by reason",
+	},
+	[]string{"reason"},
+)
+
+var EventsRejectedTotal = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "events",
+		Name:      "events_rejected_total",
+		Help:      "Total number of event stream connections rejected at the connection limit",
+	},
+)
+
+var WebhookDeliveriesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "webhooks",
+		Name:      "webhook_deliveries_total",
+		Help:      "Total number of webhook delivery attempts by result",
+	},
+	[]string{"result"},
+)
+
+var JetStreamMessagesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "jetstream",
+		Name:      "jetstream_messages_total",
+		Help:      "Total number of JetStream messages handled by consumer and result",
+	},
+	[]string{"consumer", "result"},
+)
+
+var NatsCommandsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "nats",
+		Name:      "nats_commands_total",
+		Help:      "Total number of NATS commands answered by command and result",
+	},
+	[]string{"command", "result"},
+)
+
+var BusMessagesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "bus",
+		Name:      "bus_messages_total",
+		Help:      "Total number of messages handled by consumer groups of the redis and memory buses by group and result",
+	},
+	[]string{"group", "result"},
+)
+
+var CacheNotificationsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "cacheNotify",
+		Name:      "cache_notifications_total",
+		Help:      "Total number of database change notifications handled by table and result",
+	},
+	[]string{"table", "result"},
+)
@@ -14,6 +14,8 @@ type Cache interface {
 	Delete(ctx context.Context, key string) error
 	GetInt(ctx context.Context, key string) (int, error)
 	SetInt(ctx context.Context, key string, value int, ttl time.Duration) error
+	// Incr bumps the counter at key and keeps it for ttl, 0 keeps it forever
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
 }
 
 type RedisCache struct {
@@ -48,3 +50,20 @@ func (r *RedisCache) SetInt(ctx context.Context, key string, value int, expirati
 	// TODO: change
 	return r.client.Set(ctx, key, strconv.Itoa(value), expiration).Err()
 }
+
+func (r *RedisCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	if expiration <= 0 {
+		return r.client.Incr(ctx, key).Result()
+	}
+
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.PExpire(ctx, key, expiration)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const flushBatch = 500
+
+// Flush unlinks every key matching one of patterns and returns how many were removed.
+// It walks the keyspace with SCAN, so it doesn't block the server the way KEYS does
+func Flush(ctx context.Context, client *redis.Client, patterns ...string) (int64, error) {
+	var removed int64
+	for _, pattern := range patterns {
+		iter := client.Scan(ctx, 0, pattern, flushBatch).Iterator()
+
+		keys := make([]string, 0, flushBatch)
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+			if len(keys) < flushBatch {
+				continue
+			}
+			n, err := client.Unlink(ctx, keys...).Result()
+			if err != nil {
+				return removed, err
+			}
+			removed += n
+			keys = keys[:0]
+		}
+		if err := iter.Err(); err != nil {
+			return removed, err
+		}
+
+		if len(keys) > 0 {
+			n, err := client.Unlink(ctx, keys...).Result()
+			if err != nil {
+				return removed, err
+			}
+			removed += n
+		}
+	}
+	return removed, nil
+}
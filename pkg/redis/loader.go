@@ -0,0 +1,298 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultTTL      = time.Minute
+	defaultLockTTL  = 5 * time.Second
+	defaultLockWait = 2 * time.Second
+	lockPollPeriod  = 50 * time.Millisecond
+	// defaultGenerationTTL outlasts any load, loads are bounded by request
+	// deadlines and the lock TTL
+	defaultGenerationTTL = time.Minute
+
+	generationKeyPrefix = "generation:"
+	// refreshKeyPrefix keeps background refreshes apart from the loads of
+	// misses, a miss has to wait for a value a refresh may give up on
+	refreshKeyPrefix = "refresh:"
+)
+
+var errRefreshInProgress = errors.New("refresh in progress")
+
+// Policy describes how long an entity is cached
+type Policy struct {
+	// TTL is how long a value is considered fresh
+	TTL time.Duration
+	// Jitter is a random extra added to TTL so hot keys don't expire together
+	Jitter time.Duration
+	// Stale is how long an expired value may still be served while it is refreshed
+	Stale time.Duration
+}
+
+func (p Policy) freshFor() time.Duration {
+	ttl := p.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	if p.Jitter > 0 {
+		ttl += time.Duration(rand.Int63n(int64(p.Jitter)))
+	}
+	return ttl
+}
+
+// Status tells where a value returned by Loader came from
+type Status int
+
+const (
+	Miss Status = iota
+	Hit
+	StaleHit
+)
+
+// LoadFunc builds the value for a key from the source of truth
+type LoadFunc func(ctx context.Context) (string, error)
+
+// entry is what is actually stored in redis: the value and the moment it goes stale
+type entry struct {
+	Value      string `json:"v"`
+	FreshUntil int64  `json:"f"`
+}
+
+type LoaderConfig struct {
+	Cache Cache
+	// Locker is optional, without it rebuilds are only coalesced inside the process
+	Locker   Locker
+	LockTTL  time.Duration
+	LockWait time.Duration
+	// GenerationTTL is how long an invalidated key remembers its generation,
+	// a load running longer may cache a value read before the invalidation.
+	// It defaults to a minute and is never shorter than LockTTL
+	GenerationTTL time.Duration
+	Logger        *zap.Logger
+}
+
+// Loader is a read-through cache with request coalescing and stale-while-revalidate
+type Loader struct {
+	cache    Cache
+	locker   Locker
+	lockTTL  time.Duration
+	lockWait time.Duration
+	logger   *zap.Logger
+	group    singleflight.Group
+	// generationTTL expires the generation keys, one per invalidated key
+	generationTTL time.Duration
+}
+
+func NewLoader(config LoaderConfig) *Loader {
+	l := &Loader{
+		cache:    config.Cache,
+		locker:   config.Locker,
+		lockTTL:  config.LockTTL,
+		lockWait: config.LockWait,
+		logger:   config.Logger,
+
+		generationTTL: config.GenerationTTL,
+	}
+	if l.lockTTL <= 0 {
+		l.lockTTL = defaultLockTTL
+	}
+	if l.lockWait <= 0 {
+		l.lockWait = defaultLockWait
+	}
+	if l.generationTTL <= 0 {
+		l.generationTTL = defaultGenerationTTL
+	}
+	l.generationTTL = max(l.generationTTL, l.lockTTL)
+	return l
+}
+
+// Get returns the cached value for key or builds it with load.
+//
+// Concurrent misses for the same key share one load call. When the policy
+// allows stale values an expired entry is returned immediately and refreshed
+// in the background.
+func (l *Loader) Get(ctx context.Context, key string, policy Policy, load LoadFunc) (string, Status, error) {
+	if e, ok := l.lookup(ctx, key); ok {
+		if time.Now().UnixNano() < e.FreshUntil {
+			return e.Value, Hit, nil
+		}
+		if policy.Stale > 0 {
+			l.refresh(ctx, key, policy, load)
+			return e.Value, StaleHit, nil
+		}
+	}
+
+	v, err, shared := l.group.Do(key, func() (interface{}, error) {
+		return l.load(ctx, key, policy, load, true)
+	})
+	// the shared load ran with the context of whoever started it, don't fail
+	// this caller because another one went away
+	if shared && errors.Is(err, context.Canceled) && ctx.Err() == nil {
+		v, err = l.load(ctx, key, policy, load, true)
+	}
+	if err != nil {
+		return "", Miss, err
+	}
+	return v.(string), Miss, nil
+}
+
+// refresh rebuilds key in the background, detached from the caller but bounded by the lock TTL
+func (l *Loader) refresh(ctx context.Context, key string, policy Policy, load LoadFunc) {
+	ctx = context.WithoutCancel(ctx)
+	l.group.DoChan(refreshKeyPrefix+key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, l.lockTTL)
+		defer cancel()
+
+		v, err := l.load(ctx, key, policy, load, false)
+		if err != nil && !errors.Is(err, errRefreshInProgress) {
+			l.logger.Error("failed to refresh cache", zap.String("key", key), zap.Error(err))
+		}
+		return v, err
+	})
+}
+
+// load rebuilds key, holding the distributed lock if there is one. Callers
+// that lose the lock either wait for the winner's value or give up when wait is false.
+func (l *Loader) load(ctx context.Context, key string, policy Policy, load LoadFunc, wait bool) (string, error) {
+	if l.locker != nil {
+		lockKey := "lock:" + key
+		token, ok, err := l.locker.Acquire(ctx, lockKey, l.lockTTL)
+		switch {
+		case err != nil:
+			l.logger.Error("failed to acquire cache lock", zap.String("key", lockKey), zap.Error(err))
+		case ok:
+			defer func() {
+				if err := l.locker.Release(ctx, lockKey, token); err != nil {
+					l.logger.Error("failed to release cache lock", zap.String("key", lockKey), zap.Error(err))
+				}
+			}()
+		case !wait:
+			return "", errRefreshInProgress
+		default:
+			if v, ok := l.waitFor(ctx, key); ok {
+				return v, nil
+			}
+		}
+	}
+
+	// an Invalidate that lands while the source is read means v may predate
+	// the change, it is returned to the callers of this load but not cached
+	generation, genErr := l.generation(ctx, key)
+	if genErr != nil {
+		l.logger.Error("failed to read cache generation", zap.String("key", key), zap.Error(genErr))
+	}
+
+	v, err := load(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if genErr == nil && l.current(ctx, key, generation) {
+		l.store(ctx, key, v, policy)
+		// an Invalidate between the check and the store may have deleted the
+		// key before the store wrote it back
+		if !l.current(ctx, key, generation) {
+			if err := l.cache.Delete(ctx, key); err != nil {
+				l.logger.Error("failed to drop outdated cache value", zap.String("key", key), zap.Error(err))
+			}
+		}
+	}
+	return v, nil
+}
+
+// Invalidate drops key after its source changed. Loads already running for
+// key don't cache what they read, and later Gets don't join them. The
+// generation is kept for LoaderConfig.GenerationTTL, longer than those loads
+func (l *Loader) Invalidate(ctx context.Context, key string) error {
+	if _, err := l.cache.Incr(ctx, generationKeyPrefix+key, l.generationTTL); err != nil {
+		return err
+	}
+	l.group.Forget(key)
+	l.group.Forget(refreshKeyPrefix + key)
+	return l.cache.Delete(ctx, key)
+}
+
+func (l *Loader) generation(ctx context.Context, key string) (int, error) {
+	generation, err := l.cache.GetInt(ctx, generationKeyPrefix+key)
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return generation, err
+}
+
+// current reports whether key wasn't invalidated since generation was read
+func (l *Loader) current(ctx context.Context, key string, generation int) bool {
+	now, err := l.generation(ctx, key)
+	if err != nil {
+		l.logger.Error("failed to read cache generation", zap.String("key", key), zap.Error(err))
+		return false
+	}
+	return now == generation
+}
+
+// waitFor polls the cache until another replica stores a fresh value for key
+func (l *Loader) waitFor(ctx context.Context, key string) (string, bool) {
+	deadline := time.NewTimer(l.lockWait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(lockPollPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", false
+		case <-deadline.C:
+			return "", false
+		case <-ticker.C:
+			if e, ok := l.lookup(ctx, key); ok && time.Now().UnixNano() < e.FreshUntil {
+				return e.Value, true
+			}
+		}
+	}
+}
+
+func (l *Loader) lookup(ctx context.Context, key string) (entry, bool) {
+	var e entry
+	raw, err := l.cache.Get(ctx, key)
+	if err != nil {
+		return e, false
+	}
+	if err := json.Unmarshal([]byte(raw), &e); err != nil {
+		l.logger.Error("failed to unmarshal cache entry", zap.String("key", key), zap.Error(err))
+		return e, false
+	}
+	// values cached as plain JSON before entries existed decode to an empty
+	// entry, they are rebuilt instead of served
+	if e.Value == "" || e.FreshUntil == 0 {
+		return e, false
+	}
+	return e, true
+}
+
+func (l *Loader) store(ctx context.Context, key, value string, policy Policy) {
+	freshFor := policy.freshFor()
+	e := entry{
+		Value:      value,
+		FreshUntil: time.Now().Add(freshFor).UnixNano(),
+	}
+
+	raw, err := json.Marshal(e)
+	if err != nil {
+		l.logger.Error("failed to marshal cache entry", zap.String("key", key), zap.Error(err))
+		return
+	}
+
+	if err := l.cache.Set(ctx, key, string(raw), freshFor+policy.Stale); err != nil {
+		l.logger.Error("failed to cache value", zap.String("key", key), zap.Error(err))
+	}
+}
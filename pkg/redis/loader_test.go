@@ -0,0 +1,166 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// mapCache keeps values in memory and remembers the TTL of every write
+type mapCache struct {
+	mu     sync.Mutex
+	values map[string]string
+	ttls   map[string]time.Duration
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{values: make(map[string]string), ttls: make(map[string]time.Duration)}
+}
+
+func (c *mapCache) Get(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (c *mapCache) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key], c.ttls[key] = value, ttl
+	return nil
+}
+
+func (c *mapCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+	delete(c.ttls, key)
+	return nil
+}
+
+func (c *mapCache) GetInt(ctx context.Context, key string) (int, error) {
+	value, err := c.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+func (c *mapCache) SetInt(ctx context.Context, key string, value int, ttl time.Duration) error {
+	return c.Set(ctx, key, strconv.Itoa(value), ttl)
+}
+
+func (c *mapCache) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, _ := strconv.ParseInt(c.values[key], 10, 64)
+	n++
+	c.values[key], c.ttls[key] = strconv.FormatInt(n, 10), ttl
+	return n, nil
+}
+
+func (c *mapCache) ttl(key string) (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ttl, ok := c.ttls[key]
+	return ttl, ok
+}
+
+func TestInvalidateExpiresGeneration(t *testing.T) {
+	tests := []struct {
+		name   string
+		config LoaderConfig
+		want   time.Duration
+	}{
+		{"default", LoaderConfig{}, defaultGenerationTTL},
+		{"configured", LoaderConfig{GenerationTTL: 5 * time.Minute}, 5 * time.Minute},
+		{"shorter than the lock", LoaderConfig{GenerationTTL: time.Second, LockTTL: 10 * time.Second}, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newMapCache()
+			tt.config.Cache, tt.config.Logger = cache, zap.NewNop()
+			loader := NewLoader(tt.config)
+
+			if err := loader.Invalidate(context.Background(), "goods:1:1"); err != nil {
+				t.Fatal(err)
+			}
+			if ttl, ok := cache.ttl(generationKeyPrefix + "goods:1:1"); !ok || ttl != tt.want {
+				t.Errorf("generation kept for %v, want %v", ttl, tt.want)
+			}
+		})
+	}
+}
+
+// heldLocker makes the first Acquire wait for release and lose, like a lock
+// another replica holds, later ones win
+type heldLocker struct {
+	acquired chan struct{}
+	release  chan struct{}
+	once     sync.Once
+}
+
+func (l *heldLocker) Acquire(context.Context, string, time.Duration) (string, bool, error) {
+	first := false
+	l.once.Do(func() { first = true })
+	if !first {
+		return "token", true, nil
+	}
+	close(l.acquired)
+	<-l.release
+	return "", false, nil
+}
+
+func (l *heldLocker) Release(context.Context, string, string) error { return nil }
+
+func TestMissDuringRefresh(t *testing.T) {
+	ctx := context.Background()
+	cache := newMapCache()
+	locker := &heldLocker{acquired: make(chan struct{}), release: make(chan struct{})}
+	loader := NewLoader(LoaderConfig{Cache: cache, Locker: locker, Logger: zap.NewNop()})
+	policy := Policy{TTL: time.Minute, Stale: time.Minute}
+	load := func(context.Context) (string, error) { return "fresh", nil }
+
+	// an expired entry is served stale and refreshed in the background, the
+	// refresh waits for the lock
+	cache.Set(ctx, "goods:1:1", `{"v":"stale","f":1}`, time.Minute)
+	if v, status, err := loader.Get(ctx, "goods:1:1", policy, load); err != nil || status != StaleHit || v != "stale" {
+		t.Fatalf("Get = %q, %v, %v, want the stale value", v, status, err)
+	}
+	<-locker.acquired
+
+	// the entry is gone before the refresh finishes, the miss loads on its own
+	cache.Delete(ctx, "goods:1:1")
+	type result struct {
+		v   string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, _, err := loader.Get(ctx, "goods:1:1", policy, load)
+		done <- result{v, err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+		close(locker.release)
+	case <-time.After(100 * time.Millisecond):
+		// joined the refresh, which gives up once the lock is lost
+		close(locker.release)
+		res = <-done
+	}
+	if res.err != nil || res.v != "fresh" {
+		t.Errorf("Get during a refresh = %q, %v, want the loaded value", res.v, res.err)
+	}
+}
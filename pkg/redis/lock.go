@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Locker guards a key across replicas so only one of them rebuilds it
+type Locker interface {
+	Acquire(ctx context.Context, key string, ttl time.Duration) (string, bool, error)
+	Release(ctx context.Context, key, token string) error
+}
+
+// releaseScript deletes the lock only if it is still held by the caller's token
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type RedisLocker struct {
+	client *redis.Client
+}
+
+func NewRedisLocker(client *redis.Client) *RedisLocker {
+	return &RedisLocker{client: client}
+}
+
+// Acquire tries to take the lock once and returns the token needed to release it
+func (l *RedisLocker) Acquire(ctx context.Context, key string, ttl time.Duration) (string, bool, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false, err
+	}
+	token := hex.EncodeToString(buf)
+
+	ok, err := l.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return "", false, err
+	}
+	return token, true, nil
+}
+
+func (l *RedisLocker) Release(ctx context.Context, key, token string) error {
+	return releaseScript.Run(ctx, l.client, []string{key}, token).Err()
+}
@@ -1,23 +1,29 @@
 package redis
 
 import (
+	"fmt"
+
 	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
-	"github.com/spf13/viper"
-	"go.uber.org/zap"
 )
 
-func NewClient(logger *zap.Logger) *redis.Client {
+type ClientConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+func NewClient(config ClientConfig) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
-		Addr:     viper.GetString("rdb.host") + ":" + viper.GetString("rdb.port"),
-		Password: viper.GetString("rdb.password"),
-		DB:       viper.GetInt("rdb.dbname"),
+		Addr:     config.Addr,
+		Password: config.Password,
+		DB:       config.DB,
 	})
 
 	if err := redisotel.InstrumentTracing(client); err != nil {
-		logger.Fatal("failed to instrument redis tracing", zap.Error(err))
-		panic(err)
+		_ = client.Close()
+		return nil, fmt.Errorf("instrument redis tracing: %w", err)
 	}
 
-	return client
+	return client, nil
 }
@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const tagKeyPrefix = "tag:"
+
+// TaggedKey builds a cache key that embeds the current generation of every tag.
+// Bumping any of the tags with InvalidateTags makes the old key unreachable,
+// so cached entries don't have to be found and deleted one by one.
+func TaggedKey(ctx context.Context, cache Cache, key string, tags ...string) (string, error) {
+	var b strings.Builder
+	b.WriteString(key)
+
+	for _, tag := range tags {
+		generation, err := cache.GetInt(ctx, tagKeyPrefix+tag)
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return "", err
+		}
+		fmt.Fprintf(&b, ":%s@%d", tag, generation)
+	}
+
+	return b.String(), nil
+}
+
+// InvalidateTags bumps the generation of every tag. Tag generations never
+// expire, a tag starting over would make old keys reachable again
+func InvalidateTags(ctx context.Context, cache Cache, tags ...string) error {
+	for _, tag := range tags {
+		if _, err := cache.Incr(ctx, tagKeyPrefix+tag, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go-service/pkg/eventbus"
+	p "go-service/pkg/prometheus"
+)
+
+const (
+	defaultLocalTTL        = 10 * time.Second
+	defaultLocalMaxEntries = 10000
+)
+
+// Invalidation is broadcast to every replica when a key changes
+type Invalidation struct {
+	Key string `json:"key"`
+	// Source is the replica that deleted the key, it has evicted its copy
+	Source string `json:"source,omitempty"`
+}
+
+type TieredConfig struct {
+	// Remote is the shared L2 cache
+	Remote     *RedisCache
+	Bus        eventbus.Bus
+	Subject    string
+	TTL        time.Duration
+	MaxEntries int
+	Logger     *zap.Logger
+}
+
+// localItem is an L1 entry. An item without value is a tombstone that only
+// remembers the version of the last eviction of its key, values read from
+// redis under an older version are not kept.
+type localItem struct {
+	value     string
+	hasValue  bool
+	version   int64
+	expiresAt time.Time
+}
+
+// TieredCache keeps a small in-process L1 in front of redis. Deletes are
+// broadcast so every replica evicts its L1 copy. Versions are local to the
+// replica, nothing but the cached values is kept in redis.
+type TieredCache struct {
+	remote     *RedisCache
+	bus        eventbus.Bus
+	subject    string
+	ttl        time.Duration
+	maxEntries int
+	logger     *zap.Logger
+	// id tells the invalidations of this replica apart
+	id string
+
+	mu    sync.Mutex
+	local map[string]localItem
+	// version counts the evictions of this replica
+	version int64
+}
+
+func NewTieredCache(config TieredConfig) *TieredCache {
+	c := &TieredCache{
+		remote:     config.Remote,
+		bus:        config.Bus,
+		subject:    config.Subject,
+		ttl:        config.TTL,
+		maxEntries: config.MaxEntries,
+		logger:     config.Logger,
+		id:         newReplicaID(),
+		local:      make(map[string]localItem),
+	}
+	if c.ttl <= 0 {
+		c.ttl = defaultLocalTTL
+	}
+	if c.maxEntries <= 0 {
+		c.maxEntries = defaultLocalMaxEntries
+	}
+	return c
+}
+
+// Listen subscribes to the invalidations of every replica. Those of this
+// replica come back too and are skipped, Delete evicted the key already
+func (c *TieredCache) Listen(ctx context.Context) error {
+	return c.bus.Subscribe(ctx, c.subject, func(_ context.Context, msg eventbus.Message) error {
+		var inv Invalidation
+		if err := json.Unmarshal(msg.Data, &inv); err != nil {
+			return fmt.Errorf("unmarshal cache invalidation: %w", err)
+		}
+		if inv.Source == c.id {
+			p.CacheInvalidationsTotal.WithLabelValues("ignored").Inc()
+			return nil
+		}
+		c.evict(inv.Key)
+		return nil
+	})
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string) (string, error) {
+	c.mu.Lock()
+	item, ok := c.local[key]
+	c.mu.Unlock()
+
+	now := time.Now()
+	if ok && item.hasValue && now.Before(item.expiresAt) {
+		p.CacheTierHitsTotal.WithLabelValues("l1").Inc()
+		return item.value, nil
+	}
+	p.CacheTierMissesTotal.WithLabelValues("l1").Inc()
+
+	// remember which version we read under, an invalidation arriving while
+	// redis answers must win over the value we are about to store
+	version := item.version
+	value, err := c.remote.Get(ctx, key)
+	if err != nil {
+		p.CacheTierMissesTotal.WithLabelValues("l2").Inc()
+		return "", err
+	}
+	p.CacheTierHitsTotal.WithLabelValues("l2").Inc()
+
+	c.storeLocal(key, value, version)
+	return value, nil
+}
+
+func (c *TieredCache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	version := c.local[key].version
+	c.mu.Unlock()
+
+	if err := c.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	c.storeLocal(key, value, version)
+	return nil
+}
+
+// Delete removes key from redis and tells every replica to drop its L1 copy
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	if err := c.remote.Delete(ctx, key); err != nil {
+		return err
+	}
+	c.evict(key)
+
+	return c.bus.Publish(ctx, c.subject, Invalidation{Key: key, Source: c.id})
+}
+
+func (c *TieredCache) GetInt(ctx context.Context, key string) (int, error) {
+	return c.remote.GetInt(ctx, key)
+}
+
+func (c *TieredCache) SetInt(ctx context.Context, key string, value int, ttl time.Duration) error {
+	return c.remote.SetInt(ctx, key, value, ttl)
+}
+
+func (c *TieredCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return c.remote.Incr(ctx, key, ttl)
+}
+
+// evict drops the L1 copy of key and leaves a tombstone with a new version,
+// so a Get that read redis before the eviction doesn't store its value
+func (c *TieredCache) evict(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	c.local[key] = localItem{version: c.version, expiresAt: time.Now().Add(c.ttl)}
+	p.CacheInvalidationsTotal.WithLabelValues("evicted").Inc()
+}
+
+func (c *TieredCache) storeLocal(key, value string, version int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if current, ok := c.local[key]; ok && current.version != version {
+		return
+	}
+	if len(c.local) >= c.maxEntries {
+		c.prune()
+	}
+
+	c.local[key] = localItem{
+		value:     value,
+		hasValue:  true,
+		version:   version,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+}
+
+// prune drops expired items and, if that is not enough, an arbitrary one
+func (c *TieredCache) prune() {
+	now := time.Now()
+	for key, item := range c.local {
+		if now.After(item.expiresAt) {
+			delete(c.local, key)
+		}
+	}
+	for key := range c.local {
+		if len(c.local) < c.maxEntries {
+			return
+		}
+		delete(c.local, key)
+	}
+}
+
+func newReplicaID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(buf)
+}
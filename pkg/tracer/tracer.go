@@ -1,54 +1,202 @@
 package tracer
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sync/atomic"
 
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
-// NewJaegerExporter creates new Jaeger span exporter
-//
-// url example - http://localhost:14268/api/traces
-func NewJaegerExporter(url string) (tracesdk.SpanExporter, error) {
-	return jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(url)))
+const (
+	ExporterOTLPGRPC = "otlp-grpc"
+	ExporterOTLPHTTP = "otlp-http"
+	ExporterStdout   = "stdout"
+	ExporterNone     = "none"
+)
+
+const (
+	SamplerAlways      = "always"
+	SamplerNever       = "never"
+	SamplerRatio       = "ratio"
+	SamplerParentBased = "parent-based"
+)
+
+type Config struct {
+	// Exporter is one of otlp-grpc, otlp-http, stdout or none
+	Exporter string
+	// Endpoint is host:port of the collector, e.g. localhost:4317 for otlp-grpc
+	Endpoint string
+	Insecure bool
+	// Sampler is one of always, never, ratio or parent-based
+	Sampler string
+	// Ratio is the fraction of traces sampled by ratio and parent-based samplers
+	Ratio float64
+
+	ServiceName string
+	Version     string
+	Environment string
+	// Instance defaults to the host name
+	Instance string
+	// Global installs the provider and the W3C propagators as the OpenTelemetry
+	// globals the HTTP, gRPC, Redis and NATS instrumentation uses. Leave it
+	// off when several providers share a process, like in tests
+	Global bool
 }
 
-// NewTraceProvider creates new trace provider which will create tracers
-func NewTraceProvider(exp tracesdk.SpanExporter, ServiceName string) (*tracesdk.TracerProvider, error) {
-	r, err := resource.Merge(
+// ShutdownFunc flushes buffered spans and stops the exporter
+type ShutdownFunc func(ctx context.Context) error
+
+// NewExporter creates span exporter by name, nil exporter means spans are not exported
+func NewExporter(ctx context.Context, config Config) (tracesdk.SpanExporter, error) {
+	switch config.Exporter {
+	case ExporterOTLPGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(config.Endpoint)}
+		if config.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+		if config.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterNone, "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown exporter %q", config.Exporter)
+	}
+}
+
+// NewSampler creates sampler by name
+func NewSampler(config Config) (tracesdk.Sampler, error) {
+	switch config.Sampler {
+	case SamplerAlways, "":
+		return tracesdk.AlwaysSample(), nil
+	case SamplerNever:
+		return tracesdk.NeverSample(), nil
+	case SamplerRatio:
+		return tracesdk.TraceIDRatioBased(config.Ratio), nil
+	case SamplerParentBased:
+		return tracesdk.ParentBased(tracesdk.TraceIDRatioBased(config.Ratio)), nil
+	default:
+		return nil, fmt.Errorf("unknown sampler %q", config.Sampler)
+	}
+}
+
+// Sampler wraps the configured sampler so its ratio can change while the
+// service runs, the sampler kind stays fixed
+type Sampler struct {
+	config  Config
+	current atomic.Pointer[tracesdk.Sampler]
+}
+
+func NewReloadableSampler(config Config) (*Sampler, error) {
+	s := &Sampler{config: config}
+	if err := s.SetRatio(config.Ratio); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetRatio swaps in a sampler of the same kind with the new ratio
+func (s *Sampler) SetRatio(ratio float64) error {
+	config := s.config
+	config.Ratio = ratio
+
+	sampler, err := NewSampler(config)
+	if err != nil {
+		return err
+	}
+	s.current.Store(&sampler)
+	return nil
+}
+
+func (s *Sampler) ShouldSample(p tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	return (*s.current.Load()).ShouldSample(p)
+}
+
+func (s *Sampler) Description() string {
+	return (*s.current.Load()).Description()
+}
+
+// NewResource describes this process in every exported span
+func NewResource(config Config) (*resource.Resource, error) {
+	instance := config.Instance
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+
+	return resource.Merge(
 		resource.Default(),
 		resource.NewWithAttributes(
 			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(ServiceName),
+			semconv.ServiceNameKey.String(config.ServiceName),
+			semconv.ServiceVersionKey.String(config.Version),
+			semconv.DeploymentEnvironmentKey.String(config.Environment),
+			semconv.ServiceInstanceIDKey.String(instance),
 		),
 	)
-	if err != nil {
-		return nil, err
-	}
+}
 
-	return tracesdk.NewTracerProvider(
-		tracesdk.WithBatcher(exp),
+// NewTraceProvider creates new trace provider which will create tracers
+func NewTraceProvider(exp tracesdk.SpanExporter, sampler tracesdk.Sampler, r *resource.Resource) *tracesdk.TracerProvider {
+	opts := []tracesdk.TracerProviderOption{
+		tracesdk.WithSampler(sampler),
 		tracesdk.WithResource(r),
-	), nil
+	}
+	if exp != nil {
+		opts = append(opts, tracesdk.WithBatcher(exp))
+	}
+
+	return tracesdk.NewTracerProvider(opts...)
 }
 
-func InitTracer(jaegerURL string, serviceName string) (trace.Tracer, error) {
-	exporter, err := NewJaegerExporter(jaegerURL)
+// InitTracer creates the trace provider, installed globally only with
+// config.Global. The returned sampler changes its sampling ratio at runtime
+func InitTracer(ctx context.Context, config Config) (trace.Tracer, *Sampler, ShutdownFunc, error) {
+	exporter, err := NewExporter(ctx, config)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create %s exporter: %w", config.Exporter, err)
+	}
+
+	sampler, err := NewReloadableSampler(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create jaeger exporter: %w", err)
+		return nil, nil, nil, errors.Join(fmt.Errorf("failed to create sampler: %w", err), shutdownExporter(ctx, exporter))
 	}
 
-	tp, err := NewTraceProvider(exporter, serviceName)
+	r, err := NewResource(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create trace provider: %w", err)
+		return nil, nil, nil, errors.Join(fmt.Errorf("failed to create resource: %w", err), shutdownExporter(ctx, exporter))
 	}
 
-	otel.SetTracerProvider(tp)
+	tp := NewTraceProvider(exporter, sampler, r)
 
-	return tp.Tracer("main tracer"), nil
+	if config.Global {
+		otel.SetTracerProvider(tp)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	}
+
+	return tp.Tracer("main tracer"), sampler, tp.Shutdown, nil
+}
+
+// shutdownExporter closes the connection of an exporter no provider took over
+func shutdownExporter(ctx context.Context, exporter tracesdk.SpanExporter) error {
+	if exporter == nil {
+		return nil
+	}
+	return exporter.Shutdown(ctx)
 }